@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ngavinsir/sqluy/editor"
+	"github.com/ngavinsir/sqluy/event"
+)
+
+// announceBuffer is the number of lines waiting for the announce file reader, the next ones are dropped
+const announceBuffer = 256
+
+// startAnnouncing appends the state changes to the announce file for screen readers. The file is opened in the
+// background, opening a fifo waits for its reader.
+func (a *App) startAnnouncing() {
+	if a.cfg.Announce == "" {
+		return
+	}
+	a.announcements = make(chan string, announceBuffer)
+	a.announced = make(map[string]string)
+
+	go func() {
+		f, err := os.OpenFile(a.cfg.Announce, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			log.Printf("announce: error opening %s: %s", a.cfg.Announce, err)
+			return
+		}
+		defer f.Close()
+
+		for {
+			select {
+			case <-a.ctx.Done():
+				return
+			case line := <-a.announcements:
+				_, err := f.WriteString(line + "\n")
+				if err != nil {
+					log.Printf("announce: error writing %s: %s", a.cfg.Announce, err)
+					return
+				}
+			}
+		}
+	}()
+
+	event.Subscribe(a.bus, func(e event.QueryStarted) {
+		a.announce("executing query")
+	})
+	event.Subscribe(a.bus, func(e event.QueryFinished) {
+		switch {
+		case errors.Is(e.Err, context.Canceled):
+			a.announce("query canceled")
+		case e.Err != nil:
+			a.announce("query failed: " + e.Err.Error())
+		default:
+			a.announce(fmt.Sprintf("query done, %d rows in %s", len(e.Rows), e.Duration.Round(time.Millisecond)))
+		}
+	})
+	event.Subscribe(a.bus, func(e event.ConnectionChanged) {
+		a.announce("connected to " + e.Path)
+	})
+}
+
+// announce queues the line for the announce file, dropping it when the reader is too slow.
+func (a *App) announce(line string) {
+	if a.announcements == nil {
+		return
+	}
+	select {
+	case a.announcements <- line:
+	default:
+	}
+}
+
+// announceState announces the parts of the state that changed since the last draw: the focused pane,
+// the mode and cursor of the focused editor, the cell under the results cursor, and the status message.
+func (a *App) announceState() {
+	if a.announcements == nil {
+		return
+	}
+
+	pane := a.panes[a.currentView]
+	state := [][2]string{{"focus", pane.name + " focused"}}
+	if e, ok := pane.primitive.(*editor.Editor); ok {
+		cursor := e.GetCursor()
+		state = append(state,
+			[2]string{"mode", e.ModeName() + " mode"},
+			[2]string{"cursor", fmt.Sprintf("line %d column %d", cursor[0]+1, cursor[1]+1)},
+		)
+	} else if pane.name == "results" {
+		cell := "no results"
+		if header, value, ok := a.dataviewer.GetCurrentCell(); ok {
+			cell = fmt.Sprintf("row %d of %d, %s: %s", a.dataviewer.GetCursor()[0], a.tabStates[a.currentTab].pageRowCount, header, value)
+			if a.dataviewer.GetCursor()[0] == 0 {
+				cell = "header " + header
+			}
+		}
+		state = append(state, [2]string{"cell", cell})
+	}
+
+	// a newly focused pane is announced with its whole state
+	if a.announced["focus"] != state[0][1] {
+		clear(a.announced)
+	}
+	for _, s := range state {
+		if a.announced[s[0]] != s[1] {
+			a.announced[s[0]] = s[1]
+			a.announce(s[1])
+		}
+	}
+	// a message is announced again when it's set again
+	if a.statusTime != a.announcedStatusTime && a.statusMessage != "" {
+		a.announcedStatusTime = a.statusTime
+		a.announce(a.statusMessage)
+	}
+}
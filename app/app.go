@@ -3,56 +3,117 @@ package app
 import (
 	"context"
 	_ "embed"
+	"errors"
+	"fmt"
 	"log"
-	"slices"
-	"sort"
+	"maps"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/ngavinsir/sqluy/command"
+	"github.com/ngavinsir/sqluy/config"
 	"github.com/ngavinsir/sqluy/dataviewer"
 	"github.com/ngavinsir/sqluy/editor"
+	"github.com/ngavinsir/sqluy/event"
 	"github.com/ngavinsir/sqluy/fetcher"
 	"github.com/ngavinsir/sqluy/keymap"
-	"github.com/ngavinsir/sqluy/modal"
+	"github.com/ngavinsir/sqluy/plugin"
+	"github.com/ngavinsir/sqluy/theme"
 	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
 )
 
 type (
-	showModalArg struct {
-		refocus tview.Primitive
-		text    string
-	}
-
-	delayDrawArg struct {
-		when time.Time
-		fn   func()
-	}
-
 	tabState struct {
 		headers         []string
 		rows            [][]string
 		executionStart  time.Time
 		executionFinish time.Time
-		status          TabStatus
-		query           string
-		ctx             context.Context
+		// previousDuration is the duration of the query when it was executed from the history
+		previousDuration time.Duration
+		status           TabStatus
+		query            string
+		// shownQuery is the query of the data shown in the dataviewer
+		shownQuery   string
+		page         int
+		pageRowCount int
+		// sortHeader is the column the query is ordered by with the server sort, reset by another query
+		sortHeader string
+		sortDesc   bool
+		// keyset is set when the sort column is the key of the rows, the pages then start after
+		// the last key of the page before instead of at an offset
+		keyset bool
+		// keysetAfter holds the last key of every page before the current one
+		keysetAfter []string
+		// lastKey is the key of the last row of the page, missing when it's NULL
+		lastKey   string
+		lastKeyOK bool
+		ctx       context.Context
+		cancel    context.CancelFunc
 	}
 
 	App struct {
 		*tview.Pages
-		ctx           context.Context
-		app           *tview.Application
-		tabStates     []*tabState
-		currentTab    int
-		statusText    *tview.TextView
-		currentView   int
-		views         []*tview.Box
-		wg            *sync.WaitGroup
-		delayDrawChan chan (delayDrawArg)
-		showModalChan chan (showModalArg)
-		mainModal     *tview.Modal
-		focusDelegate func(tview.Primitive)
+		ctx          context.Context
+		app          *tview.Application
+		tabStates    []*tabState
+		currentTab   int
+		statusText   *tview.TextView
+		currentView  int
+		previousView int
+		panes        []pane
+		wg           *sync.WaitGroup
+		bus          *event.Bus
+		// modals are the modals waiting to be shown, the first one is shown
+		modals     []event.Modal
+		modalMutex sync.Mutex
+		draws      *drawQueue
+		// nextFrame is when the next spinner frame is drawn
+		nextFrame time.Time
+		// screen is the screen of the last draw, the status bar is redrawn alone on it
+		screen  tcell.Screen
+		plugins []*plugin.Plugin
+		// pluginKeys maps the keys registered by the plugins to the command line they run
+		pluginKeys map[string]string
+		// announcements are the lines waiting to be appended to the announce file, nil when it's disabled
+		announcements       chan string
+		announced           map[string]string
+		announcedStatusTime time.Time
+		mainModal           *tview.Modal
+		focusDelegate       func(tview.Primitive)
+		cfg                 config.Config
+		keymap              keymap.Keymapper
+		commands            *command.Registry
+		editor              *editor.Editor
+		editorFlex          *tview.Flex
+		splitView           *editor.Editor
+		dataviewer          *dataviewer.Dataviewer
+		dataviewerPage      *tview.Pages
+		dataviewerFlex      *tview.Flex
+		executionStatus     *tview.TextView
+		flex                *tview.Flex
+		fetcher             fetcher.SqliteFetcher
+		columnWidths        config.ColumnWidths
+		bookmarks           config.Bookmarks
+		recent              config.Recent
+		schema              fetcher.Schema
+		schemaCancel        context.CancelFunc
+		schemaStart         time.Time
+		schemaLoading       atomic.Bool
+		dashboard           tview.Primitive
+		statusMessage       string
+		fileName            string
+		dirty               bool
+		autosaved           bool
+		statusTime          time.Time
+		history             []historyEntry
+		// variables are the session variables set with \set, used as :name in queries
+		variables map[string]string
 	}
 )
 
@@ -63,13 +124,32 @@ const (
 	TabStatusExecuting
 )
 
+const (
+	statusMessageDuration = 3 * time.Second
+	spinnerInterval       = 100 * time.Millisecond
+	// minWidth and minHeight is the smallest size the panes are drawn at, a smaller terminal shows a placeholder
+	minWidth  = 20
+	minHeight = 10
+)
+
+var spinnerFrames = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
 //go:embed keymap.json
 var keymapString string
 
-func New(ctx context.Context, wg *sync.WaitGroup, app *tview.Application) *App {
+func New(ctx context.Context, wg *sync.WaitGroup, app *tview.Application, cfg config.Config) *App {
 	km := keymap.New(keymapString)
-	showModalChan := make(chan showModalArg)
-	delayDrawChan := make(chan delayDrawArg)
+	// the user keymap is merged over the defaults, its keys replace the default bindings
+	userKeymap, err := config.LoadKeymap()
+	if err != nil {
+		log.Println(err)
+	}
+	if userKeymap != "" {
+		err = km.Merge(userKeymap)
+		if err != nil {
+			log.Println(err)
+		}
+	}
 
 	mainPage := tview.NewPages()
 	dataviewerPage := tview.NewPages()
@@ -82,216 +162,629 @@ func New(ctx context.Context, wg *sync.WaitGroup, app *tview.Application) *App {
 				ctx: context.Background(),
 			},
 		},
-		statusText:    tview.NewTextView(),
-		ctx:           ctx,
-		app:           app,
-		mainModal:     tview.NewModal().AddButtons([]string{"Ok"}),
-		showModalChan: showModalChan,
-		delayDrawChan: delayDrawChan,
+		statusText: tview.NewTextView(),
+		ctx:        ctx,
+		app:        app,
+		mainModal:  tview.NewModal().AddButtons([]string{"Ok"}),
+		bus:        event.NewBus(),
+		draws:      newDrawQueue(),
+		cfg:        cfg,
+		keymap:     km,
+		commands:   command.NewRegistry(),
+		variables:  map[string]string{},
+		pluginKeys: map[string]string{},
 	}
 
-	d := dataviewer.New(km)
+	columnWidths, err := config.LoadColumnWidths()
+	if err != nil {
+		log.Println(err)
+	}
+	a.columnWidths = columnWidths
 
-	dataviewerModal := modal.NewModal().AddButtons([]string{"Cancel"}).SetBackgroundColor(tcell.ColorBlack)
-	dataviewerModal.SetBorderColor(tcell.ColorBlack)
-	dataviewerModal.Box.SetBackgroundColor(tcell.ColorBlack)
+	bookmarks, err := config.LoadBookmarks()
+	if err != nil {
+		log.Println(err)
+	}
+	a.bookmarks = bookmarks
+
+	// time location is already validated on config load
+	timeLocation, _ := cfg.Dataviewer.TimeLocation()
+	d := dataviewer.New(km,
+		dataviewer.WithTimeFormat(cfg.Dataviewer.TimeFormat),
+		dataviewer.WithTimeLocation(timeLocation),
+		dataviewer.WithRawOrder(cfg.Dataviewer.RawOrder),
+		dataviewer.WithHeaderLines(cfg.Dataviewer.HeaderLines),
+		dataviewer.WithFlash(cfg.Flash),
+	)
 
 	dataviewerPage.AddPage("main", d, true, true)
-	dataviewerPage.AddPage("modal", dataviewerModal, true, false)
 
-	sqliteFetcher := fetcher.NewSqliteFetcher()
+	executionStatus := tview.NewTextView().SetTextColor(theme.Current().Accent)
+	dataviewerFlex := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(dataviewerPage, 0, 1, false).
+		AddItem(executionStatus, 0, 0, false)
+
+	sqliteFetcher, err := fetcher.NewSqliteFetcher(cfg.Connection)
+	if err != nil {
+		panic(err)
+	}
+	recent, err := config.LoadRecent()
+	if err != nil {
+		log.Println(err)
+	}
+	a.recent = recent
+	a.addRecent(func(r *config.Recent) { r.AddConnection(cfg.Connection) })
 
 	flex := tview.NewFlex().SetDirection(tview.FlexRow)
 	e := editor.New(
 		editor.WithKeymapper(km),
+		editor.WithOptions(cfg.Editor),
+		editor.WithFlash(cfg.Flash),
 		editor.WithDoneFunc(func(e *editor.Editor, s string) {
 			tabState := a.tabStates[a.currentTab]
 			if tabState.status != TabStatusEditing {
+				a.setStatusMessage("query is still executing, ctrl+c to cancel")
 				return
 			}
-			tabState.executionStart = time.Now()
-			tabState.status = TabStatusExecuting
-			e.SetDisabled(true)
-			dataviewerPage.ShowPage("modal")
-
-			go func() {
-				cols, rows, err := sqliteFetcher.Select(tabState.ctx, s)
-				executionFinish := time.Now()
-
-				app.QueueUpdateDraw(func() {
-					if err != nil {
-						showModalChan <- showModalArg{text: err.Error(), refocus: flex}
-					} else {
-						d.SetData(cols, rows)
-						if a.focusDelegate != nil {
-							a.currentView = 1
-							a.Focus(a.focusDelegate)
-						}
-					}
-
-					tabState.status = TabStatusEditing
-					tabState.executionFinish = executionFinish
-					e.SetDisabled(false)
-					dataviewerPage.HidePage("modal")
-				})
-			}()
+			query, err := a.runMetaCommands(s)
+			if err != nil {
+				a.setStatusMessage(err.Error())
+				return
+			}
+			if strings.TrimSpace(query) == "" {
+				return
+			}
+			tabState.query = substituteVariables(query, a.variables)
+			tabState.page = 0
+			a.execute(tabState)
 		}),
 	)
 	e.SetViewModalFunc(func(text string) {
-		showModalChan <- showModalArg{text: text, refocus: e}
+		a.bus.Publish(event.Modal{Text: text, Refocus: e})
+	})
+	e.SetStatusFunc(a.setStatusMessage)
+	e.SetCommandFunc(a.runCommand)
+	e.SetCompleteFunc(a.commands.CompleteLine)
+	e.OnChange(func(editor.Change) {
+		a.dirty = true
+		a.autosaved = false
 	})
+	e.SetStatuslineFunc("file", func() string { return a.fileName })
+	e.SetStatuslineFunc("dirty", func() string { return a.statuslineSegment("dirty") })
+	e.SetStatuslineFunc("connection", func() string { return a.fetcher.Name() })
 	e.SetDelayDrawFunc(func(t time.Time, fn func()) {
-		delayDrawChan <- delayDrawArg{when: t, fn: fn}
+		a.bus.Publish(event.DrawAt{When: t, Fn: fn})
 	})
 
+	editorFlex := tview.NewFlex().AddItem(e, 0, 1, true)
 	flex.
-		AddItem(e, 0, 1, true).
+		AddItem(editorFlex, 0, 1, true).
 		AddItem(a.statusText, 1, 0, false).
-		AddItem(dataviewerPage, 0, 1, false)
+		AddItem(dataviewerFlex, 0, 1, false)
 
 	mainPage.AddPage("main", flex, true, true)
 	mainPage.AddPage("modal", a.mainModal, true, false)
 
-	a.views = []*tview.Box{e.Box, d.Box}
+	d.SetEditRowFunc(func(headers []string, row map[string]string) {
+		identity, err := a.dmlIdentity(a.tabStates[a.currentTab], headers, []map[string]string{row})
+		if err != nil {
+			a.bus.Publish(event.Modal{Text: err.Error(), Refocus: d})
+			return
+		}
+
+		form := dataviewer.NewRowForm(identity.Table, identity.Keys, headers, row)
+		form.SetDoneFunc(func(query, countQuery string) {
+			dataviewerPage.RemovePage("form")
+			a.FocusPane("results")
+			a.previewDML(query, countQuery)
+		})
+		form.SetCancelFunc(func() {
+			dataviewerPage.RemovePage("form")
+			a.FocusPane("results")
+		})
+		dataviewerPage.AddPage("form", form, true, true)
+		app.SetFocus(form)
+	})
+
+	d.SetInspectFunc(func(header, value string) {
+		inspector := dataviewer.NewInspector(header, value)
+		inspector.SetDoneFunc(func() {
+			dataviewerPage.RemovePage("inspector")
+			a.FocusPane("results")
+		})
+		inspector.SetSaveFunc(func(header, value string) {
+			// the header is only a suggested name, a header like ../x mustn't pick the directory
+			name := strings.Map(func(r rune) rune {
+				if r == '/' || r == '\\' || r == '.' || unicode.IsControl(r) {
+					return '_'
+				}
+				return r
+			}, header)
+			a.pickFile("save value", fmt.Sprintf("%s-%d.bin", name, time.Now().Unix()), func(filename string) error {
+				err := os.WriteFile(filename, []byte(value), 0o644)
+				if err != nil {
+					return err
+				}
+				a.setStatusMessage("saved to " + filename)
+				return nil
+			})
+		})
+		dataviewerPage.AddPage("inspector", inspector, true, true)
+		app.SetFocus(inspector)
+	})
+
+	d.SetCommandFunc(a.runCommand)
+
+	d.SetExportFunc(func(headers []string, rows []map[string]string) {
+		a.pickFile("export", fmt.Sprintf("export-%d.csv", time.Now().Unix()), func(filename string) error {
+			return a.writeRows(filename, headers, rows)
+		})
+	})
+
+	d.SetDeleteRowsFunc(func(headers []string, rows []map[string]string) {
+		identity, err := a.dmlIdentity(a.tabStates[a.currentTab], headers, rows)
+		if err != nil {
+			a.bus.Publish(event.Modal{Text: err.Error(), Refocus: d})
+			return
+		}
+
+		queries := make([]string, len(rows))
+		for i, row := range rows {
+			queries[i] = dataviewer.DeleteQuery(identity.Table, identity.Keys, row)
+		}
+		a.previewDML(strings.Join(queries, "\n"), dataviewer.CountQuery(identity.Table, identity.Keys, rows))
+	})
+
+	d.SetFrequencyFunc(func(header string, headers []string, rows []map[string]string) {
+		fd := dataviewer.New(km)
+		fd.SetTitle("Frequency of " + header)
+		fd.SetData(headers, rows)
+		fd.SetExitFunc(func() {
+			dataviewerPage.RemovePage("frequency")
+			a.FocusPane("results")
+		})
+		dataviewerPage.AddPage("frequency", fd, true, true)
+		app.SetFocus(fd)
+	})
+
+	filterHistory := editor.NewHistory()
+	d.SetFilterPromptFunc(func(header, expr string) {
+		exit := func() {
+			dataviewerPage.RemovePage("filter")
+			a.FocusPane("results")
+		}
+		input := editor.NewPrompt(header+" ",
+			editor.WithKeymapper(km),
+			editor.WithHistory(filterHistory),
+			editor.WithValidateFunc(func(s string) error {
+				if strings.TrimSpace(s) == "" {
+					return nil
+				}
+				_, err := dataviewer.ParseFilter(s)
+				return err
+			}),
+			editor.WithDoneFunc(func(_ *editor.Editor, s string) {
+				exit()
+				err := d.SetFilter(header, s)
+				if err != nil {
+					a.bus.Publish(event.Modal{Text: err.Error(), Refocus: d})
+				}
+			}),
+		)
+		input.SetExitFunc(exit)
+		input.SetText(expr, [2]int{0, uniseg.GraphemeClusterCount(expr)})
+
+		layout := tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(input, 1, 0, true)
+		dataviewerPage.AddPage("filter", layout, true, true)
+		app.SetFocus(input)
+	})
+
+	d.SetColumnWidthFunc(func(widths map[string]int) {
+		a.columnWidths[columnWidthsKey(a.tabStates[a.currentTab].query)] = widths
+		err := a.columnWidths.Save()
+		if err != nil {
+			a.bus.Publish(event.Modal{Text: err.Error(), Refocus: d})
+		}
+	})
+
+	d.SetPageFunc(func(delta int) {
+		tabState := a.tabStates[a.currentTab]
+		if tabState.status != TabStatusEditing || tabState.query == "" {
+			return
+		}
+		// there's no next page if the current page is not full
+		if delta > 0 && tabState.pageRowCount < a.cfg.Dataviewer.PageSize {
+			return
+		}
+		if tabState.page+delta < 0 {
+			return
+		}
+		// a NULL last key has no page after it, the offset gives the same page
+		if tabState.keyset && delta > 0 && !tabState.lastKeyOK {
+			tabState.keyset = false
+		}
+		if tabState.keyset {
+			if delta > 0 {
+				tabState.keysetAfter = append(tabState.keysetAfter, tabState.lastKey)
+			} else {
+				tabState.keysetAfter = tabState.keysetAfter[:len(tabState.keysetAfter)-1]
+			}
+		}
+		tabState.page += delta
+		a.execute(tabState)
+	})
+
+	d.SetServerSortFunc(func(header string, desc bool) {
+		tabState := a.tabStates[a.currentTab]
+		if tabState.status != TabStatusEditing || tabState.shownQuery == "" {
+			return
+		}
+		if _, ok := a.fetcher.SortQuery(tabState.shownQuery, header, desc); !ok {
+			a.setStatusMessage("only a single select query can be sorted by the server")
+			return
+		}
+		tabState.query = tabState.shownQuery
+		tabState.sortHeader, tabState.sortDesc = header, desc
+		// only the key column resolves to a row identity of the column alone
+		identity, err := a.rowIdentity(tabState, []string{header})
+		tabState.keyset = err == nil && len(identity.Keys) > 0
+		tabState.page = 0
+		a.execute(tabState)
+	})
+
+	a.editor = e
+	a.editorFlex = editorFlex
+	a.dataviewer = d
+	a.dataviewerPage = dataviewerPage
+	a.dataviewerFlex = dataviewerFlex
+	a.executionStatus = executionStatus
+	a.flex = flex
+	a.fetcher = sqliteFetcher
+	a.setPanes(map[string]pane{
+		"editor":  {box: e.Box, primitive: e},
+		"results": {box: d.Box, primitive: dataviewerPage},
+		"schema":  a.newSchemaPane(),
+		"history": a.newHistoryPane(),
+	})
+	a.registerCommands()
+	a.startPlugins()
+	a.refreshSchema()
+	a.applyDialect()
+	if cfg.Dashboard {
+		a.showDashboard()
+	}
 
-	go a.modalLoop()
+	a.subscribe()
+	a.startAnnouncing()
 	go a.drawLoop()
+	if cfg.Autosave > 0 {
+		go a.autosaveLoop()
+	}
+	a.runStartup()
 
 	return &a
 }
 
-func (a *App) FocusViewIndex(index int) {
-	if index < 0 {
-		index = len(a.views) - 1
+// execute runs the tab query in the background, showing the result in the dataviewer.
+// Select queries are paginated when page size is configured.
+func (a *App) execute(tabState *tabState) {
+	if tabState.query != tabState.shownQuery {
+		tabState.sortHeader, tabState.sortDesc, tabState.keyset = "", false, false
 	}
-	if index >= len(a.views) {
-		index = 0
+	query := tabState.query
+	if tabState.sortHeader != "" {
+		sorted, ok := a.fetcher.SortQuery(query, tabState.sortHeader, tabState.sortDesc)
+		if ok {
+			query = sorted
+		} else {
+			tabState.sortHeader, tabState.sortDesc, tabState.keyset = "", false, false
+		}
+	}
+	if tabState.page == 0 {
+		tabState.keysetAfter = nil
+	}
+	pageSize := a.cfg.Dataviewer.PageSize
+	paginated := false
+	if pageSize > 0 {
+		var paged string
+		if tabState.keyset && tabState.page > 0 {
+			paged, paginated = a.fetcher.KeysetQuery(tabState.query, tabState.sortHeader, tabState.keysetAfter[tabState.page-1], tabState.sortDesc, pageSize)
+		} else {
+			paged, paginated = a.fetcher.PageQuery(query, pageSize, tabState.page*pageSize)
+		}
+		if paginated {
+			query = paged
+		}
 	}
 
-	a.currentView = index
-	a.app.SetFocus(a.views[index])
-}
+	ctx, cancel := context.WithCancel(tabState.ctx)
+	tabState.cancel = cancel
+	tabState.executionStart = time.Now()
+	tabState.previousDuration = 0
+	tabState.status = TabStatusExecuting
+	a.dataviewerFlex.ResizeItem(a.executionStatus, 1, 0)
+	a.bus.Publish(event.QueryStarted{Query: tabState.query})
+
+	// a refresh of the shown query keeps the cursor on its row, found by the row identity keys
+	refresh := tabState.query == tabState.shownQuery
+	showResults := func(cols, types []string, rows []map[string]string, keys []string) {
+		tabState.pageRowCount = len(rows)
+		title := "Dataviewer"
+		if paginated {
+			title = fmt.Sprintf("Dataviewer (page %d)", tabState.page+1)
+		}
+		a.dataviewer.SetTitle(title)
+		a.dataviewer.SetColumnWidths(maps.Clone(a.columnWidths[columnWidthsKey(tabState.query)]))
+		a.dataviewer.SetColumnTypes(types)
+		if refresh {
+			a.dataviewer.ReplaceData(cols, rows, keys)
+		} else {
+			a.dataviewer.SetData(cols, rows)
+		}
+		a.dataviewer.SetServerSort(tabState.sortHeader, tabState.sortDesc)
+		tabState.shownQuery = tabState.query
+	}
 
-func (a *App) drawLoop() {
-	a.wg.Add(1)
-	defer a.wg.Done()
+	go func() {
+		defer cancel()
+		// the rows of a new query are shown as they arrive, a refresh waits for all of them to keep the cursor row
+		var cols, types []string
+		var rows []map[string]string
+		shown := false
+		err := a.fetcher.SelectBatches(ctx, query, func(c, t []string, batch []map[string]string) {
+			cols, types = c, t
+			rows = append(rows, batch...)
+			if refresh {
+				return
+			}
+			first := !shown
+			shown = true
+			a.app.QueueUpdateDraw(func() {
+				if first {
+					showResults(c, t, batch, nil)
+					return
+				}
+				a.dataviewer.AppendRows(batch)
+				tabState.pageRowCount += len(batch)
+			})
+		})
+		executionFinish := time.Now()
+		var keys []string
+		if refresh && err == nil {
+			identity, _ := a.rowIdentity(tabState, cols)
+			keys = identity.Keys
+		}
 
-	t := time.NewTicker(10 * time.Millisecond)
-	defer t.Stop()
+		a.app.QueueUpdateDraw(func() {
+			if errors.Is(err, context.Canceled) && shown {
+				a.setStatusMessage(fmt.Sprintf("query canceled, %d rows shown", tabState.pageRowCount))
+			} else if errors.Is(err, context.Canceled) {
+				a.setStatusMessage("query canceled")
+			} else if err != nil {
+				a.bus.Publish(event.Modal{Text: err.Error(), Refocus: a.flex})
+			} else {
+				if refresh {
+					showResults(cols, types, rows, keys)
+				}
+				if tabState.keyset && len(rows) > 0 {
+					tabState.lastKey, tabState.lastKeyOK = rows[len(rows)-1][tabState.sortHeader]
+				}
+				// a full page isn't known to be the last one
+				if paginated && len(rows) == pageSize {
+					a.dataviewer.SetTitle(fmt.Sprintf("Dataviewer (page %d, more on the next page)", tabState.page+1))
+				}
+				if a.overMemoryLimit() {
+					a.bus.Publish(event.Modal{
+						Text: fmt.Sprintf("The results use about %s, over the %d MB memory limit. :truncate keeps the rows under it.",
+							formatBytes(a.dataviewer.MemoryUsage()), a.cfg.Dataviewer.MemoryLimit),
+						Refocus: a.dataviewer,
+					})
+				}
+				if a.focusDelegate != nil {
+					a.FocusPane("results")
+				}
+			}
 
-	var args []delayDrawArg
-	for {
-		select {
-		case <-a.ctx.Done():
-			return
-		case arg := <-a.delayDrawChan:
-			args = append(args, arg)
-			sort.Slice(args, func(i, j int) bool {
-				return args[i].when.Before(args[j].when)
+			tabState.status = TabStatusEditing
+			tabState.executionFinish = executionFinish
+			tabState.cancel = nil
+			a.dataviewerFlex.ResizeItem(a.executionStatus, 0, 0)
+			a.bus.Publish(event.QueryFinished{
+				Query:    tabState.query,
+				Headers:  cols,
+				Rows:     rows,
+				Duration: executionFinish.Sub(tabState.executionStart),
+				Err:      err,
 			})
-		case <-t.C:
-			if len(args) > 0 && args[0].when.After(time.Now()) {
-				a.app.Draw()
-				slices.DeleteFunc(args, func(arg delayDrawArg) bool {
-					return arg.when.Before(time.Now())
-				})
-				return
-			}
+		})
+	}()
+}
 
-			tabState := a.tabStates[a.currentTab]
+// CancelExecution cancels the query running on the current tab, false if there's none.
+func (a *App) CancelExecution() bool {
+	tabState := a.tabStates[a.currentTab]
+	if tabState.status != TabStatusExecuting || tabState.cancel == nil {
+		return false
+	}
+	tabState.cancel()
+	return true
+}
 
-			if tabState.status == TabStatusExecuting {
-				a.app.Draw()
-			}
-		}
+// SetQuery replaces the editor query, e.g. with the query piped at startup.
+func (a *App) SetQuery(query string) {
+	a.setQuery(query)
+}
+
+// Execute runs the editor query on the current tab.
+func (a *App) Execute() {
+	a.editor.Done()
+}
+
+// overMemoryLimit reports whether the loaded results are larger than the configured memory limit.
+func (a *App) overMemoryLimit() bool {
+	limit := a.cfg.Dataviewer.MemoryLimit
+	return limit > 0 && a.dataviewer.MemoryUsage() > limit<<20
+}
+
+// Rerun executes the last query of the current tab again, keeping the focus where it is.
+func (a *App) Rerun() {
+	query := a.tabStates[a.currentTab].query
+	if query == "" {
+		a.setStatusMessage("no query to rerun")
+		return
 	}
+	a.executeQuery(query)
 }
 
-func (a *App) modalLoop() {
-	a.wg.Add(1)
-	defer a.wg.Done()
+// Quit cancels the running queries, flushes the autosave, saves the session state, and stops the application.
+func (a *App) Quit() {
+	for _, tabState := range a.tabStates {
+		if tabState.cancel != nil {
+			tabState.cancel()
+		}
+	}
 
-	for {
-		select {
-		case <-a.ctx.Done():
-			return
-		case arg := <-a.showModalChan:
-			modalClosed := make(chan struct{})
-			a.app.QueueUpdateDraw(func() {
-				a.mainModal.SetText(arg.text).SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-					if buttonLabel == "Ok" {
-						a.app.SetFocus(arg.refocus)
-						a.Pages.HidePage("modal")
-						modalClosed <- struct{}{}
-					}
-				})
-				a.Pages.ShowPage("modal")
-				a.app.SetFocus(a.mainModal)
-			})
+	// the edits made since the last autosave tick are written before quitting
+	if a.cfg.Autosave > 0 {
+		a.autosave()
+	}
+	err := a.columnWidths.Save()
+	if err != nil {
+		log.Println(err)
+	}
+	a.stopPlugins()
+	a.app.Stop()
+}
 
-			select {
-			case <-a.ctx.Done():
-				return
-			case <-modalClosed:
+// dmlIdentity returns the row identity the generated UPDATE and DELETE statements match the rows by.
+// It fails without a primary or unique key in the result columns, matching on the other values could change other rows.
+// It fails too when a key of the rows is NULL, a NULL key matches every row with a NULL key.
+func (a *App) dmlIdentity(tabState *tabState, headers []string, rows []map[string]string) (fetcher.RowIdentity, error) {
+	if tabState.query == "" {
+		return fetcher.RowIdentity{}, errors.New("no query of the results in this connection to edit the rows by")
+	}
+	identity, err := a.rowIdentity(tabState, headers)
+	if err != nil {
+		return identity, err
+	}
+	if len(identity.Keys) == 0 {
+		return identity, fmt.Errorf("no primary or unique key of %s in the result columns, select one to edit the rows", identity.Table)
+	}
+	for _, row := range rows {
+		for _, key := range identity.Keys {
+			if _, ok := row[key]; !ok {
+				return identity, fmt.Errorf("the key %s of a row is NULL, it can't be told apart from other rows", key)
 			}
 		}
 	}
+	return identity, nil
+}
+
+// rowIdentity returns the table and the key columns identifying the rows of the tab query result.
+func (a *App) rowIdentity(tabState *tabState, headers []string) (fetcher.RowIdentity, error) {
+	return a.fetcher.RowIdentity(tabState.ctx, tabState.query, headers)
+}
+
+// columnWidthsKey returns the key of persisted column widths, the table name for a simple select or the query itself.
+func columnWidthsKey(query string) string {
+	if table := fetcher.TableFromQuery(query); table != "" {
+		return table
+	}
+	return strings.TrimSpace(query)
 }
 
 func (a *App) Draw(screen tcell.Screen) {
+	if x, y, w, h := a.GetRect(); w < minWidth || h < minHeight {
+		a.drawTooSmall(screen, x, y, w, h)
+		return
+	}
+
 	// draw views border color
-	for i, view := range a.views {
-		view.SetBorderColor(tcell.ColorGray)
-		if i == a.currentView && view.HasFocus() {
-			view.SetBorderColor(tcell.ColorWhite)
+	for i, pane := range a.panes {
+		pane.box.SetBorderColor(theme.Current().Border)
+		if i == a.currentView && pane.box.HasFocus() {
+			pane.box.SetBorderColor(theme.Current().FocusedBorder)
 		}
 	}
 
+	a.screen = screen
 	a.Pages.Draw(screen)
+	a.updateStatus()
+	a.announceState()
+	a.scheduleAnimation()
+}
 
-	tabState := a.tabStates[a.currentTab]
-
-	// draw status text
-	if !tabState.executionStart.IsZero() {
-		now := time.Now()
-		if tabState.executionFinish.After(tabState.executionStart) {
-			now = tabState.executionFinish
-		}
-		d := now.Sub(tabState.executionStart)
-		durationText := d.Round(time.Millisecond).String()
-		text := durationText
-		if tabState.status == TabStatusExecuting {
-			text = "executing... " + text
-		}
-		a.statusText.SetText(text)
-		a.statusText.SetTextAlign(tview.AlignRight)
+// applyTheme recolors the primitives created before the theme change,
+// the components read the current theme when drawing.
+func (a *App) applyTheme() {
+	styles := tview.Styles
+	for _, pane := range a.panes {
+		pane.box.SetBackgroundColor(styles.PrimitiveBackgroundColor).SetTitleColor(styles.TitleColor)
 	}
+	a.statusText.SetTextColor(styles.PrimaryTextColor).SetBackgroundColor(styles.PrimitiveBackgroundColor)
+	a.executionStatus.SetTextColor(theme.Current().Accent).SetBackgroundColor(styles.PrimitiveBackgroundColor)
+	a.mainModal.SetTextColor(styles.PrimaryTextColor).
+		SetButtonBackgroundColor(styles.PrimitiveBackgroundColor).
+		SetButtonTextColor(styles.PrimaryTextColor).
+		SetBackgroundColor(styles.ContrastBackgroundColor)
+}
+
+// setStatusMessage shows a short message in the status line for a while.
+func (a *App) setStatusMessage(text string) {
+	a.statusMessage = text
+	a.statusTime = time.Now()
 }
 
 func (a *App) Focus(delegate func(p tview.Primitive)) {
 	a.focusDelegate = delegate
 	a.Pages.Focus(delegate)
+	if a.dashboard != nil {
+		delegate(a.dashboard)
+		return
+	}
 	a.FocusViewIndex(a.currentView)
 }
 
+// drawTooSmall replaces the panes with the minimum size until the terminal is large enough again.
+func (a *App) drawTooSmall(screen tcell.Screen, x, y, w, h int) {
+	style := tcell.StyleDefault.Background(tview.Styles.PrimitiveBackgroundColor)
+	for row := y; row < y+h; row++ {
+		for col := x; col < x+w; col++ {
+			screen.SetContent(col, row, ' ', nil, style)
+		}
+	}
+	lines := []string{fmt.Sprintf("too small: %dx%d", w, h), fmt.Sprintf("needs %dx%d", minWidth, minHeight)}
+	for i, line := range lines {
+		tview.Print(screen, line, x, y+(h-len(lines))/2+i, w, tview.AlignCenter, tview.Styles.PrimaryTextColor)
+	}
+}
+
 func (a *App) Blur() {
 	log.Println("blur")
 	a.Pages.Blur()
 
-	for _, box := range a.views {
-		box.SetBorderColor(tcell.ColorGray)
+	for _, pane := range a.panes {
+		pane.box.SetBorderColor(theme.Current().Border)
 	}
 }
 
 func (a *App) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
 	return a.Pages.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
-		if event.Key() == tcell.KeyCtrlH {
-			a.FocusViewIndex(a.currentView + 1)
+		if keymap.EventName(event) == keymap.Canonical(a.cfg.Quit) {
+			a.Quit()
 			return
 		}
-
-		if event.Key() == tcell.KeyCtrlL {
-			a.FocusViewIndex(a.currentView - 1)
+		if keymap.EventName(event) == keymap.Canonical(a.cfg.Rerun) {
+			a.Rerun()
+			return
+		}
+		if a.handleFocusKey(event) {
+			return
+		}
+		if line, ok := a.pluginKeys[keymap.EventName(event)]; ok {
+			a.runCommand(line)
 			return
 		}
 
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ngavinsir/sqluy/config"
+	"github.com/ngavinsir/sqluy/event"
+)
+
+// writeFile saves the query to the file, which becomes the query file. No path saves to the current file.
+func (a *App) writeFile(path string) error {
+	if path == "" {
+		path = a.fileName
+	}
+	if path == "" {
+		a.pickFile("write", "query.sql", a.writeFile)
+		return nil
+	}
+
+	err := a.saveQuery(path)
+	if err != nil {
+		return err
+	}
+	a.setStatusMessage("written " + path)
+	return nil
+}
+
+// saveQuery writes the query, trimmed by the trimwhitespace option, to the file which becomes the query file.
+func (a *App) saveQuery(path string) error {
+	err := os.WriteFile(path, []byte(a.editor.TrimWhitespace()), 0o644)
+	if err != nil {
+		return err
+	}
+	if path != a.fileName {
+		a.fileName = path
+		a.addRecent(func(r *config.Recent) { r.AddFile(path) })
+	}
+	a.dirty = false
+	a.autosaved = true
+	a.bus.Publish(event.BufferSaved{Path: path})
+	return nil
+}
+
+// autosave saves the modified query to its file, or to the swap file if it has none.
+// The query saved to the swap file is still marked as modified.
+func (a *App) autosave() {
+	if !a.dirty || a.autosaved {
+		return
+	}
+
+	// the query file is saved as :w saves it
+	if a.fileName != "" {
+		err := a.saveQuery(a.fileName)
+		if err != nil {
+			a.setStatusMessage(fmt.Sprintf("autosave: %s", err))
+		}
+		return
+	}
+
+	path, err := config.SwapPath()
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+	}
+	if err == nil {
+		err = os.WriteFile(path, []byte(a.editor.GetFullText()), 0o644)
+	}
+	if err != nil {
+		a.setStatusMessage(fmt.Sprintf("autosave: %s", err))
+		return
+	}
+	a.autosaved = true
+}
+
+// autosaveLoop autosaves on every interval tick, and a last time once the app is done.
+func (a *App) autosaveLoop() {
+	a.wg.Add(1)
+	defer a.wg.Done()
+
+	t := time.NewTicker(time.Duration(a.cfg.Autosave) * time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			// the application has stopped running, nothing else touches the editor anymore
+			a.autosave()
+			return
+		case <-t.C:
+			a.app.QueueUpdateDraw(a.autosave)
+		}
+	}
+}
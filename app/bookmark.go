@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/ngavinsir/sqluy/theme"
+	"github.com/rivo/tview"
+)
+
+// bookmarksKey returns the key of the persisted bookmarks of a file, its absolute path.
+func bookmarksKey(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
+
+// saveBookmarks persists the editor bookmarks with the file they're in.
+func (a *App) saveBookmarks(path string) {
+	key := bookmarksKey(path)
+	bookmarks := a.editor.Bookmarks()
+	if len(bookmarks) == 0 {
+		if _, ok := a.bookmarks[key]; !ok {
+			return
+		}
+		delete(a.bookmarks, key)
+	} else {
+		a.bookmarks[key] = bookmarks
+	}
+	err := a.bookmarks.Save()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// showBookmarks lists the bookmarked lines, Enter or l moves the editor cursor to the line.
+func (a *App) showBookmarks() error {
+	bookmarks := a.editor.Bookmarks()
+	if len(bookmarks) == 0 {
+		a.setStatusMessage("no bookmarks")
+		return nil
+	}
+
+	list := tview.NewList().ShowSecondaryText(false).SetHighlightFullLine(true)
+	list.SetBorder(true).SetTitle(" Bookmarks ").SetTitleAlign(tview.AlignLeft)
+
+	done := func() {
+		a.dataviewerPage.RemovePage("bookmarks")
+		a.FocusPane("editor")
+	}
+	lines := strings.Split(a.editor.GetFullText(), "\n")
+	width := len(fmt.Sprint(len(lines)))
+	for _, row := range bookmarks {
+		text := strings.TrimSpace(lines[min(row, len(lines)-1)])
+		list.AddItem(fmt.Sprintf("[%s]%*d[-] %s", theme.Current().Dim, width, row+1, tview.Escape(text)), "", 0, func() {
+			done()
+			a.editor.MoveCursorTo(a.editor.GetLineCursor(row))
+		})
+	}
+	list.SetInputCapture(listCapture(done))
+
+	a.dataviewerPage.AddPage("bookmarks", list, true, true)
+	a.app.SetFocus(list)
+	return nil
+}
@@ -0,0 +1,396 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ngavinsir/sqluy/command"
+	"github.com/ngavinsir/sqluy/dataviewer"
+	"github.com/ngavinsir/sqluy/editor"
+	"github.com/ngavinsir/sqluy/snippet"
+	"github.com/ngavinsir/sqluy/theme"
+)
+
+// registerCommands registers the built-in commands of the app, editor, and dataviewer, then the user commands.
+func (a *App) registerCommands() {
+	builtins := []command.Command{
+		{Name: "set", Aliases: []string{"se"}, Usage: "set {option}...", Run: func(args string) error {
+			return a.setOptions(strings.Fields(args))
+		}, Completer: command.Words(a.cfg.Editor.OptionNames)},
+		{Name: "theme", Aliases: []string{"colorscheme", "colo"}, Usage: "theme [name]", Run: a.setTheme, Completer: command.Words(theme.Names)},
+		{Name: "keymap", Usage: "keymap {group} [file]", Run: func(args string) error {
+			return a.exportKeymap(strings.Fields(args))
+		}, Completer: command.Words(a.keymap.Groups)},
+		{Name: "undo", Aliases: []string{"u"}, Usage: "undo", Run: func(string) error {
+			a.editor.Undo()
+			return nil
+		}},
+		{Name: "redo", Aliases: []string{"red"}, Usage: "redo", Run: func(string) error {
+			a.editor.Redo()
+			return nil
+		}},
+		{Name: "filter", Usage: "filter {column} [expression]", Run: func(args string) error {
+			column, expr, _ := strings.Cut(args, " ")
+			if column == "" {
+				return errors.New("usage: filter {column} [expression]")
+			}
+			return a.dataviewer.SetFilter(column, expr)
+		}},
+		{Name: "nofilter", Usage: "nofilter", Run: func(string) error {
+			a.dataviewer.ClearFilters()
+			return nil
+		}},
+		{Name: "export", Usage: "export", Run: func(string) error {
+			a.dataviewer.ExportRows()
+			return nil
+		}},
+		{Name: "write-results", Aliases: []string{"wr"}, Usage: "write-results [file.csv|.tsv|.json|.md]", Run: func(path string) error {
+			headers, rows := a.dataviewer.VisibleRows()
+			if path == "" {
+				a.pickFile("write results", fmt.Sprintf("export-%d.csv", time.Now().Unix()), func(filename string) error {
+					return a.writeRows(filename, headers, rows)
+				})
+				return nil
+			}
+			return a.writeRows(path, headers, rows)
+		}, Completer: command.Files},
+		{Name: "snapshot", Usage: "snapshot [file]", Run: func(path string) error {
+			if path == "" {
+				a.pickFile("snapshot", fmt.Sprintf("snapshot-%d.json", time.Now().Unix()), a.saveSnapshot)
+				return nil
+			}
+			return a.saveSnapshot(path)
+		}, Completer: command.Files},
+		{Name: "restore", Usage: "restore [file]", Run: func(path string) error {
+			if path == "" {
+				a.pickFile("restore", "", a.restoreSnapshot)
+				return nil
+			}
+			return a.restoreSnapshot(path)
+		}, Completer: command.Files},
+		{Name: "rerun", Usage: "rerun", Run: func(string) error {
+			a.Rerun()
+			return nil
+		}},
+		{Name: "history", Usage: "history", Run: func(string) error {
+			return a.showHistory()
+		}},
+		{Name: "bookmarks", Usage: "bookmarks", Run: func(string) error {
+			return a.showBookmarks()
+		}},
+		{Name: "snippet", Aliases: []string{"sn"}, Usage: "snippet [name] [args...]", Run: a.runSnippet,
+			Completer: command.Words(func() []string { return snippet.Names(a.editor.Dialect()) })},
+		{Name: "truncate", Usage: "truncate [rows]", Run: a.truncateResults},
+		{Name: "refresh", Usage: "refresh", Run: func(string) error {
+			a.refreshSchema()
+			return nil
+		}},
+		{Name: "schema", Usage: "schema [table]", Run: a.showSchema, Completer: command.Words(a.tableNames)},
+		{Name: "diagnostics", Aliases: []string{"diag"}, Usage: "diagnostics", Run: func(string) error {
+			return a.showDiagnostics()
+		}},
+		{Name: "dialect", Usage: "dialect [name]", Run: func(name string) error {
+			if name == "" {
+				a.setStatusMessage(a.editor.Dialect() + " (available: " + strings.Join(editor.Dialects(), ", ") + ")")
+				return nil
+			}
+			return a.editor.SetDialect(name)
+		}, Completer: command.Words(editor.Dialects)},
+		{Name: "quit", Aliases: []string{"q", "qa", "q!"}, Usage: "quit", Run: func(string) error {
+			a.Quit()
+			return nil
+		}},
+		{Name: "write", Aliases: []string{"w"}, Usage: "write [file]", Run: a.writeFile, Completer: command.Files},
+		{Name: "connect", Usage: "connect {path}", Run: a.connect, Completer: command.CompleterFunc(func(word string) []string {
+			recent := command.Words(func() []string { return a.recent.Connections }).Complete(word)
+			return append(recent, command.Files.Complete(word)...)
+		})},
+		{Name: "edit", Aliases: []string{"e"}, Usage: "edit [file]", Run: func(path string) error {
+			if path == "" {
+				a.pickFile("edit", "", a.openFile)
+				return nil
+			}
+			return a.openFile(path)
+		}, Completer: command.Files},
+		{Name: "wincmd", Aliases: []string{"winc"}, Usage: "wincmd {h|j|k|l|w|W|p|s|v|q}", Run: a.windowCommand,
+			Completer: command.Words(func() []string { return []string{"h", "j", "k", "l", "w", "W", "p", "s", "v", "q"} })},
+		{Name: "split", Aliases: []string{"sp"}, Usage: "split", Run: func(string) error {
+			return a.splitEditor(false)
+		}},
+		{Name: "vsplit", Aliases: []string{"vs"}, Usage: "vsplit", Run: func(string) error {
+			return a.splitEditor(true)
+		}},
+		{Name: "close", Aliases: []string{"clo"}, Usage: "close", Run: func(string) error {
+			return a.closeSplit()
+		}},
+		{Name: "commands", Usage: "commands", Run: func(string) error {
+			a.setStatusMessage(strings.Join(a.commands.Names(), " "))
+			return nil
+		}},
+	}
+	for _, c := range builtins {
+		err := a.commands.Register(c)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	names := make([]string, 0, len(a.cfg.Commands))
+	for name := range a.cfg.Commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		err := a.commands.RegisterAlias(name, a.cfg.Commands[name])
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// runStartup runs the startup command lines of the config, a failing one doesn't stop the next ones.
+// The first error is shown in the status bar.
+func (a *App) runStartup() {
+	var first string
+	for _, line := range a.cfg.Startup {
+		err := a.commands.Run(line)
+		if err == nil {
+			continue
+		}
+		log.Printf("startup: %s: %s", line, err)
+		if first == "" {
+			first = fmt.Sprintf("startup: %s: %s", line, err)
+		}
+	}
+	if first != "" {
+		a.setStatusMessage(first)
+	}
+}
+
+// runCommand runs the command entered in the editor command line.
+func (a *App) runCommand(cmd string) {
+	err := a.commands.Run(cmd)
+	if err != nil {
+		a.setStatusMessage(err.Error())
+	}
+}
+
+// setOptions applies :set arguments to the editor options, "name?" shows the option value.
+func (a *App) setOptions(args []string) error {
+	if len(args) == 0 {
+		args = []string{"number?", "relativenumber?", "ignorecase?", "smartcase?", "tabstop?", "shiftwidth?", "scrolloff?", "trimwhitespace?", "wrap?"}
+	}
+
+	options := a.cfg.Editor
+	var values []string
+	for _, arg := range args {
+		if name, ok := strings.CutSuffix(arg, "?"); ok {
+			value, err := options.Get(name)
+			if err != nil {
+				return err
+			}
+			values = append(values, value)
+			continue
+		}
+
+		err := options.Set(arg)
+		if err != nil {
+			return err
+		}
+	}
+
+	a.cfg.Editor = options
+	a.editor.SetOptions(options)
+	if len(values) > 0 {
+		a.setStatusMessage(strings.Join(values, " "))
+	}
+	return nil
+}
+
+// setTheme switches the theme at runtime, no name shows the current and available themes.
+func (a *App) setTheme(name string) error {
+	if name == "" {
+		a.setStatusMessage(a.cfg.Theme + " (available: " + strings.Join(theme.Names(), ", ") + ")")
+		return nil
+	}
+
+	err := theme.Set(name)
+	if err != nil {
+		return err
+	}
+	a.cfg.Theme = name
+	a.applyTheme()
+	return nil
+}
+
+// writeRows writes the rows to the file in the format of its extension.
+func (a *App) writeRows(path string, headers []string, rows []map[string]string) error {
+	if len(headers) == 0 {
+		return errors.New("no results to write")
+	}
+	text, err := dataviewer.FormatFile(path, headers, rows)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(path, []byte(text), 0o644)
+	if err != nil {
+		return err
+	}
+	a.setStatusMessage(fmt.Sprintf("exported %d rows to %s", len(rows), path))
+	return nil
+}
+
+// saveSnapshot saves the current result set with its query and connection.
+func (a *App) saveSnapshot(path string) error {
+	tabState := a.tabStates[a.currentTab]
+	if tabState.query == "" {
+		return errors.New("no result to snapshot")
+	}
+
+	s := a.dataviewer.Snapshot()
+	s.Query = tabState.query
+	s.Connection = a.fetcher.Path()
+	err := s.Save(path)
+	if err != nil {
+		return err
+	}
+	a.setStatusMessage(fmt.Sprintf("saved %d rows to %s", len(s.Rows), path))
+	return nil
+}
+
+// restoreSnapshot shows a saved result set in the dataviewer without running its query.
+func (a *App) restoreSnapshot(path string) error {
+	if path == "" {
+		return errors.New("usage: restore {file}")
+	}
+
+	tabState := a.tabStates[a.currentTab]
+	if tabState.status != TabStatusEditing {
+		return errors.New("query is still executing, ctrl+c to cancel")
+	}
+
+	s, err := dataviewer.LoadSnapshot(path)
+	if err != nil {
+		return err
+	}
+	// paging, refreshing, and the row edits run the snapshot query from now on,
+	// unless it's a snapshot of another connection, its query would run against the wrong database
+	tabState.query, tabState.shownQuery = "", ""
+	if s.Connection == a.fetcher.Path() {
+		tabState.query = s.Query
+		tabState.shownQuery = s.Query
+	}
+	tabState.page = 0
+	tabState.pageRowCount = len(s.Rows)
+	tabState.sortHeader, tabState.sortDesc, tabState.keyset = "", false, false
+
+	a.dataviewer.SetTitle(fmt.Sprintf("Snapshot (%s, %s)", filepath.Base(s.Connection), s.Time.Local().Format(time.DateTime)))
+	a.dataviewer.SetColumnWidths(maps.Clone(a.columnWidths[columnWidthsKey(s.Query)]))
+	a.dataviewer.SetColumnTypes(s.Types)
+	a.dataviewer.SetData(s.Headers, s.Rows)
+	a.dataviewer.SetServerSort("", false)
+	a.setStatusMessage(strings.Join(strings.Fields(s.Query), " "))
+	a.FocusPane("results")
+	return nil
+}
+
+// exportKeymap shows the keymap table of a group, or writes it to the file given as the second argument.
+func (a *App) exportKeymap(args []string) error {
+	groups := a.keymap.Groups()
+	if len(args) == 0 || !slices.Contains(groups, args[0]) {
+		return fmt.Errorf("usage: keymap {group} [file] (groups: %s)", strings.Join(groups, ", "))
+	}
+
+	table := a.keymap.Table(args[0])
+	if len(args) > 1 {
+		err := os.WriteFile(args[1], []byte(table), 0o644)
+		if err != nil {
+			return err
+		}
+		a.setStatusMessage("wrote " + args[0] + " keymap to " + args[1])
+		return nil
+	}
+
+	pane := dataviewer.NewInspector("keymap "+args[0], table)
+	pane.SetDoneFunc(func() {
+		a.dataviewerPage.RemovePage("keymap")
+		a.FocusPane("editor")
+	})
+	a.dataviewerPage.AddPage("keymap", pane, true, true)
+	a.app.SetFocus(pane)
+	return nil
+}
+
+// truncateResults drops the loaded rows after the given number, or the rows over the memory limit.
+func (a *App) truncateResults(args string) error {
+	n := a.dataviewer.RowsWithin(a.cfg.Dataviewer.MemoryLimit << 20)
+	if args != "" {
+		var err error
+		n, err = strconv.Atoi(args)
+		if err != nil || n < 0 {
+			return errors.New("usage: truncate [rows]")
+		}
+	} else if a.cfg.Dataviewer.MemoryLimit <= 0 {
+		return errors.New("no memory limit set, usage: truncate {rows}")
+	}
+	kept := a.dataviewer.Truncate(n)
+	a.tabStates[a.currentTab].pageRowCount = kept
+	a.setStatusMessage(fmt.Sprintf("kept %d rows, ~%s", kept, formatBytes(a.dataviewer.MemoryUsage())))
+	return nil
+}
+
+// runSnippet executes the admin snippet of the editor dialect on the current connection,
+// without a name it lists the snippets.
+func (a *App) runSnippet(args string) error {
+	dialect := a.editor.Dialect()
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		names := snippet.Names(dialect)
+		if len(names) == 0 {
+			return fmt.Errorf("no snippets for the %s dialect", dialect)
+		}
+		a.setStatusMessage(strings.Join(names, " "))
+		return nil
+	}
+
+	s, err := snippet.Get(dialect, fields[0])
+	if err != nil {
+		return err
+	}
+	query, err := s.Fill(fields[1:])
+	if err != nil {
+		return err
+	}
+	a.executeQuery(query)
+	return nil
+}
+
+// showDiagnostics shows the syntax errors of the query with their line and column.
+func (a *App) showDiagnostics() error {
+	diagnostics := a.editor.Diagnostics()
+	if len(diagnostics) == 0 {
+		a.setStatusMessage("no syntax errors")
+		return nil
+	}
+
+	lines := make([]string, len(diagnostics))
+	for i, d := range diagnostics {
+		lines[i] = d.String()
+	}
+	pane := dataviewer.NewInspector("diagnostics", strings.Join(lines, "\n"))
+	pane.SetDoneFunc(func() {
+		a.dataviewerPage.RemovePage("diagnostics")
+		a.FocusPane("editor")
+	})
+	a.dataviewerPage.AddPage("diagnostics", pane, true, true)
+	a.app.SetFocus(pane)
+	return nil
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ngavinsir/sqluy/config"
+	"github.com/ngavinsir/sqluy/event"
+	"github.com/ngavinsir/sqluy/fetcher"
+	"github.com/ngavinsir/sqluy/theme"
+	"github.com/rivo/tview"
+)
+
+// showDashboard shows the recent connections, query files, queries, and the saved queries,
+// selecting one or pressing q drops into the editor.
+func (a *App) showDashboard() {
+	list := tview.NewList().ShowSecondaryText(false).SetHighlightFullLine(true)
+	list.SetBorder(true).SetTitle(" sqluy ").SetTitleAlign(tview.AlignLeft)
+
+	done := func() {
+		a.RemovePage("dashboard")
+		a.dashboard = nil
+		a.FocusPane("editor")
+	}
+	add := func(kind, text string, fn func() error) {
+		text = strings.Join(strings.Fields(text), " ")
+		list.AddItem(fmt.Sprintf("[%s]%-10s[-] %s", theme.Current().Dim, kind, tview.Escape(text)), "", 0, func() {
+			err := fn()
+			if err != nil {
+				a.setStatusMessage(err.Error())
+			}
+			done()
+		})
+	}
+
+	add("new", "empty query", func() error { return nil })
+	for _, c := range a.recent.Connections {
+		add("connection", c, func() error { return a.connect(c) })
+	}
+	for _, f := range a.recent.Files {
+		add("file", f, func() error { return a.openFile(f) })
+	}
+	for _, q := range a.recent.Queries {
+		add("query", q, func() error { return a.setQuery(q) })
+	}
+	names := make([]string, 0, len(a.cfg.Queries))
+	for name := range a.cfg.Queries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		add("saved", name, func() error { return a.setQuery(a.cfg.Queries[name]) })
+	}
+
+	list.SetInputCapture(listCapture(done))
+
+	a.AddPage("dashboard", list, true, true)
+	a.dashboard = list
+}
+
+// connect opens the sqlite database, closing the current one.
+func (a *App) connect(path string) error {
+	if path == "" {
+		return errors.New("usage: connect {path}")
+	}
+
+	f, err := fetcher.NewSqliteFetcher(path)
+	if err != nil {
+		return err
+	}
+	err = a.fetcher.Close()
+	if err != nil {
+		log.Println(err)
+	}
+	a.fetcher = f
+	a.bus.Publish(event.ConnectionChanged{Path: path})
+	return nil
+}
+
+// openFile loads the query file into the editor.
+func (a *App) openFile(path string) error {
+	if path == "" {
+		return errors.New("usage: edit {file}")
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	err = a.setQuery(string(b))
+	if err != nil {
+		return err
+	}
+	a.fileName = path
+	a.dirty = false
+	a.editor.SetBookmarks(a.bookmarks[bookmarksKey(path)])
+	a.addRecent(func(r *config.Recent) { r.AddFile(path) })
+	return nil
+}
+
+// setQuery replaces the editor text as an undoable change.
+func (a *App) setQuery(query string) error {
+	a.editor.Begin()
+	a.editor.SetTextAndNotify(query, [2]int{0, 0})
+	a.editor.Commit()
+	return nil
+}
+
+// addRecent updates and saves the recently used entries.
+func (a *App) addRecent(fn func(r *config.Recent)) {
+	fn(&a.recent)
+	err := a.recent.Save()
+	if err != nil {
+		log.Println(err)
+	}
+}
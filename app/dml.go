@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/ngavinsir/sqluy/event"
+	"github.com/rivo/tview"
+)
+
+// previewDML shows the generated UPDATE or DELETE statements with the number of rows they change,
+// counted by the count query, and executes them only once confirmed.
+// They can be moved to the editor instead to change them first.
+func (a *App) previewDML(query, countQuery string) {
+	tabState := a.tabStates[a.currentTab]
+	refocus := a.app.GetFocus()
+	go func() {
+		count, err := a.fetcher.Count(tabState.ctx, countQuery)
+		if err != nil {
+			a.bus.Publish(event.Modal{Text: err.Error(), Refocus: refocus})
+			return
+		}
+
+		a.app.QueueUpdateDraw(func() {
+			styles := tview.Styles
+			modal := tview.NewModal().
+				SetText(fmt.Sprintf("%s\n\n%d rows will be affected", query, count)).
+				AddButtons([]string{"Execute", "Edit", "Cancel"}).
+				SetTextColor(styles.PrimaryTextColor).
+				SetButtonBackgroundColor(styles.PrimitiveBackgroundColor).
+				SetButtonTextColor(styles.PrimaryTextColor).
+				SetBackgroundColor(styles.ContrastBackgroundColor)
+			modal.SetDoneFunc(func(_ int, buttonLabel string) {
+				a.Pages.RemovePage("dml")
+				a.app.SetFocus(refocus)
+				switch buttonLabel {
+				case "Execute":
+					a.executeDML(query)
+				case "Edit":
+					a.editor.Begin()
+					a.editor.SetTextAndNotify(query, [2]int{0, 0})
+					a.editor.Commit()
+					a.FocusPane("editor")
+				}
+			})
+			a.Pages.AddPage("dml", modal, true, true)
+			a.app.SetFocus(modal)
+		})
+	}()
+}
+
+// executeDML executes the confirmed statements and refreshes the shown results.
+func (a *App) executeDML(query string) {
+	tabState := a.tabStates[a.currentTab]
+	go func() {
+		count, err := a.fetcher.Exec(tabState.ctx, query)
+		if err != nil {
+			a.bus.Publish(event.Modal{Text: err.Error(), Refocus: a.flex})
+			return
+		}
+
+		a.app.QueueUpdateDraw(func() {
+			a.setStatusMessage(fmt.Sprintf("%d rows affected", count))
+			if tabState.shownQuery != "" && tabState.status == TabStatusEditing {
+				tabState.query = tabState.shownQuery
+				a.execute(tabState)
+			}
+		})
+	}()
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ngavinsir/sqluy/event"
+	"github.com/rivo/tview"
+)
+
+// drawQueue is the delayed draws ordered by their due time, safe for concurrent use.
+type drawQueue struct {
+	mutex sync.Mutex
+	draws []event.DrawAt
+	// wake tells the draw loop the earliest due time may have changed
+	wake chan struct{}
+}
+
+func newDrawQueue() *drawQueue {
+	return &drawQueue{wake: make(chan struct{}, 1)}
+}
+
+// push adds the draw after the ones due at the same time.
+func (q *drawQueue) push(d event.DrawAt) {
+	q.mutex.Lock()
+	i, _ := slices.BinarySearchFunc(q.draws, d.When, func(other event.DrawAt, when time.Time) int {
+		if other.When.After(when) {
+			return 1
+		}
+		return -1
+	})
+	q.draws = slices.Insert(q.draws, i, d)
+	q.mutex.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the due time of the earliest draw, false when the queue is empty.
+func (q *drawQueue) next() (time.Time, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.draws) == 0 {
+		return time.Time{}, false
+	}
+	return q.draws[0].When, true
+}
+
+// popDue removes and returns the draws due at or before now, earliest first.
+func (q *drawQueue) popDue(now time.Time) []event.DrawAt {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	i := 0
+	for i < len(q.draws) && !q.draws[i].When.After(now) {
+		i++
+	}
+	due := slices.Clone(q.draws[:i])
+	q.draws = slices.Delete(q.draws, 0, i)
+	return due
+}
+
+// drawLoop redraws when a delayed draw is due, running its function on the UI goroutine first.
+// It sleeps until the earliest due time, other redraws come from the events.
+func (a *App) drawLoop() {
+	a.wg.Add(1)
+	defer a.wg.Done()
+
+	timer := time.NewTimer(0)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		if when, ok := a.draws.next(); ok {
+			timer.Reset(time.Until(when))
+		}
+
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-a.draws.wake:
+			timer.Stop()
+		case now := <-timer.C:
+			due := a.draws.popDue(now)
+			if len(due) == 0 {
+				continue
+			}
+			a.app.QueueUpdate(func() {
+				a.runDraws(due)
+			})
+		}
+	}
+}
+
+// runDraws runs the functions of the due draws, then redraws the primitives they're limited to.
+// The whole app is redrawn when one of them isn't limited, or when the main page is covered or too small.
+func (a *App) runDraws(due []event.DrawAt) {
+	partial := a.screen != nil
+	var primitives []tview.Primitive
+	for _, d := range due {
+		if d.Fn != nil {
+			d.Fn()
+		}
+		partial = partial && len(d.Primitives) > 0
+		primitives = append(primitives, d.Primitives...)
+	}
+
+	_, _, w, h := a.GetRect()
+	if front, _ := a.Pages.GetFrontPage(); !partial || front != "main" || w < minWidth || h < minHeight {
+		a.app.ForceDraw()
+		return
+	}
+	for _, p := range primitives {
+		p.Draw(a.screen)
+	}
+	a.screen.Show()
+	a.scheduleAnimation()
+}
+
+// scheduleAnimation redraws the spinners and the progress once per frame while a query executes,
+// the schema loads, or the syntax is highlighted in the background. It's called on every draw.
+// The query clock and the schema spinner only redraw the status bar.
+func (a *App) scheduleAnimation() {
+	_, highlighting := a.editor.SyntaxProgress()
+	executing := a.tabStates[a.currentTab].status == TabStatusExecuting || a.schemaLoading.Load()
+	if !executing && !highlighting || time.Now().Before(a.nextFrame) {
+		return
+	}
+	a.nextFrame = time.Now().Add(spinnerInterval)
+	if highlighting {
+		a.draws.push(event.DrawAt{When: a.nextFrame})
+		return
+	}
+	a.draws.push(event.DrawAt{
+		When:       a.nextFrame,
+		Fn:         a.updateStatus,
+		Primitives: []tview.Primitive{a.executionStatus, a.statusText},
+	})
+}
+
+// updateStatus sets the texts of the execution spinner and the status bar.
+func (a *App) updateStatus() {
+	tabState := a.tabStates[a.currentTab]
+	if tabState.status == TabStatusExecuting {
+		frame := int(time.Since(tabState.executionStart)/spinnerInterval) % len(spinnerFrames)
+		a.executionStatus.SetText(fmt.Sprintf(" %c executing... (ctrl+c to cancel)", spinnerFrames[frame]))
+	}
+	a.drawStatusline()
+}
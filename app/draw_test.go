@@ -0,0 +1,124 @@
+package app
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ngavinsir/sqluy/event"
+)
+
+func TestDrawQueueOrder(t *testing.T) {
+	q := newDrawQueue()
+	start := time.Now()
+	var ran []int
+	push := func(delay time.Duration, id int) {
+		q.push(event.DrawAt{When: start.Add(delay), Fn: func() { ran = append(ran, id) }})
+	}
+	push(30*time.Millisecond, 1)
+	push(10*time.Millisecond, 2)
+	push(20*time.Millisecond, 3)
+	// due at the same time, they run in the order they're pushed
+	push(10*time.Millisecond, 4)
+	push(0, 5)
+
+	if when, ok := q.next(); !ok || !when.Equal(start) {
+		t.Fatalf("next() = %v, %v, want the earliest due time", when, ok)
+	}
+
+	for _, d := range q.popDue(start.Add(time.Hour)) {
+		d.Fn()
+	}
+	if want := []int{5, 2, 4, 3, 1}; !slices.Equal(ran, want) {
+		t.Errorf("draws ran in the order %v, want %v", ran, want)
+	}
+	if _, ok := q.next(); ok {
+		t.Error("next() is ok with an empty queue")
+	}
+}
+
+func TestDrawQueuePopDue(t *testing.T) {
+	q := newDrawQueue()
+	start := time.Now()
+	for _, delay := range []time.Duration{0, 10, 20, 30} {
+		q.push(event.DrawAt{When: start.Add(delay * time.Millisecond)})
+	}
+
+	if due := q.popDue(start.Add(-time.Millisecond)); len(due) != 0 {
+		t.Errorf("popDue before the first due time = %d draws, want none", len(due))
+	}
+	// a draw due exactly now is due
+	if due := q.popDue(start.Add(10 * time.Millisecond)); len(due) != 2 {
+		t.Errorf("popDue at the second due time = %d draws, want 2", len(due))
+	}
+	if when, ok := q.next(); !ok || !when.Equal(start.Add(20*time.Millisecond)) {
+		t.Errorf("next() after popDue = %v, %v, want the third due time", when, ok)
+	}
+	if due := q.popDue(start.Add(time.Hour)); len(due) != 2 {
+		t.Errorf("popDue after the last due time = %d draws, want 2", len(due))
+	}
+	if due := q.popDue(start.Add(time.Hour)); len(due) != 0 {
+		t.Errorf("popDue of an empty queue = %d draws, want none", len(due))
+	}
+}
+
+func TestDrawQueueWake(t *testing.T) {
+	q := newDrawQueue()
+	select {
+	case <-q.wake:
+		t.Fatal("woken before a push")
+	default:
+	}
+
+	// pushes don't block on a loop that isn't listening, and wake it once
+	done := make(chan struct{})
+	go func() {
+		for range 3 {
+			q.push(event.DrawAt{When: time.Now()})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("push blocked without a listening draw loop")
+	}
+
+	select {
+	case <-q.wake:
+	default:
+		t.Fatal("not woken after a push")
+	}
+	select {
+	case <-q.wake:
+		t.Fatal("woken twice for the pushes before the loop listened")
+	default:
+	}
+}
+
+func TestDrawQueueConcurrentPush(t *testing.T) {
+	q := newDrawQueue()
+	start := time.Now()
+	done := make(chan struct{})
+	for i := range 8 {
+		go func() {
+			for j := range 100 {
+				q.push(event.DrawAt{When: start.Add(time.Duration((i*100+j)%37) * time.Millisecond)})
+			}
+			done <- struct{}{}
+		}()
+	}
+	for range 8 {
+		<-done
+	}
+
+	due := q.popDue(start.Add(time.Hour))
+	if len(due) != 800 {
+		t.Fatalf("popDue = %d draws, want 800", len(due))
+	}
+	for i := 1; i < len(due); i++ {
+		if due[i].When.Before(due[i-1].When) {
+			t.Fatalf("draw %d is due before the one popped ahead of it", i)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"github.com/ngavinsir/sqluy/config"
+	"github.com/ngavinsir/sqluy/event"
+)
+
+// Bus returns the event bus of the app, e.g. to react to the queries executed.
+func (a *App) Bus() *event.Bus {
+	return a.bus
+}
+
+// subscribe subscribes the app to its events.
+func (a *App) subscribe() {
+	event.Subscribe(a.bus, a.queueModal)
+	event.Subscribe(a.bus, a.draws.push)
+
+	event.Subscribe(a.bus, func(e event.QueryFinished) {
+		if e.Err != nil {
+			return
+		}
+		a.addRecent(func(r *config.Recent) { r.AddQuery(e.Query) })
+		a.addHistory(e.Query, e.Duration)
+		if a.fetcher.ChangesSchema(e.Query) {
+			a.refreshSchema()
+		}
+	})
+	event.Subscribe(a.bus, func(e event.ConnectionChanged) {
+		a.addRecent(func(r *config.Recent) { r.AddConnection(e.Path) })
+		a.refreshSchema()
+		a.applyDialect()
+	})
+	event.Subscribe(a.bus, func(e event.BufferSaved) {
+		a.saveBookmarks(e.Path)
+	})
+}
+
+// queueModal shows the modal once the modals before it are closed, it may be called from any goroutine.
+func (a *App) queueModal(m event.Modal) {
+	a.modalMutex.Lock()
+	a.modals = append(a.modals, m)
+	first := len(a.modals) == 1
+	a.modalMutex.Unlock()
+	// queued from another goroutine, waiting for the update on the UI goroutine would deadlock
+	if first {
+		go a.app.QueueUpdateDraw(a.showModal)
+	}
+}
+
+// showModal shows the first queued modal, closing it shows the next one.
+func (a *App) showModal() {
+	a.modalMutex.Lock()
+	m := a.modals[0]
+	a.modalMutex.Unlock()
+
+	a.mainModal.SetText(m.Text).SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		if buttonLabel != "Ok" {
+			return
+		}
+		a.app.SetFocus(m.Refocus)
+		a.Pages.HidePage("modal")
+
+		a.modalMutex.Lock()
+		a.modals = a.modals[1:]
+		next := len(a.modals) > 0
+		a.modalMutex.Unlock()
+		if next {
+			a.showModal()
+		}
+	})
+	a.Pages.ShowPage("modal")
+	a.app.SetFocus(a.mainModal)
+}
@@ -0,0 +1,185 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ngavinsir/sqluy/keymap"
+	"github.com/rivo/tview"
+)
+
+type (
+	// pane is a focusable part of the app, cycled through in the configured focus order.
+	// The primitive is focused instead of the box so it can restore its own focus, e.g. an open command line.
+	pane struct {
+		name      string
+		box       *tview.Box
+		primitive tview.Primitive
+		// page is the dataviewer page of a pane shown over the results only while it's focused,
+		// fill lists its items again when it's shown
+		page string
+		fill func()
+	}
+)
+
+// setPanes orders the panes by the configured focus order, unknown pane names are ignored.
+func (a *App) setPanes(panes map[string]pane) {
+	a.panes = nil
+	for _, name := range a.cfg.Focus.Order {
+		p, ok := panes[name]
+		if !ok || slices.ContainsFunc(a.panes, func(p pane) bool { return p.name == name }) {
+			log.Printf("unknown or duplicate pane in focus order: %s\n", name)
+			continue
+		}
+		p.name = name
+		a.panes = append(a.panes, p)
+	}
+
+	// the built-in panes left out of the order are appended, the app relies on focusing them
+	for _, name := range []string{"editor", "results", "schema", "history"} {
+		if !slices.ContainsFunc(a.panes, func(p pane) bool { return p.name == name }) {
+			p := panes[name]
+			p.name = name
+			a.panes = append(a.panes, p)
+		}
+	}
+}
+
+// FocusViewIndex focuses the pane at the index of the focus order, wrapping around.
+func (a *App) FocusViewIndex(index int) {
+	if index < 0 {
+		index = len(a.panes) - 1
+	}
+	if index >= len(a.panes) {
+		index = 0
+	}
+
+	// the panes over the results are hidden when another pane is focused
+	for i, p := range a.panes {
+		if p.page == "" {
+			continue
+		}
+		if i != index {
+			a.dataviewerPage.HidePage(p.page)
+			continue
+		}
+		if front, _ := a.dataviewerPage.GetFrontPage(); index != a.currentView || front != p.page {
+			p.fill()
+			a.dataviewerPage.ShowPage(p.page)
+		}
+	}
+
+	if index != a.currentView {
+		a.previousView = a.currentView
+	}
+	a.currentView = index
+	a.app.SetFocus(a.panes[index].primitive)
+}
+
+// FocusPane focuses the pane by its name.
+func (a *App) FocusPane(name string) {
+	i := slices.IndexFunc(a.panes, func(p pane) bool { return p.name == name })
+	if i < 0 {
+		return
+	}
+	a.FocusViewIndex(i)
+}
+
+// handleFocusKey cycles or jumps the pane focus with the configured keys, false if the event isn't one.
+func (a *App) handleFocusKey(event *tcell.EventKey) bool {
+	name := keymap.EventName(event)
+	switch {
+	case name == keymap.Canonical(a.cfg.Focus.Next):
+		a.FocusViewIndex(a.currentView + 1)
+		return true
+	case name == keymap.Canonical(a.cfg.Focus.Prev):
+		a.FocusViewIndex(a.currentView - 1)
+		return true
+	case name == keymap.Canonical(a.cfg.Focus.Toggle):
+		a.toggleFocus()
+		return true
+	}
+
+	for key, pane := range a.cfg.Focus.Jump {
+		if name == keymap.Canonical(key) {
+			a.FocusPane(pane)
+			return true
+		}
+	}
+	return false
+}
+
+// toggleFocus flips the focus between the editor views and the results, the pane modes and pending keys are kept.
+func (a *App) toggleFocus() {
+	if name := a.panes[a.currentView].name; name == "editor" || name == "split" {
+		a.FocusPane("results")
+		return
+	}
+	a.FocusPane("editor")
+}
+
+// windowCommand runs a vim window command, h/j/k/l focus the pane in that direction,
+// w and W cycle the focus order, p focuses the previous pane, s and v split the editor, and q closes the split.
+func (a *App) windowCommand(cmd string) error {
+	switch cmd {
+	case "h":
+		a.focusDirection(-1, 0)
+	case "j":
+		a.focusDirection(0, 1)
+	case "k":
+		a.focusDirection(0, -1)
+	case "l":
+		a.focusDirection(1, 0)
+	case "w":
+		a.FocusViewIndex(a.currentView + 1)
+	case "W":
+		a.FocusViewIndex(a.currentView - 1)
+	case "p":
+		a.FocusViewIndex(a.previousView)
+	case "s":
+		return a.splitEditor(false)
+	case "v":
+		return a.splitEditor(true)
+	case "q", "c":
+		return a.closeSplit()
+	default:
+		return fmt.Errorf("unknown window command: %s", cmd)
+	}
+	return nil
+}
+
+// focusDirection focuses the nearest visible pane beside the focused one in the direction,
+// overlapping it on the other axis. The focus stays if there's none.
+func (a *App) focusDirection(dx, dy int) {
+	x, y, w, h := a.panes[a.currentView].box.GetRect()
+	nearest, nearestDistance := -1, 0
+	for i, p := range a.panes {
+		px, py, pw, ph := p.box.GetRect()
+		// a hidden pane over the results keeps the rect it was last drawn at
+		if i == a.currentView || pw <= 0 || ph <= 0 || p.page != "" {
+			continue
+		}
+
+		var distance int
+		switch {
+		case dx < 0 && px+pw <= x && py < y+h && py+ph > y:
+			distance = x - (px + pw)
+		case dx > 0 && px >= x+w && py < y+h && py+ph > y:
+			distance = px - (x + w)
+		case dy < 0 && py+ph <= y && px < x+w && px+pw > x:
+			distance = y - (py + ph)
+		case dy > 0 && py >= y+h && px < x+w && px+pw > x:
+			distance = py - (y + h)
+		default:
+			continue
+		}
+		if nearest < 0 || distance < nearestDistance {
+			nearest, nearestDistance = i, distance
+		}
+	}
+	if nearest >= 0 {
+		a.FocusViewIndex(nearest)
+	}
+}
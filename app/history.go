@@ -0,0 +1,130 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ngavinsir/sqluy/theme"
+	"github.com/rivo/tview"
+)
+
+// historyLimit is the number of executed queries kept in the history pane.
+const historyLimit = 100
+
+type (
+	// historyEntry is a query executed in this session with how long it took.
+	historyEntry struct {
+		query    string
+		duration time.Duration
+	}
+)
+
+// addHistory puts the executed query at the front of the history, dropping the oldest over the limit.
+func (a *App) addHistory(query string, duration time.Duration) {
+	a.history = append([]historyEntry{{query: query, duration: duration}}, a.history...)
+	a.history = a.history[:min(len(a.history), historyLimit)]
+}
+
+// showHistory focuses the history pane, if there's any executed query.
+func (a *App) showHistory() error {
+	if len(a.history) == 0 {
+		a.setStatusMessage("no executed queries")
+		return nil
+	}
+	a.FocusPane("history")
+	return nil
+}
+
+// newHistoryPane returns the pane listing the queries executed in this session, most recent first.
+// Enter or l loads the query into the editor, x executes it on the current connection.
+func (a *App) newHistoryPane() pane {
+	list := tview.NewList().ShowSecondaryText(false).SetHighlightFullLine(true)
+	list.SetBorder(true).SetTitle(" History (enter: load, x: execute) ").SetTitleAlign(tview.AlignLeft)
+
+	done := func() {
+		a.FocusPane("editor")
+	}
+	// entries is the history listed, the queries executed since are listed the next time it's shown
+	var entries []historyEntry
+	capture := listCapture(done)
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyRune && event.Rune() == 'x' {
+			if len(entries) == 0 {
+				return nil
+			}
+			entry := entries[list.GetCurrentItem()]
+			done()
+			a.executeHistory(entry)
+			return nil
+		}
+		return capture(event)
+	})
+
+	fill := func() {
+		list.Clear()
+		entries = a.history
+		for _, entry := range entries {
+			text := strings.Join(strings.Fields(entry.query), " ")
+			duration := entry.duration.Round(time.Millisecond).String()
+			list.AddItem(fmt.Sprintf("[%s]%8s[-] %s", theme.Current().Dim, duration, tview.Escape(text)), "", 0, func() {
+				a.setQuery(entry.query)
+				done()
+			})
+		}
+	}
+	a.dataviewerPage.AddPage("history", list, true, false)
+	return pane{box: list.Box, primitive: list, page: "history", fill: fill}
+}
+
+// executeHistory executes the history query on the current tab, the duration segment shows
+// its previous duration for comparison.
+func (a *App) executeHistory(entry historyEntry) {
+	if a.executeQuery(entry.query) {
+		a.tabStates[a.currentTab].previousDuration = entry.duration
+	}
+}
+
+// executeQuery executes the query on the current tab without changing the editor text,
+// false if the tab is still executing one.
+func (a *App) executeQuery(query string) bool {
+	tabState := a.tabStates[a.currentTab]
+	if tabState.status != TabStatusEditing {
+		a.setStatusMessage("query is still executing, ctrl+c to cancel")
+		return false
+	}
+	tabState.query = query
+	tabState.page = 0
+	a.execute(tabState)
+	return true
+}
+
+// listCapture moves through a list with vim keys, Esc and q call done.
+func listCapture(done func()) func(event *tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyRune {
+			if event.Key() == tcell.KeyEsc {
+				done()
+				return nil
+			}
+			return event
+		}
+		switch event.Rune() {
+		case 'j':
+			return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		case 'k':
+			return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+		case 'g':
+			return tcell.NewEventKey(tcell.KeyHome, 0, tcell.ModNone)
+		case 'G':
+			return tcell.NewEventKey(tcell.KeyEnd, 0, tcell.ModNone)
+		case 'l':
+			return tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
+		case 'q':
+			done()
+			return nil
+		}
+		return event
+	}
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"github.com/ngavinsir/sqluy/filepicker"
+)
+
+// pickFile shows the file picker over the app, fn is run with the picked path and its error is shown.
+// A non-empty save name allows picking a file that doesn't exist yet, prefilled with the name.
+func (a *App) pickFile(title, saveName string, fn func(path string) error) {
+	p := filepicker.New(".").SetTitle(title)
+	if saveName != "" {
+		p.SetSaveMode(saveName)
+	}
+
+	done := func() {
+		a.RemovePage("picker")
+		a.FocusViewIndex(a.currentView)
+	}
+	p.SetSelectFunc(func(path string) {
+		done()
+		err := fn(path)
+		if err != nil {
+			a.setStatusMessage(err.Error())
+		}
+	})
+	p.SetCancelFunc(done)
+
+	a.AddPage("picker", p, true, true)
+	a.app.SetFocus(p)
+}
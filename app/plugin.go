@@ -0,0 +1,162 @@
+package app
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/ngavinsir/sqluy/command"
+	"github.com/ngavinsir/sqluy/event"
+	"github.com/ngavinsir/sqluy/keymap"
+	"github.com/ngavinsir/sqluy/plugin"
+)
+
+// startPlugins starts the configured plugins and forwards them the app events.
+func (a *App) startPlugins() {
+	names := make([]string, 0, len(a.cfg.Plugins))
+	for name := range a.cfg.Plugins {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		cfg := a.cfg.Plugins[name]
+		p, err := plugin.Start(a.ctx, name, cfg.Command, cfg.Args, a.handlePlugin)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		a.plugins = append(a.plugins, p)
+
+		go func() {
+			err := p.Call(a.ctx, "initialize", map[string]string{"name": name}, nil)
+			if err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+	if len(a.plugins) == 0 {
+		return
+	}
+
+	event.Subscribe(a.bus, func(e event.QueryStarted) {
+		a.notifyPlugins("query/started", map[string]any{"query": e.Query})
+	})
+	event.Subscribe(a.bus, func(e event.QueryFinished) {
+		// the rows are sent as arrays in the header order
+		rows := make([][]string, len(e.Rows))
+		for i, row := range e.Rows {
+			rows[i] = make([]string, len(e.Headers))
+			for j, header := range e.Headers {
+				rows[i][j] = row[header]
+			}
+		}
+		params := map[string]any{
+			"query":       e.Query,
+			"headers":     e.Headers,
+			"rows":        rows,
+			"duration_ms": e.Duration.Milliseconds(),
+		}
+		if e.Err != nil {
+			params["error"] = e.Err.Error()
+		}
+		a.notifyPlugins("query/finished", params)
+	})
+	event.Subscribe(a.bus, func(e event.ConnectionChanged) {
+		a.notifyPlugins("connection/changed", map[string]any{"path": e.Path})
+	})
+	event.Subscribe(a.bus, func(e event.BufferSaved) {
+		a.notifyPlugins("buffer/saved", map[string]any{"path": e.Path})
+	})
+}
+
+// stopPlugins asks the plugins to exit.
+func (a *App) stopPlugins() {
+	for _, p := range a.plugins {
+		p.Close()
+	}
+}
+
+func (a *App) notifyPlugins(method string, params any) {
+	for _, p := range a.plugins {
+		err := p.Notify(method, params)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// handlePlugin answers the requests of the plugin on the UI goroutine:
+// command/register {name, usage} adds a command running the plugin command/run request,
+// key/register {key, command} runs the command line on the key from any pane,
+// and status/show {text} shows the text in the status bar.
+func (a *App) handlePlugin(p *plugin.Plugin, method string, params json.RawMessage) (any, error) {
+	var fn func() error
+	switch method {
+	case "command/register":
+		var c struct {
+			Name  string `json:"name"`
+			Usage string `json:"usage"`
+		}
+		err := json.Unmarshal(params, &c)
+		if err != nil || c.Name == "" {
+			return nil, fmt.Errorf("invalid %s params", method)
+		}
+		fn = func() error {
+			return a.commands.Register(command.Command{Name: c.Name, Usage: cmp.Or(c.Usage, c.Name), Run: func(args string) error {
+				go a.runPluginCommand(p, c.Name, args)
+				return nil
+			}})
+		}
+	case "key/register":
+		var k struct {
+			Key     string `json:"key"`
+			Command string `json:"command"`
+		}
+		err := json.Unmarshal(params, &k)
+		if err != nil || k.Key == "" || strings.TrimSpace(k.Command) == "" {
+			return nil, fmt.Errorf("invalid %s params", method)
+		}
+		fn = func() error {
+			a.pluginKeys[keymap.Canonical(k.Key)] = k.Command
+			return nil
+		}
+	case "status/show":
+		var s struct {
+			Text string `json:"text"`
+		}
+		err := json.Unmarshal(params, &s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s params", method)
+		}
+		fn = func() error {
+			a.setStatusMessage(s.Text)
+			return nil
+		}
+	default:
+		return nil, plugin.ErrMethodNotFound
+	}
+
+	// QueueUpdateDraw waits for the update, queued from here it would block the select until the UI runs it
+	done := make(chan error, 1)
+	go a.app.QueueUpdateDraw(func() { done <- fn() })
+	select {
+	case <-a.ctx.Done():
+		return nil, a.ctx.Err()
+	case err := <-done:
+		return nil, err
+	}
+}
+
+// runPluginCommand sends the command/run request {name, args} to the plugin, its error is shown in the status bar.
+func (a *App) runPluginCommand(p *plugin.Plugin, name, args string) {
+	err := p.Call(a.ctx, "command/run", map[string]string{"name": name, "args": args}, nil)
+	if err != nil && a.ctx.Err() == nil {
+		a.app.QueueUpdateDraw(func() {
+			a.setStatusMessage(err.Error())
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ngavinsir/sqluy/dataviewer"
+	"github.com/ngavinsir/sqluy/event"
+	"github.com/ngavinsir/sqluy/fetcher"
+	"github.com/ngavinsir/sqluy/theme"
+	"github.com/rivo/tview"
+)
+
+// refreshSchema introspects the schema in the background, superseding a running refresh.
+// The stale schema is used until the refresh is done.
+func (a *App) refreshSchema() {
+	if a.schemaCancel != nil {
+		a.schemaCancel()
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.schemaCancel = cancel
+	a.schemaStart = time.Now()
+	a.schemaLoading.Store(true)
+
+	f := a.fetcher
+	go func() {
+		schema, err := f.Schema(ctx)
+		a.app.QueueUpdateDraw(func() {
+			// superseded by another refresh or the app is quitting
+			if ctx.Err() != nil {
+				return
+			}
+			cancel()
+			a.schemaCancel = nil
+			a.schemaLoading.Store(false)
+			defer a.bus.Publish(event.SchemaRefreshed{Err: err})
+			if err != nil {
+				a.setStatusMessage(err.Error())
+				return
+			}
+			a.schema = schema
+		})
+	}()
+}
+
+// tableNames returns the table names of the last refreshed schema.
+func (a *App) tableNames() []string {
+	names := make([]string, len(a.schema.Tables))
+	for i, t := range a.schema.Tables {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// applyDialect highlights the editor with the configured dialect of the connection, or its driver dialect.
+func (a *App) applyDialect() {
+	name := a.cfg.Dialects[a.fetcher.Path()]
+	if name == "" {
+		name = a.fetcher.Dialect()
+	}
+	err := a.editor.SetDialect(name)
+	if err != nil {
+		a.setStatusMessage(err.Error())
+	}
+}
+
+// showSchema shows the table names, or the columns of the given table.
+func (a *App) showSchema(table string) error {
+	if table == "" {
+		names := a.tableNames()
+		a.setStatusMessage(fmt.Sprintf("%d tables: %s", len(names), strings.Join(names, " ")))
+		return nil
+	}
+
+	t, ok := a.schema.Table(table)
+	if !ok {
+		return fmt.Errorf("unknown table: %s", table)
+	}
+	a.setStatusMessage(t.Name + ": " + strings.Join(t.Columns, " "))
+	return nil
+}
+
+// newSchemaPane returns the pane listing the tables of the last refreshed schema with their columns.
+// Enter or l loads a select of the table into the editor, x executes it on the current connection.
+func (a *App) newSchemaPane() pane {
+	list := tview.NewList().ShowSecondaryText(false).SetHighlightFullLine(true)
+	list.SetBorder(true).SetTitle(" Schema (enter: load, x: execute) ").SetTitleAlign(tview.AlignLeft)
+
+	done := func() {
+		a.FocusPane("editor")
+	}
+	// tables is the schema listed, a refresh is listed the next time it's shown
+	var tables []fetcher.Table
+	selectQuery := func(t fetcher.Table) string {
+		return "SELECT * FROM " + dataviewer.QuoteIdentifier(t.Name) + ";"
+	}
+	capture := listCapture(done)
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyRune && event.Rune() == 'x' {
+			if len(tables) == 0 {
+				return nil
+			}
+			t := tables[list.GetCurrentItem()]
+			done()
+			a.executeQuery(selectQuery(t))
+			return nil
+		}
+		return capture(event)
+	})
+
+	fill := func() {
+		list.Clear()
+		tables = a.schema.Tables
+		for _, t := range tables {
+			list.AddItem(fmt.Sprintf("%s [%s]%s[-]", tview.Escape(t.Name), theme.Current().Dim, tview.Escape(strings.Join(t.Columns, ", "))), "", 0, func() {
+				a.setQuery(selectQuery(t))
+				done()
+			})
+		}
+	}
+	a.dataviewerPage.AddPage("schema", list, true, false)
+	return pane{box: list.Box, primitive: list, page: "schema", fill: fill}
+}
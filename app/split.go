@@ -0,0 +1,47 @@
+package app
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/rivo/tview"
+)
+
+// splitEditor shows a second view of the editor text below the editor, or beside it when vertical, and focuses it.
+func (a *App) splitEditor(vertical bool) error {
+	if a.splitView != nil {
+		return errors.New("the editor is already split")
+	}
+
+	direction := tview.FlexRow
+	if vertical {
+		direction = tview.FlexColumn
+	}
+	v := a.editor.Split()
+	a.editorFlex.SetDirection(direction).AddItem(v, 0, 1, false)
+	a.splitView = v
+
+	i := slices.IndexFunc(a.panes, func(p pane) bool { return p.name == "editor" })
+	a.panes = slices.Insert(a.panes, i+1, pane{name: "split", box: v.Box, primitive: v})
+	a.FocusPane("split")
+	return nil
+}
+
+// closeSplit closes the split view of the editor, focusing the editor if the split was focused.
+func (a *App) closeSplit() error {
+	if a.splitView == nil {
+		return errors.New("the editor isn't split")
+	}
+
+	focused := a.panes[a.currentView].name
+	if focused == "split" {
+		focused = "editor"
+	}
+	a.editorFlex.RemoveItem(a.splitView)
+	a.splitView.Close()
+	a.splitView = nil
+	a.panes = slices.DeleteFunc(a.panes, func(p pane) bool { return p.name == "split" })
+	// the pane indexes after the split moved
+	a.FocusPane(focused)
+	return nil
+}
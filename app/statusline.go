@@ -0,0 +1,100 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rivo/uniseg"
+)
+
+// statuslineSegment returns the text of an app status bar segment, empty if there's nothing to show.
+func (a *App) statuslineSegment(name string) string {
+	tabState := a.tabStates[a.currentTab]
+
+	switch name {
+	case "message":
+		if time.Since(a.statusTime) < statusMessageDuration {
+			return a.statusMessage
+		}
+	case "filter":
+		return a.dataviewer.FilterSummary()
+	case "rows":
+		if !tabState.executionFinish.IsZero() {
+			return fmt.Sprintf("%d rows", tabState.pageRowCount)
+		}
+	case "memory":
+		if usage := a.dataviewer.MemoryUsage(); usage > 0 {
+			text := "~" + formatBytes(usage)
+			if a.overMemoryLimit() {
+				text += " (over limit)"
+			}
+			return text
+		}
+	case "duration":
+		if tabState.executionStart.IsZero() {
+			return ""
+		}
+		now := time.Now()
+		if tabState.executionFinish.After(tabState.executionStart) {
+			now = tabState.executionFinish
+		}
+		text := now.Sub(tabState.executionStart).Round(time.Millisecond).String()
+		if tabState.status == TabStatusExecuting {
+			text = "executing... " + text
+		}
+		if tabState.previousDuration > 0 {
+			text += " (was " + tabState.previousDuration.Round(time.Millisecond).String() + ")"
+		}
+		return text
+	case "file":
+		return a.fileName
+	case "dirty":
+		if a.dirty {
+			return "[+]"
+		}
+	case "connection":
+		return a.fetcher.Name()
+	case "schema":
+		if a.schemaLoading.Load() {
+			frame := int(time.Since(a.schemaStart)/spinnerInterval) % len(spinnerFrames)
+			return fmt.Sprintf("%c refreshing schema", spinnerFrames[frame])
+		}
+	}
+	return ""
+}
+
+func (a *App) statuslineText(names []string) string {
+	var texts []string
+	for _, name := range names {
+		if text := a.statuslineSegment(name); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "  ")
+}
+
+// drawStatusline fills the status bar with the left and right segments.
+func (a *App) drawStatusline() {
+	left := a.statuslineText(a.cfg.Statusline.Left)
+	right := a.statuslineText(a.cfg.Statusline.Right)
+
+	_, _, w, _ := a.statusText.GetInnerRect()
+	padding := max(w-uniseg.StringWidth(left)-uniseg.StringWidth(right), 1)
+	// the status bar has no color tags, the segments are shown as they are
+	a.statusText.SetText(left + strings.Repeat(" ", padding) + right)
+}
+
+// formatBytes returns the size with a binary unit, e.g. 1.5 MB.
+func formatBytes(n int) string {
+	const unit = 1 << 10
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	size, exp := float64(n)/unit, 0
+	for size >= unit && exp < 3 {
+		size /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", size, "KMGT"[exp])
+}
@@ -0,0 +1,181 @@
+package app
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ngavinsir/sqluy/dataviewer"
+	"github.com/ngavinsir/sqluy/fetcher"
+)
+
+// runMetaCommands runs the psql-like meta commands on the leading lines of the query starting with a backslash,
+// e.g. \set, and returns the rest of the query. A meta command listing the database objects, e.g. \dt,
+// returns its introspection query instead.
+func (a *App) runMetaCommands(query string) (string, error) {
+	for {
+		line, rest, _ := strings.Cut(strings.TrimLeft(query, " \t\n"), "\n")
+		if !strings.HasPrefix(line, `\`) {
+			return query, nil
+		}
+		query = rest
+
+		name, args, _ := strings.Cut(strings.TrimSpace(line[1:]), " ")
+		args = strings.TrimSpace(args)
+		var err error
+		switch name {
+		case "set":
+			err = a.setVariable(args)
+		case "unset":
+			err = a.unsetVariable(args)
+		case "?":
+			a.setStatusMessage(strings.Join(append([]string{`\set [name value]`, `\unset {name}...`}, fetcher.MetaCommands...), "  "))
+		default:
+			// the other meta commands are introspection queries of the database, run instead of the rest
+			return a.fetcher.MetaQuery(name, args)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// setVariable sets the session variable to the value, a value in single quotes is unquoted.
+// Without arguments, it shows the variables.
+func (a *App) setVariable(args string) error {
+	if args == "" {
+		if len(a.variables) == 0 {
+			a.setStatusMessage("no variables")
+			return nil
+		}
+		names := slices.Sorted(maps.Keys(a.variables))
+		values := make([]string, len(names))
+		for i, name := range names {
+			values[i] = name + " = " + a.variables[name]
+		}
+		a.setStatusMessage(strings.Join(values, ", "))
+		return nil
+	}
+
+	name, value, _ := strings.Cut(args, " ")
+	if !validVariableName(name) {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	value = strings.TrimSpace(value)
+	if len(value) > 1 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	a.variables[name] = value
+	return nil
+}
+
+// unsetVariable deletes the session variables.
+func (a *App) unsetVariable(args string) error {
+	names := strings.Fields(args)
+	if len(names) == 0 {
+		return fmt.Errorf("usage: \\unset {name}...")
+	}
+	for _, name := range names {
+		delete(a.variables, name)
+	}
+	return nil
+}
+
+func validVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := range len(name) {
+		if !isVariableByte(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVariableByte(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// substituteVariables replaces :name with the variable value, :'name' with the value as a string literal,
+// and :"name" with the value as an identifier. String literals, quoted identifiers, comments, and casts
+// like ::int are left alone, so are unknown variables, e.g. sqlite parameters.
+func substituteVariables(query string, variables map[string]string) string {
+	if len(variables) == 0 {
+		return query
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				end = len(query) - i - 2
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 2
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i - 4
+			}
+			b.WriteString(query[i : i+end+4])
+			i += end + 4
+		case strings.HasPrefix(query[i:], "::"):
+			b.WriteString("::")
+			i += 2
+		case c == ':':
+			value, n := substituteVariable(query[i+1:], variables)
+			if n == 0 {
+				b.WriteByte(c)
+				i++
+				continue
+			}
+			b.WriteString(value)
+			i += n + 1
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// substituteVariable returns the value of the variable reference after a colon and its length,
+// 0 if it's not a known variable.
+func substituteVariable(s string, variables map[string]string) (string, int) {
+	quote := byte(0)
+	if s != "" && (s[0] == '\'' || s[0] == '"') {
+		quote = s[0]
+		s = s[1:]
+	}
+
+	n := 0
+	for n < len(s) && isVariableByte(s[n]) {
+		n++
+	}
+	value, ok := variables[s[:n]]
+	if n == 0 || !ok {
+		return "", 0
+	}
+
+	switch {
+	case quote == 0:
+		return value, n
+	case n == len(s) || s[n] != quote:
+		return "", 0
+	case quote == '\'':
+		return dataviewer.QuoteValue(value), n + 2
+	default:
+		return dataviewer.QuoteIdentifier(value), n + 2
+	}
+}
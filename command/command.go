@@ -0,0 +1,120 @@
+// Package command is the registry of the named commands run from the command line
+package command
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type (
+	// Command is a named operation, Run receives the rest of the command line as args.
+	// Completer completes its arguments on the command line, nil completes nothing.
+	Command struct {
+		Name      string
+		Aliases   []string
+		Usage     string
+		Run       func(args string) error
+		Completer Completer
+	}
+
+	Registry struct {
+		commands map[string]*Command
+		depth    int
+	}
+)
+
+// maxDepth limits user commands running other user commands, stopping recursive ones
+const maxDepth = 10
+
+var ErrUnknownCommand = errors.New("unknown command")
+
+func NewRegistry() *Registry {
+	return &Registry{commands: make(map[string]*Command)}
+}
+
+// Register adds the command, its name and aliases must not be registered yet.
+func (r *Registry) Register(c Command) error {
+	for _, name := range append([]string{c.Name}, c.Aliases...) {
+		if r.commands[name] != nil {
+			return fmt.Errorf("command: %s is already registered", name)
+		}
+	}
+
+	for _, name := range append([]string{c.Name}, c.Aliases...) {
+		r.commands[name] = &c
+	}
+	return nil
+}
+
+// RegisterAlias adds a user command expanding to the command line, the args are appended to it.
+// The args are completed by the completer of the expanded command.
+func (r *Registry) RegisterAlias(name, line string) error {
+	return r.Register(Command{
+		Name:  name,
+		Usage: line,
+		Run: func(args string) error {
+			return r.Run(strings.TrimSpace(line + " " + args))
+		},
+		Completer: CompleterFunc(func(word string) []string {
+			target, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+			c := r.commands[target]
+			if c == nil || c.Completer == nil || r.depth >= maxDepth {
+				return nil
+			}
+			r.depth++
+			defer func() { r.depth-- }()
+			return c.Completer.Complete(word)
+		}),
+	})
+}
+
+// Get returns the command by its name or alias.
+func (r *Registry) Get(name string) (Command, bool) {
+	c := r.commands[name]
+	if c == nil {
+		return Command{}, false
+	}
+	return *c, true
+}
+
+// Names returns the command names without aliases, sorted.
+func (r *Registry) Names() []string {
+	var names []string
+	for name, c := range r.commands {
+		if name == c.Name {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Complete returns the command names and aliases starting with the prefix, sorted.
+func (r *Registry) Complete(prefix string) []string {
+	var names []string
+	for name := range r.commands {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Run runs the command line, the first word is the command name.
+func (r *Registry) Run(line string) error {
+	name, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+	c := r.commands[name]
+	if c == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, name)
+	}
+	if r.depth >= maxDepth {
+		return fmt.Errorf("command: %s is nested too deep", name)
+	}
+
+	r.depth++
+	defer func() { r.depth-- }()
+	return c.Run(strings.TrimSpace(args))
+}
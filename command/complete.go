@@ -0,0 +1,83 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type (
+	// Completer returns the completions of the argument being typed.
+	Completer interface {
+		Complete(word string) []string
+	}
+
+	CompleterFunc func(word string) []string
+)
+
+func (f CompleterFunc) Complete(word string) []string {
+	return f(word)
+}
+
+// Words completes from the words returned by the function, sorted.
+func Words(words func() []string) Completer {
+	return CompleterFunc(func(word string) []string {
+		var res []string
+		for _, w := range words() {
+			if strings.HasPrefix(w, word) {
+				res = append(res, w)
+			}
+		}
+		slices.Sort(res)
+		return slices.Compact(res)
+	})
+}
+
+// Files completes file paths, directories end with a slash.
+var Files Completer = CompleterFunc(func(word string) []string {
+	dir, prefix := filepath.Split(word)
+	// an absolute dir is read as is, an empty one is the working directory
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var res []string
+	for _, entry := range entries {
+		name := entry.Name()
+		// hidden files are completed only when asked for
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if entry.IsDir() {
+			name += string(filepath.Separator)
+		}
+		res = append(res, dir+name)
+	}
+	return res
+})
+
+// CompleteLine returns the completed command lines, completing the command name,
+// or the last argument with the command completer.
+func (r *Registry) CompleteLine(line string) []string {
+	name, args, hasArgs := strings.Cut(line, " ")
+	if !hasArgs {
+		return r.Complete(name)
+	}
+
+	c := r.commands[name]
+	if c == nil || c.Completer == nil {
+		return nil
+	}
+	i := strings.LastIndex(args, " ") + 1
+	var lines []string
+	for _, s := range c.Completer.Complete(args[i:]) {
+		lines = append(lines, line[:len(line)-len(args)+i]+s)
+	}
+	return lines
+}
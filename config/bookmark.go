@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Bookmarks is the bookmarked 0-based rows of the query files, keyed by absolute file path.
+type Bookmarks map[string][]int
+
+func bookmarksPath() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "bookmarks.json"), nil
+}
+
+// LoadBookmarks reads the persisted bookmarks, a missing file results in empty bookmarks.
+func LoadBookmarks() (Bookmarks, error) {
+	b := make(Bookmarks)
+
+	path, err := bookmarksPath()
+	if err != nil {
+		return b, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return b, fmt.Errorf("config: error reading %s: %w", path, err)
+	}
+
+	err = json.Unmarshal(data, &b)
+	if err != nil {
+		return b, fmt.Errorf("config: error parsing %s: %w", path, err)
+	}
+	return b, nil
+}
+
+func (b Bookmarks) Save() error {
+	path, err := bookmarksPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("config: error encoding bookmarks: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return fmt.Errorf("config: error creating %s: %w", filepath.Dir(path), err)
+	}
+	err = os.WriteFile(path, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("config: error writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ColumnWidths is the dataviewer column width overrides per header, keyed by table name or query.
+type ColumnWidths map[string]map[string]int
+
+func columnWidthsPath() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "column_widths.json"), nil
+}
+
+// LoadColumnWidths reads the persisted column widths, a missing file results in empty column widths.
+func LoadColumnWidths() (ColumnWidths, error) {
+	c := make(ColumnWidths)
+
+	path, err := columnWidthsPath()
+	if err != nil {
+		return c, err
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("config: error reading %s: %w", path, err)
+	}
+
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return c, fmt.Errorf("config: error parsing %s: %w", path, err)
+	}
+	return c, nil
+}
+
+func (c ColumnWidths) Save() error {
+	path, err := columnWidthsPath()
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("config: error encoding column widths: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return fmt.Errorf("config: error creating %s: %w", filepath.Dir(path), err)
+	}
+	err = os.WriteFile(path, b, 0o644)
+	if err != nil {
+		return fmt.Errorf("config: error writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type (
+	Dataviewer struct {
+		// TimeFormat is a go time layout used to render temporal columns, empty keeps the original format
+		TimeFormat string `json:"time_format"`
+		// TimeZone is "local", "UTC", or an IANA time zone name, empty keeps the original time zone
+		TimeZone string `json:"time_zone"`
+		// PageSize limits the rows fetched per select query, 0 fetches all rows
+		PageSize int `json:"page_size"`
+		// RawOrder makes yanks and exports of marked rows keep the fetched order instead of the sorted view
+		RawOrder bool `json:"raw_order"`
+		// HeaderLines limits the header height, longer column names are cut, 0 wraps them fully
+		HeaderLines int `json:"header_lines"`
+		// MemoryLimit is the approximate size in MB of the loaded results above which a warning is shown, 0 disables it
+		MemoryLimit int `json:"memory_limit"`
+	}
+
+	// Editor is the editor options, also changeable at runtime with :set
+	Editor struct {
+		// Number shows the line numbers, relative to the cursor line with RelativeNumber
+		Number         bool `json:"number"`
+		RelativeNumber bool `json:"relativenumber"`
+		// IgnoreCase makes search case insensitive
+		IgnoreCase bool `json:"ignorecase"`
+		// SmartCase makes a search with uppercase letters case sensitive even with IgnoreCase
+		SmartCase bool `json:"smartcase"`
+		// TabStop is the width of a tab
+		TabStop int `json:"tabstop"`
+		// ShiftWidth is the width of an indent level of the shift operators, 0 uses TabStop
+		ShiftWidth int `json:"shiftwidth"`
+		// ScrollOff is the minimum number of lines kept above and below the cursor
+		ScrollOff int `json:"scrolloff"`
+		// Wrap continues the lines longer than the editor width on the next screen rows instead of scrolling sideways
+		Wrap bool `json:"wrap"`
+		// TrimWhitespace strips the trailing whitespace and keeps a single trailing newline
+		// when the query is saved or executed
+		TrimWhitespace bool `json:"trimwhitespace"`
+		// Statusline is the segments of the editor status line,
+		// built-in segments are mode, readonly, pending, search, selection, progress, diagnostic, position, file, dirty, and connection
+		Statusline Statusline `json:"statusline"`
+	}
+
+	// Statusline is the segment names shown on the left and right side of a status line
+	Statusline struct {
+		Left  []string `json:"left"`
+		Right []string `json:"right"`
+	}
+
+	// Focus is the order and keys of the pane focus, keys are in keymap notation, e.g. <C-h>
+	Focus struct {
+		// Order is the pane names cycled through, built-in panes are editor, results, schema, and history
+		Order []string `json:"order"`
+		Next  string   `json:"next"`
+		Prev  string   `json:"prev"`
+		// Jump maps keys to the pane they focus, e.g. {"<M-1>": "editor"}
+		Jump map[string]string `json:"jump"`
+		// Toggle flips the focus between the editor and the results
+		Toggle string `json:"toggle"`
+	}
+
+	// Flash is the jump labels of the flash motion in the editor and the dataviewer
+	Flash struct {
+		// Alphabet is the label characters, the earlier ones label the closer matches
+		Alphabet string `json:"alphabet"`
+		// Placement is where the label is drawn, "after" or "before" the match
+		Placement string `json:"placement"`
+		// MultiChar labels the matches left when the alphabet runs out with two characters
+		MultiChar bool `json:"multi_char"`
+	}
+
+	Config struct {
+		// Theme is the built-in theme name: dark, light, or high-contrast
+		Theme string `json:"theme"`
+		// AmbiguousWidth is the cell width of east asian ambiguous characters, "narrow" or "wide",
+		// wide matches most CJK terminal setups
+		AmbiguousWidth string     `json:"ambiguous_width"`
+		Dataviewer     Dataviewer `json:"dataviewer"`
+		Editor         Editor     `json:"editor"`
+		Flash          Flash      `json:"flash"`
+		// Statusline is the segments of the app status bar,
+		// built-in segments are message, filter, rows, memory, duration, file, dirty, connection, and schema
+		Statusline Statusline `json:"statusline"`
+		Focus      Focus      `json:"focus"`
+		// Quit is the key quitting the app, in keymap notation
+		Quit string `json:"quit"`
+		// Rerun is the key executing the last query of the tab again from any pane, in keymap notation
+		Rerun string `json:"rerun"`
+		// Connection is the sqlite database opened at startup
+		Connection string `json:"connection"`
+		// Dialects maps connections to their SQL dialect: sql, sqlite, postgres, or mysql,
+		// other connections use the dialect of their driver
+		Dialects map[string]string `json:"dialects"`
+		// Dashboard shows the recent connections, files, and queries at startup
+		Dashboard bool `json:"dashboard"`
+		// Autosave is the interval in seconds to save the modified query to its file,
+		// or to the swap file if it has none, 0 disables it
+		Autosave int `json:"autosave"`
+		// Queries is the saved queries by name, listed in the dashboard
+		Queries map[string]string `json:"queries"`
+		// Commands is the user commands, the name mapped to the command line it runs, e.g. {"nonu": "set nonumber"}
+		Commands map[string]string `json:"commands"`
+		// Announce is the file plain text lines describing the app state changes are appended to, e.g. a fifo
+		// read by a screen reader: the focused pane, the mode, the cursor position, the selected cell,
+		// the query status, and the status messages. Empty disables it
+		Announce string `json:"announce"`
+		// Startup is the command lines run in order once the app is started, like a vimrc,
+		// e.g. ["connect ./app.db", "edit report.sql", "set nonumber"]
+		Startup []string `json:"startup"`
+		// Plugins is the experimental plugins by name, executables talking JSON-RPC over stdio,
+		// see the plugin package for the protocol
+		Plugins map[string]Plugin `json:"plugins"`
+	}
+
+	// Plugin is the command starting a plugin
+	Plugin struct {
+		Command string   `json:"command"`
+		Args    []string `json:"args"`
+	}
+)
+
+// Default returns the config used when there's no config file.
+func Default() Config {
+	return Config{
+		Theme:          "dark",
+		AmbiguousWidth: "narrow",
+		Dataviewer: Dataviewer{
+			PageSize:    1000,
+			HeaderLines: 1,
+			MemoryLimit: 256,
+		},
+		Editor: Editor{
+			Number:         true,
+			RelativeNumber: true,
+			TabStop:        4,
+			Statusline: Statusline{
+				Left:  []string{"mode", "readonly", "pending"},
+				Right: []string{"search", "selection", "progress", "diagnostic", "position"},
+			},
+		},
+		Flash: Flash{
+			Alphabet:  "abcdefghijkmnpqrtwxyzABCDEFGHJKLMNPQRTUVWXY",
+			Placement: "after",
+			MultiChar: true,
+		},
+		Statusline: Statusline{
+			Left:  []string{"message"},
+			Right: []string{"schema", "filter", "memory", "duration"},
+		},
+		Quit:       "<C-q>",
+		Rerun:      "<F5>",
+		Connection: "./chinook.db",
+		Dashboard:  true,
+		Autosave:   30,
+		Focus: Focus{
+			Order: []string{"editor", "results", "schema", "history"},
+			Next:  "<C-h>",
+			Prev:  "<C-l>",
+			Jump: map[string]string{
+				"<M-1>": "editor",
+				"<M-2>": "results",
+				"<M-3>": "schema",
+				"<M-4>": "history",
+			},
+			Toggle: "<C-t>",
+		},
+	}
+}
+
+// Path returns the config file location, $XDG_CONFIG_HOME/sqluy/config.json by default.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("config: error getting config dir: %w", err)
+	}
+	return filepath.Join(dir, "sqluy", "config.json"), nil
+}
+
+// SwapPath returns the file autosaving the query that isn't saved to a file, next to the config file.
+func SwapPath() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "swap.sql"), nil
+}
+
+// OpenLog opens the app log file next to the config file for appending, log.txt, the log and the plugin
+// stderr mustn't be written over the terminal UI.
+func OpenLog() (*os.File, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	path = filepath.Join(filepath.Dir(path), "log.txt")
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("config: error creating %s: %w", filepath.Dir(path), err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("config: error opening %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Load reads the config file, a missing file results in the default config.
+func Load() (Config, error) {
+	c := Default()
+
+	path, err := Path()
+	if err != nil {
+		return c, err
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("config: error reading %s: %w", path, err)
+	}
+
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return c, fmt.Errorf("config: error parsing %s: %w", path, err)
+	}
+
+	_, err = c.Dataviewer.TimeLocation()
+	if err != nil {
+		return c, err
+	}
+	if c.AmbiguousWidth != "narrow" && c.AmbiguousWidth != "wide" {
+		return c, fmt.Errorf("config: invalid ambiguous width %s", c.AmbiguousWidth)
+	}
+	if c.Editor.TabStop < 1 || c.Editor.ScrollOff < 0 || c.Editor.ShiftWidth < 0 {
+		return c, fmt.Errorf("config: invalid editor tabstop %d, scrolloff %d, or shiftwidth %d",
+			c.Editor.TabStop, c.Editor.ScrollOff, c.Editor.ShiftWidth)
+	}
+	if c.Flash.Alphabet == "" || c.Flash.Placement != "after" && c.Flash.Placement != "before" {
+		return c, fmt.Errorf("config: invalid flash alphabet %q or placement %s", c.Flash.Alphabet, c.Flash.Placement)
+	}
+	if c.Autosave < 0 {
+		return c, fmt.Errorf("config: invalid autosave interval %d", c.Autosave)
+	}
+	return c, nil
+}
+
+// TimeLocation returns the location of the configured time zone, nil if it's not set.
+func (d Dataviewer) TimeLocation() (*time.Location, error) {
+	switch d.TimeZone {
+	case "":
+		return nil, nil
+	case "local":
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(d.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("config: invalid time zone %s: %w", d.TimeZone, err)
+	}
+	return loc, nil
+}
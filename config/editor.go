@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Set applies a vim-like :set argument, e.g. "number", "nonumber", "invnumber", "number!", or "tabstop=2".
+func (e *Editor) Set(arg string) error {
+	if name, value, ok := strings.Cut(arg, "="); ok {
+		p := e.intOption(name)
+		if p == nil {
+			return fmt.Errorf("config: unknown number option %s", name)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("config: invalid value of %s: %w", name, err)
+		}
+		if n < 0 || (n == 0 && p == &e.TabStop) {
+			return fmt.Errorf("config: invalid value of %s: %d", name, n)
+		}
+		*p = n
+		return nil
+	}
+
+	name := strings.TrimSuffix(arg, "!")
+	toggle := name != arg
+	if p := e.boolOption(name); p != nil {
+		*p = !toggle || !*p
+		return nil
+	}
+	if p := e.boolOption(strings.TrimPrefix(name, "inv")); p != nil && strings.HasPrefix(name, "inv") {
+		*p = !*p
+		return nil
+	}
+	if p := e.boolOption(strings.TrimPrefix(name, "no")); p != nil && strings.HasPrefix(name, "no") && !toggle {
+		*p = false
+		return nil
+	}
+	return fmt.Errorf("config: unknown option %s", arg)
+}
+
+// Get returns the option value formatted like vim's :set name?.
+func (e *Editor) Get(name string) (string, error) {
+	if p := e.intOption(name); p != nil {
+		return fmt.Sprintf("%s=%d", name, *p), nil
+	}
+	if p := e.boolOption(name); p != nil {
+		if *p {
+			return name, nil
+		}
+		return "no" + name, nil
+	}
+	return "", fmt.Errorf("config: unknown option %s", name)
+}
+
+// OptionNames returns the option names accepted by Set, without the short names.
+func (e *Editor) OptionNames() []string {
+	return []string{"ignorecase", "number", "relativenumber", "scrolloff", "shiftwidth", "smartcase", "tabstop", "trimwhitespace", "wrap"}
+}
+
+func (e *Editor) boolOption(name string) *bool {
+	switch name {
+	case "number", "nu":
+		return &e.Number
+	case "relativenumber", "rnu":
+		return &e.RelativeNumber
+	case "ignorecase", "ic":
+		return &e.IgnoreCase
+	case "smartcase", "scs":
+		return &e.SmartCase
+	case "trimwhitespace", "trim":
+		return &e.TrimWhitespace
+	case "wrap":
+		return &e.Wrap
+	}
+	return nil
+}
+
+func (e *Editor) intOption(name string) *int {
+	switch name {
+	case "tabstop", "ts":
+		return &e.TabStop
+	case "scrolloff", "so":
+		return &e.ScrollOff
+	case "shiftwidth", "sw":
+		return &e.ShiftWidth
+	}
+	return nil
+}
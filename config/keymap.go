@@ -0,0 +1,37 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// KeymapPath returns the user keymap file location, next to the config file. It's in the format of the
+// default keymap.json, its bindings and macros, e.g. {"keys": ["Y"], "groups": ["n"], "macro": "y$"},
+// are merged over the defaults.
+func KeymapPath() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "keymap.json"), nil
+}
+
+// LoadKeymap reads the user keymap file, a missing file results in an empty string.
+func LoadKeymap() (string, error) {
+	path, err := KeymapPath()
+	if err != nil {
+		return "", err
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("config: error reading %s: %w", path, err)
+	}
+	return string(b), nil
+}
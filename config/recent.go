@@ -0,0 +1,95 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Recent is the recently used connections, query files, and queries, most recent first.
+type Recent struct {
+	Connections []string `json:"connections"`
+	Files       []string `json:"files"`
+	Queries     []string `json:"queries"`
+}
+
+// recentLimit is the number of entries kept per list
+const recentLimit = 10
+
+func recentPath() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "recent.json"), nil
+}
+
+// LoadRecent reads the recently used entries, a missing file results in empty lists.
+func LoadRecent() (Recent, error) {
+	var r Recent
+
+	path, err := recentPath()
+	if err != nil {
+		return r, err
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return r, fmt.Errorf("config: error reading %s: %w", path, err)
+	}
+
+	err = json.Unmarshal(b, &r)
+	if err != nil {
+		return r, fmt.Errorf("config: error parsing %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Save writes the recently used entries next to the config file.
+func (r Recent) Save() error {
+	path, err := recentPath()
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("config: error encoding recent: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return fmt.Errorf("config: error creating %s: %w", filepath.Dir(path), err)
+	}
+	err = os.WriteFile(path, b, 0o644)
+	if err != nil {
+		return fmt.Errorf("config: error writing %s: %w", path, err)
+	}
+	return nil
+}
+
+func (r *Recent) AddConnection(s string) {
+	r.Connections = addRecent(r.Connections, s)
+}
+
+func (r *Recent) AddFile(s string) {
+	r.Files = addRecent(r.Files, s)
+}
+
+func (r *Recent) AddQuery(s string) {
+	r.Queries = addRecent(r.Queries, s)
+}
+
+// addRecent moves or inserts the entry to the front, dropping the oldest over the limit.
+func addRecent(list []string, s string) []string {
+	list = slices.DeleteFunc(list, func(e string) bool { return e == s })
+	list = slices.Insert(list, 0, s)
+	return list[:min(len(list), recentLimit)]
+}
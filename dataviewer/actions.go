@@ -63,15 +63,49 @@ const (
 	ActionChange
 	ActionDelete
 	ActionYank
+	ActionEditRow
+	ActionToggleColumnTypes
+	ActionInspectCell
+	ActionYankHeaders
+	ActionYankHeaderLines
+	ActionNextPage
+	ActionPrevPage
+	ActionFrequency
+	ActionToggleMark
+	ActionClearMarks
+	ActionYankRows
+	ActionExportRows
+	ActionDeleteRows
+	ActionWidenColumn
+	ActionNarrowColumn
+	ActionResetColumnWidth
+	ActionFilterColumn
+	ActionClearFilters
+	ActionYankCell
+	ActionMoveLines
+	ActionSortColumn
+	ActionYankInList
+	ActionRunCommand
+	ActionEnableBackSearch
+	ActionMovePageDown
+	ActionMovePageUp
+	ActionEnableColumnSearch
+	ActionScrollCellRight
+	ActionScrollCellLeft
+	ActionScrollCellHalfRight
+	ActionScrollCellHalfLeft
+	ActionResetCellScroll
+	ActionServerSortColumn
+	ActionExportSelection
 )
 
 var OperatorActions = []Action{ActionChange, ActionDelete, ActionYank, ActionVisual}
 var MotionActions = []Action{ActionMoveLeft, ActionMoveRight, ActionMoveUp, ActionMoveDown, ActionMoveEndOfLine, ActionMoveStartOfLine, ActionMoveFirstNonWhitespace, ActionFlash,
-	ActionMoveLastLine, ActionMoveFirstLine, ActionMoveEndOfWord, ActionMoveStartOfWord, ActionMoveBackStartOfWord, ActionMoveBackEndOfWord, ActionEnableSearch, ActionTil,
+	ActionMoveLastLine, ActionMoveFirstLine, ActionMoveEndOfWord, ActionMoveStartOfWord, ActionMoveBackStartOfWord, ActionMoveBackEndOfWord, ActionEnableSearch, ActionEnableBackSearch, ActionEnableColumnSearch, ActionTil,
 	ActionTilBack, ActionFind, ActionFindBack, ActionInside, ActionAround, ActionMoveStartOfBigWord, ActionMoveBackStartOfBigWord, ActionMoveEndOfBigWord, ActionMoveBackEndOfBigWord}
 var CountlessMotionActions = []Action{ActionMoveStartOfLine}
 var OperatorlessMotionActions = []Action{ActionMoveLeft, ActionMoveRight, ActionMoveUp, ActionMoveDown, ActionMoveEndOfLine, ActionMoveStartOfLine, ActionMoveFirstNonWhitespace,
-	ActionMoveLastLine, ActionMoveFirstLine, ActionMoveEndOfWord, ActionMoveStartOfWord, ActionMoveBackStartOfWord, ActionMoveBackEndOfWord, ActionEnableSearch, ActionTil,
+	ActionMoveLastLine, ActionMoveFirstLine, ActionMoveEndOfWord, ActionMoveStartOfWord, ActionMoveBackStartOfWord, ActionMoveBackEndOfWord, ActionEnableSearch, ActionEnableBackSearch, ActionEnableColumnSearch, ActionTil,
 	ActionTilBack, ActionFind, ActionFindBack, ActionMoveStartOfBigWord, ActionMoveBackStartOfBigWord, ActionMoveEndOfBigWord, ActionMoveBackEndOfBigWord, ActionFlash}
 var WaitingForRuneActions = []Action{ActionTil, ActionTilBack, ActionFind, ActionFindBack, ActionInside, ActionAround}
 
@@ -130,6 +164,40 @@ var actionMapper = map[Action]string{
 	ActionChange:                 "change",
 	ActionDelete:                 "delete",
 	ActionYank:                   "yank",
+	ActionEditRow:                "edit_row",
+	ActionToggleColumnTypes:      "toggle_column_types",
+	ActionInspectCell:            "inspect_cell",
+	ActionYankHeaders:            "yank_headers",
+	ActionYankHeaderLines:        "yank_header_lines",
+	ActionNextPage:               "next_page",
+	ActionPrevPage:               "prev_page",
+	ActionFrequency:              "frequency",
+	ActionToggleMark:             "toggle_mark",
+	ActionClearMarks:             "clear_marks",
+	ActionYankRows:               "yank_rows",
+	ActionExportRows:             "export_rows",
+	ActionDeleteRows:             "delete_rows",
+	ActionWidenColumn:            "widen_column",
+	ActionNarrowColumn:           "narrow_column",
+	ActionResetColumnWidth:       "reset_column_width",
+	ActionFilterColumn:           "filter_column",
+	ActionClearFilters:           "clear_filters",
+	ActionYankCell:               "yank_cell",
+	ActionMoveLines:              "move_lines",
+	ActionSortColumn:             "sort_column",
+	ActionYankInList:             "yank_in_list",
+	ActionRunCommand:             "run_command",
+	ActionEnableBackSearch:       "enable_back_search",
+	ActionMovePageDown:           "move_page_down",
+	ActionMovePageUp:             "move_page_up",
+	ActionEnableColumnSearch:     "enable_column_search",
+	ActionScrollCellRight:        "scroll_cell_right",
+	ActionScrollCellLeft:         "scroll_cell_left",
+	ActionScrollCellHalfRight:    "scroll_cell_half_right",
+	ActionScrollCellHalfLeft:     "scroll_cell_half_left",
+	ActionResetCellScroll:        "reset_cell_scroll",
+	ActionServerSortColumn:       "server_sort_column",
+	ActionExportSelection:        "export_selection",
 }
 var reverseActionMapper map[string]Action
 var reverseActionMapperOnce sync.Once
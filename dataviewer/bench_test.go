@@ -0,0 +1,64 @@
+package dataviewer
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ngavinsir/sqluy/keymap"
+)
+
+// benchData returns a synthetic result set of n rows with a mix of short, long, and numeric columns.
+func benchData(n int) ([]string, []map[string]string) {
+	headers := []string{"id", "name", "email", "amount", "created_at", "description"}
+	rows := make([]map[string]string, n)
+	for i := range rows {
+		rows[i] = map[string]string{
+			"id":          strconv.Itoa(i),
+			"name":        fmt.Sprintf("user %d", i),
+			"email":       fmt.Sprintf("user%d@example.com", i),
+			"amount":      strconv.FormatFloat(float64(i)*1.25, 'f', 2, 64),
+			"created_at":  "2024-01-02 03:04:05",
+			"description": fmt.Sprintf("a longer text value that is wider than the column, row %d", i),
+		}
+	}
+	return headers, rows
+}
+
+func BenchmarkDraw(b *testing.B) {
+	for _, n := range []int{1_000, 100_000} {
+		b.Run(fmt.Sprintf("rows=%d", n), func(b *testing.B) {
+			screen := tcell.NewSimulationScreen("UTF-8")
+			if err := screen.Init(); err != nil {
+				b.Fatal(err)
+			}
+			defer screen.Fini()
+			screen.SetSize(200, 50)
+
+			d := New(keymap.New(`{"keymaps": {}}`))
+			d.SetRect(0, 0, 200, 50)
+			d.SetData(benchData(n))
+			d.cursor = [2]int{n / 2, 2}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				d.Draw(screen)
+			}
+		})
+	}
+}
+
+func BenchmarkSetData(b *testing.B) {
+	for _, n := range []int{1_000, 100_000} {
+		b.Run(fmt.Sprintf("rows=%d", n), func(b *testing.B) {
+			d := New(keymap.New(`{"keymaps": {}}`))
+			headers, rows := benchData(n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				d.SetData(headers, rows)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package dataviewer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// IsBinary reports whether the value is not printable text, e.g. a BLOB column.
+func IsBinary(s string) bool {
+	if !utf8.ValidString(s) {
+		return true
+	}
+	for _, r := range s {
+		if r == '\t' || r == '\n' || r == '\r' {
+			continue
+		}
+		if unicode.IsControl(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// HexDump formats the value as hex and ascii columns, 16 bytes per line.
+func HexDump(s string) string {
+	var b strings.Builder
+	for offset := 0; offset < len(s); offset += 16 {
+		line := s[offset:min(offset+16, len(s))]
+
+		fmt.Fprintf(&b, "%08x  ", offset)
+		for i := range 16 {
+			if i < len(line) {
+				fmt.Fprintf(&b, "%02x ", line[i])
+			} else {
+				b.WriteString("   ")
+			}
+			if i == 7 {
+				b.WriteString(" ")
+			}
+		}
+
+		b.WriteString(" |")
+		for i := range len(line) {
+			if line[i] < 0x20 || line[i] > 0x7e {
+				b.WriteString(".")
+				continue
+			}
+			b.WriteByte(line[i])
+		}
+		b.WriteString("|\n")
+	}
+	return b.String()
+}
@@ -1,6 +1,8 @@
 package dataviewer
 
 import (
+	"image"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"github.com/rivo/uniseg"
@@ -13,6 +15,15 @@ type (
 		textColor  tcell.Color
 		bgColor    tcell.Color
 		topPadding int
+		offset     int
+		ellipsis   string
+		clip       image.Rectangle
+	}
+
+	// cluster is a grapheme cluster with its screen width.
+	cluster struct {
+		runes []rune
+		width int
 	}
 )
 
@@ -29,33 +40,136 @@ func NewCell(text string, x, y, w, h, topPadding int, textColor, bgColor, border
 	}
 }
 
+// SetEllipsis sets the text ending the last line when the text doesn't fit in the cell.
+func (c *Cell) SetEllipsis(ellipsis string) *Cell {
+	c.ellipsis = ellipsis
+	return c
+}
+
+// SetOffset skips the first n grapheme clusters of the text, the ellipsis shows the text is scrolled.
+func (c *Cell) SetOffset(n int) *Cell {
+	c.offset = n
+	return c
+}
+
+// SetClip limits the drawing to the given rectangle, e.g. the viewport of the dataviewer.
+func (c *Cell) SetClip(x, y, w, h int) *Cell {
+	c.clip = image.Rect(x, y, x+w, y+h)
+	return c
+}
+
+// Draw draws the border and the text wrapped on grapheme cluster boundaries, without tview,
+// so nothing is drawn outside of the clip rectangle.
 func (c *Cell) Draw(screen tcell.Screen) {
-	c.Box.DrawForSubclass(screen, c)
+	x, y, w, h := c.GetRect()
+	if w <= 0 || h <= 0 {
+		return
+	}
 
-	x, y, w, h := c.Box.GetInnerRect()
+	borderStyle := tcell.StyleDefault.Foreground(c.GetBorderColor()).Background(c.bgColor)
+	for row := y; row < y+h; row++ {
+		for col := x; col < x+w; col++ {
+			c.setContent(screen, col, row, ' ', nil, borderStyle)
+		}
+	}
+	for col := x + 1; col < x+w-1; col++ {
+		c.setContent(screen, col, y, tview.Borders.Horizontal, nil, borderStyle)
+		c.setContent(screen, col, y+h-1, tview.Borders.Horizontal, nil, borderStyle)
+	}
+	for row := y + 1; row < y+h-1; row++ {
+		c.setContent(screen, x, row, tview.Borders.Vertical, nil, borderStyle)
+		c.setContent(screen, x+w-1, row, tview.Borders.Vertical, nil, borderStyle)
+	}
+	c.setContent(screen, x, y, tview.Borders.TopLeft, nil, borderStyle)
+	c.setContent(screen, x+w-1, y, tview.Borders.TopRight, nil, borderStyle)
+	c.setContent(screen, x, y+h-1, tview.Borders.BottomLeft, nil, borderStyle)
+	c.setContent(screen, x+w-1, y+h-1, tview.Borders.BottomRight, nil, borderStyle)
 
-	textX := x
-	textY := y
-	if c.topPadding > 0 {
-		textY += c.topPadding
+	x, y, w, h = c.GetInnerRect()
+	y += c.topPadding
+	h -= c.topPadding
+	textStyle := tcell.StyleDefault.Foreground(c.textColor).Background(c.bgColor)
+	for i, line := range c.lines(w, h) {
+		textX := x
+		for _, cl := range line {
+			c.setContent(screen, textX, y+i, cl.runes[0], cl.runes[1:], textStyle)
+			textX += cl.width
+		}
 	}
-	state := -1
-	s := c.text
-	boundaries := 0
-	cluster := ""
-	for s != "" {
-		cluster, s, boundaries, state = uniseg.StepString(s, state)
-		textWidth := boundaries >> uniseg.ShiftWidth
-		if textX+textWidth > x+w {
-			textY++
-			textX = x
+}
+
+// lines wraps the text into at most h lines of w width. A cluster wider than a line is dropped,
+// the ellipsis replaces the end of the last line if the text is cut.
+func (c *Cell) lines(w, h int) [][]cluster {
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+
+	var lines [][]cluster
+	var line []cluster
+	lineWidth := 0
+	cut := false
+	cls := clusters(c.text)
+	if c.offset > 0 && c.offset < len(cls) {
+		cls = append(clusters(c.ellipsis), cls[c.offset:]...)
+	}
+	for _, cl := range cls {
+		if cl.width > w {
+			cut = true
+			continue
 		}
-		if textY >= y+h {
-			break
+		if lineWidth+cl.width > w {
+			lines = append(lines, line)
+			line, lineWidth = nil, 0
+			if len(lines) == h {
+				cut = true
+				break
+			}
 		}
+		line = append(line, cl)
+		lineWidth += cl.width
+	}
+	if len(lines) < h {
+		lines = append(lines, line)
+	}
+	if !cut || c.ellipsis == "" {
+		return lines
+	}
+
+	ellipsis := clusters(c.ellipsis)
+	ellipsisWidth := uniseg.StringWidth(c.ellipsis)
+	if ellipsisWidth > w {
+		return lines
+	}
+	last := lines[len(lines)-1]
+	lastWidth := 0
+	for _, cl := range last {
+		lastWidth += cl.width
+	}
+	for len(last) > 0 && lastWidth+ellipsisWidth > w {
+		lastWidth -= last[len(last)-1].width
+		last = last[:len(last)-1]
+	}
+	lines[len(lines)-1] = append(last, ellipsis...)
+	return lines
+}
+
+func clusters(s string) []cluster {
+	var res []cluster
+	state := -1
+	for s != "" {
+		var str string
+		var boundaries int
+		str, s, boundaries, state = uniseg.StepString(s, state)
+		res = append(res, cluster{runes: []rune(str), width: boundaries >> uniseg.ShiftWidth})
+	}
+	return res
+}
 
-		runes := []rune(cluster)
-		screen.SetContent(textX, textY, runes[0], runes[1:], tcell.StyleDefault.Foreground(c.textColor).Background(c.bgColor))
-		textX += textWidth
+// setContent sets the screen cell if it's inside the clip rectangle, or anywhere if there's none.
+func (c *Cell) setContent(screen tcell.Screen, x, y int, primary rune, combining []rune, style tcell.Style) {
+	if !c.clip.Empty() && !image.Pt(x, y).In(c.clip) {
+		return
 	}
+	screen.SetContent(x, y, primary, combining, style)
 }
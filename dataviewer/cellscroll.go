@@ -0,0 +1,30 @@
+package dataviewer
+
+// ScrollCell scrolls the text of the cursor cell by n grapheme clusters, to the right for a positive n,
+// so a value wider than its column can be read in place. Every cell keeps its offset until the view changes.
+func (d *Dataviewer) ScrollCell(n int) {
+	if d.cursor[0] < 1 || d.cursor[0] > len(d.rows) || d.cursor[1] >= len(d.headers) {
+		return
+	}
+
+	text := d.cellText(d.cursor[1], d.rows[d.cursor[0]-1][d.headers[d.cursor[1]]])
+	offset := max(0, min(d.cellOffsets[d.cursor]+n, len(clusters(text))-1))
+	if offset == 0 {
+		delete(d.cellOffsets, d.cursor)
+		return
+	}
+	if d.cellOffsets == nil {
+		d.cellOffsets = make(map[[2]int]int)
+	}
+	d.cellOffsets[d.cursor] = offset
+}
+
+// ScrollCellHalf scrolls the text of the cursor cell by n halves of its drawn width.
+func (d *Dataviewer) ScrollCellHalf(n int) {
+	d.ScrollCell(n * max(d.cursorWidth/2, 1))
+}
+
+// ResetCellScroll shows the text of the cursor cell from its start.
+func (d *Dataviewer) ResetCellScroll() {
+	delete(d.cellOffsets, d.cursor)
+}
@@ -0,0 +1,33 @@
+package dataviewer
+
+import "maps"
+
+func (d *Dataviewer) ResizeColumn(delta int) {
+	if d.cursor[1] >= len(d.headers) {
+		return
+	}
+
+	header := d.headers[d.cursor[1]]
+	width := d.getColTextWidth(d.cursor[1]) + delta
+	if width < 1 {
+		width = 1
+	}
+	d.columnWidths[header] = width
+	d.columnWidthsChanged()
+}
+
+func (d *Dataviewer) ResetColumnWidth() {
+	if d.cursor[1] >= len(d.headers) {
+		return
+	}
+
+	delete(d.columnWidths, d.headers[d.cursor[1]])
+	d.columnWidthsChanged()
+}
+
+func (d *Dataviewer) columnWidthsChanged() {
+	d.updateContentWidths()
+	if d.columnWidthFunc != nil {
+		d.columnWidthFunc(maps.Clone(d.columnWidths))
+	}
+}
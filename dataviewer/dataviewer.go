@@ -3,11 +3,18 @@ package dataviewer
 import (
 	_ "embed"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/ngavinsir/sqluy/config"
 	"github.com/ngavinsir/sqluy/editor"
+	"github.com/ngavinsir/sqluy/keymap"
+	"github.com/ngavinsir/sqluy/register"
+	"github.com/ngavinsir/sqluy/theme"
 	"github.com/ngavinsir/sqluy/vim"
 	"github.com/rivo/tview"
 	"github.com/rivo/uniseg"
@@ -16,52 +23,191 @@ import (
 type (
 	keymapper interface {
 		Get(keys []string, group string) ([]string, bool)
+		Args(keys []string, group, action string) keymap.Args
+		Macro(keys []string, group string) []string
 	}
 
 	Dataviewer struct {
-		keymapper  keymapper
-		runeRunner map[Action]func(r rune)
+		keymapper        keymapper
+		editRowFunc      func(headers []string, row map[string]string)
+		inspectFunc      func(header, value string)
+		pageFunc         func(delta int)
+		frequencyFunc    func(header string, headers []string, rows []map[string]string)
+		exitFunc         func()
+		exportFunc       func(headers []string, rows []map[string]string)
+		deleteRowsFunc   func(headers []string, rows []map[string]string)
+		filterPromptFunc func(header, expr string)
+		serverSortFunc   func(header string, desc bool)
+		commandFunc      func(string)
+		markedRows       map[int]struct{}
+		filters          map[string]Filter
+		columnWidths     map[string]int
+		columnWidthFunc  func(widths map[string]int)
+		timeFormat       string
+		timeLocation     *time.Location
+		runeRunner       map[Action]func(r rune)
 		*tview.Box
-		operatorRunner   map[Action]func(target [2]int)
-		motionRunner     map[Action]func() [2]int
-		actionRunner     map[Action]func()
-		searchEditor     *editor.Editor
-		pending          []string
-		rowHeights       []int
-		rows             []map[string]string
+		operatorRunner map[Action]func(target [2]int)
+		motionRunner   map[Action]func() [2]int
+		actionRunner   map[Action]func()
+		searchEditor   *editor.Editor
+		searchQuery    string
+		// searchHeader limits the search matches to its column, empty searches the whole grid
+		searchHeader   string
+		searchHistory  *editor.History
+		searchBackward bool
+		searchMatches  [][2]int
+		searchMatchSet map[[2]int]struct{}
+		flashOptions   config.Flash
+		flashLabels    map[string][2]int
+		flashCells     map[[2]int]string
+		flashPrefix    string
+		flashQueryLen  int
+		drawnCells     [][2]int
+		pending        []string
+		rowHeights     []int
+		rows           []map[string]string
+		loadedRows     []map[string]string
+		memoryUsage    int
+		view           []int
+		sortHeader     string
+		sortDesc       bool
+		// serverSortHeader is the column the query is ordered by, the sort of every row and not only the loaded ones
+		serverSortHeader string
+		serverSortDesc   bool
+		rawOrder         bool
+		headerLines      int
 		headers          []string
-		colWidths        []int
+		columnTypes      []string
+		contentWidths    []int
+		// cellOffsets is the scroll offset of the cells scrolled with zl, keyed by cursor position
+		cellOffsets map[[2]int]int
+		// cursorWidth is the drawn width of the cursor column
+		cursorWidth      int
 		visualStart      [2]int
 		offsets          [2]int
 		cursor           [2]int
 		lastMotion       Action
-		borderColor      tcell.Color
-		bgColor          tcell.Color
 		pendingAction    Action
-		textColor        tcell.Color
+		actionArgs       keymap.Args
+		macroDepth       int
 		pendingCount     int
-		visibleRight     int
 		visibleBottom    int
-		visibleLeft      int
 		visibleTop       int
 		waitingForMotion bool
+		showColumnTypes  bool
 		mode             mode
 	}
 )
 
-func New(km keymapper) *Dataviewer {
+func New(km keymapper, options ...func(*Dataviewer)) *Dataviewer {
 	d := &Dataviewer{
-		keymapper:    km,
-		Box:          tview.NewBox().SetBorder(true).SetTitle("Dataviewer").SetTitleAlign(tview.AlignLeft),
-		bgColor:      tview.Styles.PrimitiveBackgroundColor,
-		borderColor:  tcell.ColorGray,
-		textColor:    tcell.ColorWhite,
-		visibleLeft:  -1,
-		visibleRight: -1,
+		keymapper:       km,
+		Box:             tview.NewBox().SetBorder(true).SetTitle("Dataviewer").SetTitleAlign(tview.AlignLeft),
+		showColumnTypes: true,
+		markedRows:      make(map[int]struct{}),
+		columnWidths:    make(map[string]int),
+		filters:         make(map[string]Filter),
+		flashOptions:    config.Default().Flash,
+		searchHistory:   editor.NewHistory(),
+	}
+	for _, option := range options {
+		option(d)
+	}
+
+	d.actionRunner = map[Action]func(){
+		ActionEditRow:           d.EditRow,
+		ActionToggleColumnTypes: d.ToggleColumnTypes,
+		ActionInspectCell:       d.InspectCell,
+		ActionFrequency:         d.ShowFrequencies,
+		ActionToggleMark:        d.ToggleMark,
+		ActionWidenColumn: func() {
+			d.ResizeColumn(d.getActionCount())
+		},
+		ActionNarrowColumn: func() {
+			d.ResizeColumn(-d.getActionCount())
+		},
+		ActionResetColumnWidth: d.ResetColumnWidth,
+		ActionScrollCellRight: func() {
+			d.ScrollCell(d.getActionCount())
+		},
+		ActionScrollCellLeft: func() {
+			d.ScrollCell(-d.getActionCount())
+		},
+		ActionScrollCellHalfRight: func() {
+			d.ScrollCellHalf(d.getActionCount())
+		},
+		ActionScrollCellHalfLeft: func() {
+			d.ScrollCellHalf(-d.getActionCount())
+		},
+		ActionResetCellScroll: d.ResetCellScroll,
+		ActionFilterColumn:    d.FilterColumn,
+		ActionMoveNextSearch: func() {
+			d.MoveCursorTo(d.GetSearchCursor(d.searchDirection() * d.getActionCount()))
+		},
+		ActionMovePrevSearch: func() {
+			d.MoveCursorTo(d.GetSearchCursor(-d.searchDirection() * d.getActionCount()))
+		},
+		ActionClearFilters:     d.ClearFilters,
+		ActionSortColumn:       d.SortColumn,
+		ActionServerSortColumn: d.ServerSortColumn,
+		ActionMovePageDown: func() {
+			d.scrollRows(d.pageRows() * d.getActionCount())
+		},
+		ActionMovePageUp: func() {
+			d.scrollRows(-d.pageRows() * d.getActionCount())
+		},
+		ActionMoveLines: func() {
+			row := d.cursor[0] + d.actionArgs.Int("n", 1)*d.getActionCount()
+			d.MoveCursorTo([2]int{max(0, min(row, len(d.rows))), d.cursor[1]})
+		},
+		ActionClearMarks: d.ClearMarks,
+		ActionYankRows:   d.YankRows,
+		ActionYankCell:   d.YankCell,
+		ActionYankInList: d.YankInList,
+		ActionExportRows: d.ExportRows,
+		ActionVisualLine: d.VisualLine,
+		ActionSwitchVisualStart: func() {
+			d.cursor, d.visualStart = d.visualStart, d.cursor
+		},
+		ActionExportSelection: d.ExportSelection,
+		ActionDeleteRows:      d.DeleteRows,
+		ActionRunCommand: func() {
+			if d.commandFunc != nil && d.actionArgs.String("cmd") != "" {
+				d.commandFunc(d.actionArgs.String("cmd"))
+			}
+		},
+		ActionExit: func() {
+			if d.mode == visual || d.mode == vline {
+				d.mode = normal
+				return
+			}
+			if d.exitFunc != nil {
+				d.exitFunc()
+			}
+		},
+		ActionYankHeaders: func() {
+			register.Yank(register.Unnamed, strings.Join(d.headers, ", "), register.Charwise)
+		},
+		ActionNextPage: func() {
+			if d.pageFunc != nil {
+				d.pageFunc(1)
+			}
+		},
+		ActionPrevPage: func() {
+			if d.pageFunc != nil {
+				d.pageFunc(-1)
+			}
+		},
+		ActionYankHeaderLines: func() {
+			register.Yank(register.Unnamed, strings.Join(d.headers, "\n"), register.Linewise)
+		},
 	}
 
 	d.operatorRunner = map[Action]func(target [2]int){
-		ActionNone: d.MoveCursorTo,
+		ActionNone:   d.MoveCursorTo,
+		ActionYank:   d.YankUntil,
+		ActionVisual: d.VisualUntil,
 	}
 
 	d.motionRunner = map[Action]func() [2]int{
@@ -82,8 +228,10 @@ func New(km keymapper) *Dataviewer {
 		// ActionMoveEndOfWord:          d.GetEndOfWordCursor,
 		// ActionMoveBackEndOfWord:      d.GetBackEndOfWordCursor,
 		// ActionMoveBackStartOfWord:    d.GetBackStartOfWordCursor,
-		// ActionEnableSearch:           d.EnableSearch,
-		// ActionFlash:                  d.Flash,
+		ActionEnableSearch:       d.EnableSearch,
+		ActionEnableBackSearch:   d.EnableBackSearch,
+		ActionEnableColumnSearch: d.EnableColumnSearch,
+		ActionFlash:              d.Flash,
 		// ActionTil:                    d.GetTilCursor,
 		// ActionTilBack:                d.GetTilBackCursor,
 		// ActionFind:                   d.GetFindCursor,
@@ -95,23 +243,180 @@ func New(km keymapper) *Dataviewer {
 	return d
 }
 
+func (d *Dataviewer) SetEditRowFunc(f func(headers []string, row map[string]string)) *Dataviewer {
+	d.editRowFunc = f
+	return d
+}
+
+func (d *Dataviewer) SetInspectFunc(f func(header, value string)) *Dataviewer {
+	d.inspectFunc = f
+	return d
+}
+
+func (d *Dataviewer) SetPageFunc(f func(delta int)) *Dataviewer {
+	d.pageFunc = f
+	return d
+}
+
+func (d *Dataviewer) SetFrequencyFunc(f func(header string, headers []string, rows []map[string]string)) *Dataviewer {
+	d.frequencyFunc = f
+	return d
+}
+
+func (d *Dataviewer) SetExitFunc(f func()) *Dataviewer {
+	d.exitFunc = f
+	return d
+}
+
+func (d *Dataviewer) SetExportFunc(f func(headers []string, rows []map[string]string)) *Dataviewer {
+	d.exportFunc = f
+	return d
+}
+
+func (d *Dataviewer) SetDeleteRowsFunc(f func(headers []string, rows []map[string]string)) *Dataviewer {
+	d.deleteRowsFunc = f
+	return d
+}
+
+// SetCommandFunc sets a handler for the commands of the run_command keymaps, e.g. "wincmd j".
+func (d *Dataviewer) SetCommandFunc(f func(string)) *Dataviewer {
+	d.commandFunc = f
+	return d
+}
+
+// SetFilterPromptFunc sets a handler asking for the filter expression of the column, with the current expression.
+func (d *Dataviewer) SetFilterPromptFunc(f func(header, expr string)) *Dataviewer {
+	d.filterPromptFunc = f
+	return d
+}
+
+// SetServerSortFunc sets a handler running the query again ordered by the column, descending or not,
+// or without ordering when the header is empty.
+func (d *Dataviewer) SetServerSortFunc(f func(header string, desc bool)) *Dataviewer {
+	d.serverSortFunc = f
+	return d
+}
+
+// SetColumnWidths sets the width overrides per header, replacing the auto-fit content width.
+func (d *Dataviewer) SetColumnWidths(widths map[string]int) *Dataviewer {
+	d.columnWidths = widths
+	if d.columnWidths == nil {
+		d.columnWidths = make(map[string]int)
+	}
+	d.updateContentWidths()
+	return d
+}
+
+// SetColumnWidthFunc sets a handler called with the width overrides whenever a column is resized.
+func (d *Dataviewer) SetColumnWidthFunc(f func(widths map[string]int)) *Dataviewer {
+	d.columnWidthFunc = f
+	return d
+}
+
+func (d *Dataviewer) SetColumnTypes(types []string) *Dataviewer {
+	d.columnTypes = types
+	d.updateContentWidths()
+	return d
+}
+
+func (d *Dataviewer) ToggleColumnTypes() {
+	d.showColumnTypes = !d.showColumnTypes
+	d.updateContentWidths()
+}
+
+func (d *Dataviewer) isColumnTypesVisible() bool {
+	return d.showColumnTypes && len(d.columnTypes) == len(d.headers)
+}
+
 func (d *Dataviewer) SetData(headers []string, rows []map[string]string) {
 	d.headers = headers
-	d.rows = rows
+	d.loadedRows = rows
+	d.updateMemoryUsage()
 	d.cursor = [2]int{0, 0}
-	d.offsets = [2]int{0, 0}
-	d.visibleLeft = -1
-	d.visibleRight = -1
-	clear(d.colWidths)
+	clear(d.markedRows)
+	clear(d.filters)
+	d.sortHeader, d.sortDesc = "", false
+	d.applyView()
+}
+
+// ReplaceData sets the refreshed data of the same query, e.g. the next page or a rerun, keeping the view.
+// The filters and the sort of the columns still there are kept, and the cursor follows its row,
+// matched by the key columns when there are some or by its position otherwise.
+func (d *Dataviewer) ReplaceData(headers []string, rows []map[string]string, keys []string) {
+	cursor, offset := d.cursor, d.offsets[0]
+	var header string
+	if cursor[1] < len(d.headers) {
+		header = d.headers[cursor[1]]
+	}
+	var row map[string]string
+	if cursor[0] > 0 && cursor[0] <= len(d.rows) {
+		row = d.rows[cursor[0]-1]
+	}
+
+	d.headers = headers
+	d.loadedRows = rows
+	d.updateMemoryUsage()
+	clear(d.markedRows)
+	maps.DeleteFunc(d.filters, func(h string, _ Filter) bool { return !slices.Contains(headers, h) })
+	if !slices.Contains(headers, d.sortHeader) {
+		d.sortHeader, d.sortDesc = "", false
+	}
+	d.applyView()
+
+	d.cursor[0] = min(cursor[0], len(d.rows))
+	if i := d.rowIndexByKeys(row, keys); i >= 0 {
+		d.cursor[0] = i + 1
+	}
+	d.cursor[1] = min(cursor[1], max(len(headers)-1, 0))
+	if j := slices.Index(headers, header); j >= 0 {
+		d.cursor[1] = j
+	}
+	d.offsets[0] = min(offset, d.cursor[0])
+}
+
+// AppendRows adds the rows after the loaded ones, e.g. the next batch of a running query, keeping the view.
+func (d *Dataviewer) AppendRows(rows []map[string]string) {
+	if len(rows) == 0 {
+		return
+	}
+	cursor, offset := d.cursor, d.offsets[0]
+	d.loadedRows = append(d.loadedRows, rows...)
+	for _, row := range rows {
+		d.memoryUsage += rowSize(row)
+	}
+	d.applyView()
+	d.cursor = cursor
+	d.offsets[0] = offset
+}
+
+// rowIndexByKeys returns the index of the shown row with the same key values as the row, -1 if there's none.
+func (d *Dataviewer) rowIndexByKeys(row map[string]string, keys []string) int {
+	if row == nil || len(keys) == 0 {
+		return -1
+	}
+	for _, key := range keys {
+		if _, ok := row[key]; !ok || !slices.Contains(d.headers, key) {
+			return -1
+		}
+	}
+	return slices.IndexFunc(d.rows, func(r map[string]string) bool {
+		for _, key := range keys {
+			if r[key] != row[key] {
+				return false
+			}
+		}
+		return true
+	})
 }
 
 func (d *Dataviewer) Draw(screen tcell.Screen) {
-	defer func() {
-		fmt.Printf("cursor: %+v, offsets: %+v\n", d.cursor, d.offsets)
-		// fmt.Printf("vis left: %d, vis right: %d, colWidths: %+v\n", d.visibleLeft, d.visibleRight, d.colWidths)
-	}()
 	d.Box.DrawForSubclass(screen, d)
 
+	if d.searchEditor != nil {
+		defer d.searchEditor.Draw(screen)
+	}
+
+	d.drawnCells = d.drawnCells[:0]
 	if d.headers == nil {
 		return
 	}
@@ -121,8 +426,17 @@ func (d *Dataviewer) Draw(screen tcell.Screen) {
 	textY := y
 	textY += d.getHeaderHeight() + 1
 	textX = x
+	var widths []int
 	defer func() {
-		tview.Print(screen, fmt.Sprintf(" x:%d/%d y:%d/%d ", d.cursor[1], len(d.headers)-1, d.cursor[0], len(d.rows)), x+2, y+h, 20, tview.AlignLeft, tcell.ColorWhite)
+		position := fmt.Sprintf(" x:%d/%d y:%d/%d ", d.cursor[1], len(d.headers)-1, d.cursor[0], len(d.rows))
+		if offset := d.cellOffsets[d.cursor]; offset > 0 {
+			position += fmt.Sprintf("scroll:%d ", offset)
+		}
+		// show the full name of a cut header
+		if header, ok := d.cutHeader(widths); ok {
+			position += tview.Escape(header) + " "
+		}
+		tview.Print(screen, position, x+2, y+h, w-4, tview.AlignLeft, tview.Styles.PrimaryTextColor)
 	}()
 
 	// adjust offset if cursor hidden on the top
@@ -133,26 +447,13 @@ func (d *Dataviewer) Draw(screen tcell.Screen) {
 		}
 	}
 
-	// adjust offset if cursor is hidden on the left
-	fmt.Println("adjust left")
-	if d.getColWidth(d.cursor[1]) == 0 && d.cursor[1] < d.offsets[1] {
-		d.offsets[1] = d.cursor[1]
-		for d.offsets[1] > 0 {
-			d.offsets[1]--
-			d.visibleLeft = -1
-			d.visibleRight = -1
-			if b := d.getColWidth(d.cursor[1]); b == 0 {
-				break
-			}
-		}
+	// adjust offset if cursor is hidden on the left or right
+	widths = d.scrollToCursorColumn(w)
+	if len(widths) == 0 {
+		return
 	}
-
-	// adjust offset if cursor is hidden on the right
-	fmt.Println("adjust right")
-	for d.getColWidth(d.cursor[1]) == 0 && d.cursor[1] > d.offsets[1] {
-		d.offsets[1]++
-		d.visibleLeft = -1
-		d.visibleRight = -1
+	if i := d.cursor[1] - d.offsets[1]; i >= 0 && i < len(widths) {
+		d.cursorWidth = widths[i]
 	}
 
 	// adjust offset if cursor hidden on the bottom
@@ -161,23 +462,13 @@ func (d *Dataviewer) Draw(screen tcell.Screen) {
 	if height >= y+h {
 		return
 	}
+	// a row takes at least two lines, the rows further above can't be shown with the cursor
+	d.offsets[0] = max(d.offsets[0], d.cursor[0]-h/2)
 bottomOffset:
 	for d.offsets[0] < d.cursor[0] {
 		for i, r := range d.rows[d.offsets[0]:d.cursor[0]] {
 			i += d.offsets[0]
-			// measure max text height on the row
-			textHeight := 1
-			for _, header := range d.headers {
-				v, ok := r[header]
-				if !ok {
-					continue
-				}
-				text := fmt.Sprintf("%+v", v)
-				th := d.getTextHeight(text, w-2)
-				if th > textHeight {
-					textHeight = th
-				}
-			}
+			textHeight := d.rowHeight(r, w)
 
 			// increment row offset if current row span until below bottom offset
 			if height+textHeight+1 >= y+h {
@@ -195,7 +486,23 @@ bottomOffset:
 		}
 	}
 
+	// scroll the rows above into the space left below the last row, e.g. after the box grew
+	for d.offsets[0] > 0 {
+		height := y + d.getHeaderHeight() + 2
+		for _, r := range d.rows[d.offsets[0]-1:] {
+			height += d.rowHeight(r, w) + 1
+			if height >= y+h {
+				break
+			}
+		}
+		if height >= y+h {
+			break
+		}
+		d.offsets[0]--
+	}
+
 	// draw rows
+	d.visibleTop, d.visibleBottom = d.offsets[0]+1, d.offsets[0]
 	for i, r := range d.rows[d.offsets[0]:] {
 		i += d.offsets[0]
 		firstRowOffset := 0
@@ -203,23 +510,11 @@ bottomOffset:
 			firstRowOffset = 1
 		}
 
-		// measure max text height on the row
-		textHeight := 1
-		for _, header := range d.headers {
-			v, ok := r[header]
-			if !ok {
-				continue
-			}
-			text := fmt.Sprintf("%+v", v)
-			th := d.getTextHeight(text, w-2)
-			if th > textHeight {
-				textHeight = th
-			}
-		}
-
+		textHeight := d.rowHeight(r, w)
 		if textY+1+textHeight+firstRowOffset >= y+h {
 			break
 		}
+		d.visibleBottom = i + 1
 
 		for j, header := range d.headers[d.offsets[1]:] {
 			j += d.offsets[1]
@@ -231,13 +526,12 @@ bottomOffset:
 			if !ok {
 				continue
 			}
-			text := fmt.Sprintf("%+v", v)
+			text := d.cellText(j, v)
 
-			fmt.Printf("draw row: %d, col: %d\n", i, j)
-			colWidth := d.getColWidth(j)
-			if colWidth == 0 {
+			if j-d.offsets[1] >= len(widths) {
 				break
 			}
+			colWidth := widths[j-d.offsets[1]]
 
 			if d.HasFocus() && d.cursor == [2]int{i + 1, j} {
 				defer d.drawCell(screen, i, j, textX, textY, colWidth, 2+textHeight, firstRowOffset, text)
@@ -262,7 +556,10 @@ bottomOffset:
 			break
 		}
 
-		colWidth := d.getColWidth(i)
+		if i-d.offsets[1] >= len(widths) {
+			break
+		}
+		colWidth := widths[i-d.offsets[1]]
 
 		if d.HasFocus() && d.cursor == [2]int{0, i} {
 			defer d.drawHeader(screen, i, textX, textY, colWidth, 2+headerHeight, header)
@@ -274,20 +571,17 @@ bottomOffset:
 	}
 }
 
-func (d *Dataviewer) getColTextWidth(colIndex int) int {
-	header := d.headers[colIndex]
-	maxWidth := uniseg.StringWidth(header)
-	for _, r := range d.rows {
+// rowHeight returns the height of the tallest cell text of the row.
+func (d *Dataviewer) rowHeight(r map[string]string, w int) int {
+	textHeight := 1
+	for j, header := range d.headers {
 		v, ok := r[header]
 		if !ok {
 			continue
 		}
-		width := uniseg.StringWidth(fmt.Sprintf("%+v", v))
-		if width > maxWidth {
-			maxWidth = width
-		}
+		textHeight = max(textHeight, d.getTextHeight(d.cellText(j, v), w-2))
 	}
-	return maxWidth
+	return textHeight
 }
 
 func (d *Dataviewer) getTextHeight(text string, w int) int {
@@ -310,74 +604,6 @@ func (d *Dataviewer) getTextHeight(text string, w int) int {
 	return textY + 1
 }
 
-func (d *Dataviewer) getColWidth(colIndex int) int {
-	isColVisible := colIndex >= d.visibleLeft && colIndex <= d.visibleRight
-	isCursorVisible := d.cursor[1] >= d.visibleLeft && d.cursor[1] <= d.visibleRight
-	// if col and cursor is visible, returned cached width
-	if isColVisible && isCursorVisible {
-		return d.colWidths[colIndex-d.visibleLeft]
-	}
-	// if cursor is visible but col is not, then just return 0
-	if isCursorVisible {
-		return 0
-	}
-
-	startIndex := d.offsets[1]
-	lastIndex := d.offsets[1]
-	x, _, w, _ := d.Box.GetInnerRect()
-	width := x
-
-	emptyHorizontalSpace := 0
-	for j := range d.headers[d.offsets[1]:] {
-		j += d.offsets[1]
-		lastIndex = j
-
-		// if the first width is already too wide, break
-		if width+d.getColTextWidth(j)+1 >= x+w {
-			break
-		}
-
-		width += d.getColTextWidth(j) + 1
-
-		// stop if the next header is too wide
-		if j < len(d.headers)-1 && width+d.getColTextWidth(j+1)+1 >= x+w {
-			fmt.Println("next header is too wide")
-			break
-		}
-	}
-	emptyHorizontalSpace = w + x - width - 1
-
-	d.visibleLeft = startIndex
-	d.visibleRight = lastIndex
-
-	if startIndex == lastIndex && width == x && width+d.getColTextWidth(startIndex)+1 >= x+w {
-		d.colWidths = []int{emptyHorizontalSpace - 1}
-	} else {
-		d.colWidths = make([]int, lastIndex-startIndex+1)
-		for a := range len(d.colWidths) {
-			colWidth := d.getColTextWidth(a + startIndex)
-			if emptyHorizontalSpace > 0 && a < len(d.colWidths)-1 {
-				fmt.Println("$a")
-				d.colWidths[a] = colWidth + emptyHorizontalSpace/(lastIndex-startIndex+1)
-			} else if emptyHorizontalSpace > 0 {
-				fmt.Println("$b")
-				d.colWidths[a] = colWidth + emptyHorizontalSpace - (emptyHorizontalSpace/(lastIndex-startIndex+1))*(lastIndex-startIndex)
-			} else {
-				fmt.Println("$c")
-				d.colWidths[a] = colWidth
-			}
-		}
-	}
-
-	// fmt.Printf("colIdx: %d, startIdx: %d, lastIdx: %d, x: %d, w: %d, width: %d, empty: %d\n", colIndex, startIndex, lastIndex, x, w, width, emptyHorizontalSpace)
-	// fmt.Printf("vis start: %d, vis end: %d, colwidth: %d, col widths: %+v\n", d.visibleLeft, d.visibleRight, d.getColTextWidth(startIndex), d.colWidths)
-
-	if colIndex >= startIndex && colIndex <= lastIndex {
-		return d.colWidths[colIndex-startIndex]
-	}
-	return 0
-}
-
 func (d *Dataviewer) getHeaderHeight() int {
 	_, _, w, _ := d.Box.GetInnerRect()
 	textHeight := 1
@@ -387,123 +613,199 @@ func (d *Dataviewer) getHeaderHeight() int {
 			textHeight = th
 		}
 	}
+	if d.headerLines > 0 {
+		textHeight = min(textHeight, d.headerLines)
+	}
+	if d.isColumnTypesVisible() {
+		textHeight++
+	}
 	return textHeight
 }
 
+// cutHeader returns the header of the cursor column if it doesn't fit in the header lines.
+func (d *Dataviewer) cutHeader(widths []int) (string, bool) {
+	i := d.cursor[1] - d.offsets[1]
+	if d.headerLines == 0 || d.cursor[1] >= len(d.headers) || i < 0 || i >= len(widths) {
+		return "", false
+	}
+	header := d.headers[d.cursor[1]]
+	return header, d.getTextHeight(header, widths[i]) > d.headerLines
+}
+
+// ellipsis ends the text of a cell that doesn't fit
+const ellipsis = "…"
+
 func (d *Dataviewer) drawCell(screen tcell.Screen, i, j, x, y, colWidth, height, topPadding int, content string) {
-	textColor := d.textColor
-	borderColor := d.borderColor
-	bgColor := d.bgColor
+	t := theme.Current()
+	textColor := tview.Styles.PrimaryTextColor
+	borderColor := t.Border
+	bgColor := tview.Styles.PrimitiveBackgroundColor
+	if d.isMarked(i) {
+		textColor = t.MarkedText
+		bgColor = t.MarkedBackground
+	}
+	if d.isSelected(i+1, j) {
+		textColor = tview.Styles.PrimaryTextColor
+		bgColor = tview.Styles.ContrastBackgroundColor
+	}
+	if d.isSearchMatch(i+1, j) {
+		textColor = t.MatchText
+		bgColor = t.MatchBackground
+	}
 	if d.HasFocus() && d.cursor == [2]int{i + 1, j} {
-		textColor = tcell.ColorBlack
-		borderColor = tcell.ColorBlack
-		bgColor = tcell.ColorYellow
+		textColor = t.CursorText
+		borderColor = t.CursorText
+		bgColor = t.CursorBackground
 	}
-	c := NewCell(content, x, y, colWidth+2, height, topPadding, textColor, bgColor, borderColor)
+	c := NewCell(content, x, y, colWidth+2, height, topPadding, textColor, bgColor, borderColor).
+		SetClip(d.GetInnerRect()).
+		SetEllipsis(ellipsis).
+		SetOffset(d.cellOffsets[[2]int{i + 1, j}])
 	c.Draw(screen)
+	d.drawnCells = append(d.drawnCells, [2]int{i + 1, j})
+	d.drawFlashLabel(screen, c, [2]int{i + 1, j}, x, y+topPadding, colWidth)
 
 	// top left junction
 	if j > 0 {
-		screen.SetContent(x, y, tview.Borders.Cross, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x, y, tview.Borders.Cross, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	} else {
-		screen.SetContent(x, y, tview.Borders.LeftT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x, y, tview.Borders.LeftT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	}
 
 	// top right junction
 	if j >= len(d.headers)-1 {
-		screen.SetContent(x+colWidth+1, y, tview.Borders.RightT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x+colWidth+1, y, tview.Borders.RightT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	} else {
-		screen.SetContent(x+colWidth+1, y, tview.Borders.Cross, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x+colWidth+1, y, tview.Borders.Cross, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	}
 
 	// bottom left juction
 	if i >= len(d.rows)-1 && j > 0 {
-		screen.SetContent(x, y-1+height+topPadding, tview.Borders.BottomT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x, y-1+height+topPadding, tview.Borders.BottomT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	} else if j > 0 {
-		screen.SetContent(x, y-1+height+topPadding, tview.Borders.Cross, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x, y-1+height+topPadding, tview.Borders.Cross, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	} else if i >= len(d.rows)-1 {
-		screen.SetContent(x, y-1+height+topPadding, tview.Borders.BottomLeft, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x, y-1+height+topPadding, tview.Borders.BottomLeft, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	} else {
-		screen.SetContent(x, y-1+height+topPadding, tview.Borders.LeftT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x, y-1+height+topPadding, tview.Borders.LeftT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	}
 
 	// top right junction
 	if j >= len(d.headers)-1 {
-		screen.SetContent(x+colWidth+1, y, tview.Borders.RightT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x+colWidth+1, y, tview.Borders.RightT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	} else {
-		screen.SetContent(x+colWidth+1, y, tview.Borders.Cross, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x+colWidth+1, y, tview.Borders.Cross, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	}
 
 	// bottom right junction
 	if i >= len(d.rows)-1 && j < len(d.headers)-1 {
-		screen.SetContent(x+colWidth+1, y-1+height+topPadding, tview.Borders.BottomT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x+colWidth+1, y-1+height+topPadding, tview.Borders.BottomT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	} else if j < len(d.headers)-1 {
-		screen.SetContent(x+colWidth+1, y-1+height+topPadding, tview.Borders.Cross, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x+colWidth+1, y-1+height+topPadding, tview.Borders.Cross, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	} else if i >= len(d.rows)-1 {
-		screen.SetContent(x+colWidth+1, y-1+height+topPadding, tview.Borders.BottomRight, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x+colWidth+1, y-1+height+topPadding, tview.Borders.BottomRight, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	} else {
-		screen.SetContent(x+colWidth+1, y-1+height+topPadding, tview.Borders.RightT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x+colWidth+1, y-1+height+topPadding, tview.Borders.RightT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	}
 }
 
 func (d *Dataviewer) drawHeader(screen tcell.Screen, i, x, y, colWidth, height int, header string) {
-	textColor := d.bgColor
-	borderColor := d.borderColor
-	bgColor := d.textColor
+	t := theme.Current()
+	textColor := t.HeaderText
+	borderColor := t.Border
+	bgColor := t.HeaderBackground
+	if d.isSearchMatch(0, i) {
+		textColor = t.MatchText
+		bgColor = t.MatchBackground
+	}
 	if d.HasFocus() && d.cursor == [2]int{0, i} {
-		textColor = tcell.ColorBlack
-		borderColor = tcell.ColorBlack
-		bgColor = tcell.ColorYellow
+		textColor = t.CursorText
+		borderColor = t.CursorText
+		bgColor = t.CursorBackground
 	}
-	c := NewCell(header, x, y, colWidth+2, height, 0, textColor, bgColor, borderColor)
+	c := NewCell(header, x, y, colWidth+2, height, 0, textColor, bgColor, borderColor).
+		SetClip(d.GetInnerRect()).
+		SetEllipsis(ellipsis)
 	c.Draw(screen)
+	d.drawnCells = append(d.drawnCells, [2]int{0, i})
+	d.drawFlashLabel(screen, c, [2]int{0, i}, x, y, colWidth)
+
+	// draw column type as a dimmed line below the header text
+	if d.isColumnTypesVisible() {
+		tview.Print(screen, tview.Escape(d.columnTypes[i]), x+1, y+height-2, colWidth, tview.AlignLeft, t.Dim)
+	}
 
 	// top left junction
 	if i > 0 {
-		screen.SetContent(x, y, tview.Borders.TopT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x, y, tview.Borders.TopT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	} else {
-		screen.SetContent(x, y, tview.Borders.TopLeft, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x, y, tview.Borders.TopLeft, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	}
 
 	// top right junction
 	if i >= len(d.headers)-1 {
-		screen.SetContent(x+colWidth+1, y, tview.Borders.TopRight, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x+colWidth+1, y, tview.Borders.TopRight, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	} else {
-		screen.SetContent(x+colWidth+1, y, tview.Borders.TopT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x+colWidth+1, y, tview.Borders.TopT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	}
 
 	// bottom left junction
 	if i > 0 {
-		screen.SetContent(x, y-1+height, tview.Borders.BottomT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x, y-1+height, tview.Borders.BottomT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	} else {
-		screen.SetContent(x, y-1+height, tview.Borders.BottomLeft, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x, y-1+height, tview.Borders.BottomLeft, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	}
 
 	// bottom right junction
 	if i >= len(d.headers)-1 {
-		screen.SetContent(x+colWidth+1, y-1+height, tview.Borders.BottomRight, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x+colWidth+1, y-1+height, tview.Borders.BottomRight, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	} else {
-		screen.SetContent(x+colWidth+1, y-1+height, tview.Borders.BottomT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
+		c.setContent(screen, x+colWidth+1, y-1+height, tview.Borders.BottomT, nil, tcell.StyleDefault.Foreground(borderColor).Background(bgColor))
 	}
 }
 
 func (d *Dataviewer) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
 	return d.Box.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
-		eventName := event.Name()
-		if event.Key() == tcell.KeyRune {
-			eventName = string(event.Rune())
-		} else {
-			eventName = strings.ToLower(eventName)
+		// embedded search editor is not null, send input event to it
+		if se := d.searchEditor; se != nil {
+			se.InputHandler()(event, setFocus)
+			if d.searchEditor == nil && se.HasFocus() {
+				setFocus(d)
+			}
+			return
+		}
+
+		// esc and ctrl-c cancel a pending count, operator or motion before they're mapped to anything else
+		if key := event.Key(); (key == tcell.KeyEsc || key == tcell.KeyCtrlC) && d.hasPending() {
+			d.ResetAction()
+			return
 		}
+
+		eventName := keymap.EventName(event)
 		d.pending = append(d.pending, eventName)
 
 		isDigit := event.Key() == tcell.KeyRune && unicode.IsDigit(event.Rune())
 
 		group := "r"
-		if d.cursor[0] == 0 {
+		if d.mode == visual || d.mode == vline {
+			group = "v"
+		} else if d.cursor[0] == 0 {
 			group = "h"
 		}
 
+		// expand macros into their keys
+		if macro := d.keymapper.Macro(d.pending, group); macro != nil {
+			d.ResetAction()
+			if d.macroDepth < keymap.MaxMacroDepth {
+				d.macroDepth++
+				for _, key := range macro {
+					d.InputHandler()(keymap.Event(key), setFocus)
+				}
+				d.macroDepth--
+			}
+			return
+		}
+
 		actionStrings, anyStartWith := d.keymapper.Get(d.pending, group)
 		if actionStrings == nil {
 			actionStrings = []string{""}
@@ -581,6 +883,7 @@ func (d *Dataviewer) InputHandler() func(event *tcell.EventKey, setFocus func(p
 
 			// handle the other action
 			if d.actionRunner[action] != nil {
+				d.actionArgs = d.keymapper.Args(d.pending, group, actionString)
 				d.actionRunner[action]()
 				d.ResetAction()
 				return
@@ -603,8 +906,16 @@ func (d *Dataviewer) InputHandler() func(event *tcell.EventKey, setFocus func(p
 	})
 }
 
+func (d *Dataviewer) getActionCount() int {
+	n := 1 + d.pendingCount
+	if d.pendingCount > 0 {
+		n--
+	}
+	return n
+}
+
 func (d *Dataviewer) GetUpCursor() [2]int {
-	res := [2]int{d.cursor[0] - 1, d.cursor[1]}
+	res := [2]int{d.cursor[0] - d.getActionCount(), d.cursor[1]}
 	if res[0] < 0 {
 		return [2]int{0, d.cursor[1]}
 	}
@@ -612,15 +923,30 @@ func (d *Dataviewer) GetUpCursor() [2]int {
 }
 
 func (d *Dataviewer) GetDownCursor() [2]int {
-	res := [2]int{d.cursor[0] + 1, d.cursor[1]}
+	res := [2]int{d.cursor[0] + d.getActionCount(), d.cursor[1]}
 	if res[0] > len(d.rows) {
 		return [2]int{len(d.rows), d.cursor[1]}
 	}
 	return res
 }
 
+// pageRows is the number of rows shown on the last draw, at least one.
+func (d *Dataviewer) pageRows() int {
+	return max(d.visibleBottom-d.visibleTop+1, 1)
+}
+
+// scrollRows scrolls the view n rows down, or up if n is negative, moving the cursor along.
+// The view doesn't scroll past the page ending with the last row.
+func (d *Dataviewer) scrollRows(n int) {
+	if len(d.rows) == 0 {
+		return
+	}
+	d.offsets[0] = max(min(d.offsets[0]+n, len(d.rows)-d.pageRows()), 0)
+	d.cursor[0] = max(min(d.cursor[0]+n, len(d.rows)), min(d.cursor[0], 1))
+}
+
 func (d *Dataviewer) GetLeftCursor() [2]int {
-	res := [2]int{d.cursor[0], d.cursor[1] - 1}
+	res := [2]int{d.cursor[0], d.cursor[1] - d.getActionCount()}
 	if res[1] < 0 {
 		return [2]int{d.cursor[0], 0}
 	}
@@ -628,7 +954,7 @@ func (d *Dataviewer) GetLeftCursor() [2]int {
 }
 
 func (d *Dataviewer) GetRightCursor() [2]int {
-	res := [2]int{d.cursor[0], d.cursor[1] + 1}
+	res := [2]int{d.cursor[0], d.cursor[1] + d.getActionCount()}
 	if res[1] > len(d.headers)-1 {
 		return [2]int{d.cursor[0], len(d.headers) - 1}
 	}
@@ -644,34 +970,96 @@ func (d *Dataviewer) GetStartOfLineCursor() [2]int {
 }
 
 func (d *Dataviewer) GetFirstLineCursor() [2]int {
+	if d.pendingCount > 0 {
+		return d.GetRowCursor(d.pendingCount)
+	}
 	return [2]int{0, d.cursor[1]}
 }
 
 func (d *Dataviewer) GetLastLineCursor() [2]int {
+	if d.pendingCount > 0 {
+		return d.GetRowCursor(d.pendingCount)
+	}
 	return [2]int{len(d.rows), d.cursor[1]}
 }
 
+// GetRowCursor returns the cursor on the n-th row, 0 is the header.
+func (d *Dataviewer) GetRowCursor(n int) [2]int {
+	if n < 0 {
+		n = 0
+	}
+	if n > len(d.rows) {
+		n = len(d.rows)
+	}
+	return [2]int{n, d.cursor[1]}
+}
+
+// GetCursor returns the cursor [row, col] position, row 0 is the header.
+func (d *Dataviewer) GetCursor() [2]int {
+	return d.cursor
+}
+
+// GetCurrentRow returns the row under the cursor, false if the cursor is on the header.
+func (d *Dataviewer) GetCurrentRow() (map[string]string, bool) {
+	if d.cursor[0] < 1 || d.cursor[0] > len(d.rows) {
+		return nil, false
+	}
+	return d.rows[d.cursor[0]-1], true
+}
+
+// GetCurrentCell returns the header and the value of the cell under the cursor, the value is the header
+// on the header row. It's false when there are no columns.
+func (d *Dataviewer) GetCurrentCell() (string, string, bool) {
+	if d.cursor[1] >= len(d.headers) {
+		return "", "", false
+	}
+	header := d.headers[d.cursor[1]]
+	row, ok := d.GetCurrentRow()
+	if !ok {
+		return header, header, true
+	}
+	return header, row[header], true
+}
+
+func (d *Dataviewer) EditRow() {
+	row, ok := d.GetCurrentRow()
+	if !ok || d.editRowFunc == nil {
+		return
+	}
+	d.editRowFunc(d.headers, row)
+}
+
+func (d *Dataviewer) InspectCell() {
+	header, value, ok := d.GetCurrentCell()
+	if d.inspectFunc == nil || !ok {
+		return
+	}
+	d.inspectFunc(header, value)
+}
+
+// YankCell yanks the value under the cursor, the column name on the header.
+func (d *Dataviewer) YankCell() {
+	if d.cursor[1] >= len(d.headers) {
+		return
+	}
+
+	header := d.headers[d.cursor[1]]
+	row, ok := d.GetCurrentRow()
+	if !ok {
+		register.Yank(register.Unnamed, header, register.Charwise)
+		return
+	}
+	register.Yank(register.Unnamed, row[header], register.Charwise)
+}
+
 func (d *Dataviewer) MoveCursorTo(to [2]int) {
 	d.cursor = to
 }
 
-func (d *Dataviewer) EnableSearch() [2]int {
-	x, y, w, h := d.Box.GetInnerRect()
-	se := editor.New(editor.WithKeymapper(d.keymapper)).SetOneLineMode(true)
-	se.SetText("", [2]int{0, 0})
-	se.SetRect(x, y+h-1, w, 1)
-	se.ChangeMode(editor.ModeInsert)
-	// se.onDoneFunc = func(s string) {
-	// 	d.searchEditor = nil
-	// 	d.ResetAction()
-	// }
-	// se.onExitFunc = func() {
-	// 	d.searchEditor = nil
-	// 	d.ResetAction()
-	// }
-	d.searchEditor = se
-	d.waitingForMotion = true
-	return vim.AsyncMotion
+// hasPending reports whether a count, operator or motion is waiting for more keys.
+func (d *Dataviewer) hasPending() bool {
+	return d.waitingForMotion || d.pendingCount != 0 || d.pendingAction != ActionNone ||
+		d.lastMotion != ActionNone || len(d.pending) > 0
 }
 
 func (d *Dataviewer) ResetAction() {
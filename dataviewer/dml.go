@@ -0,0 +1,62 @@
+package dataviewer
+
+import (
+	"strings"
+)
+
+func QuoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func QuoteValue(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// UpdateQuery builds an UPDATE statement setting every changed column of the row,
+// identified by the given keys, which must be a primary or unique key of the table.
+func UpdateQuery(table string, keys, headers []string, old, new map[string]string) string {
+	var sets []string
+	for _, header := range headers {
+		if old[header] == new[header] {
+			continue
+		}
+		sets = append(sets, QuoteIdentifier(header)+" = "+QuoteValue(new[header]))
+	}
+	if len(sets) == 0 {
+		return ""
+	}
+
+	return "UPDATE " + QuoteIdentifier(table) + "\nSET " + strings.Join(sets, ",\n    ") + "\nWHERE " + whereClause(keys, old) + ";"
+}
+
+// DeleteQuery builds a DELETE statement for the row, identified by the given keys.
+func DeleteQuery(table string, keys []string, row map[string]string) string {
+	return "DELETE FROM " + QuoteIdentifier(table) + "\nWHERE " + whereClause(keys, row) + ";"
+}
+
+// CountQuery builds a SELECT COUNT(*) of the table rows matched by any of the rows, to preview
+// how many rows their UPDATE or DELETE statements change.
+func CountQuery(table string, keys []string, rows []map[string]string) string {
+	conditions := make([]string, len(rows))
+	for i, row := range rows {
+		conditions[i] = whereClause(keys, row)
+		if len(rows) > 1 {
+			conditions[i] = "(" + conditions[i] + ")"
+		}
+	}
+	return "SELECT COUNT(*) FROM " + QuoteIdentifier(table) + "\nWHERE " + strings.Join(conditions, "\n   OR ") + ";"
+}
+
+// whereClause matches the row by its key values, a key missing from the row is NULL.
+func whereClause(keys []string, row map[string]string) string {
+	conditions := make([]string, len(keys))
+	for i, key := range keys {
+		value, ok := row[key]
+		if !ok {
+			conditions[i] = QuoteIdentifier(key) + " IS NULL"
+			continue
+		}
+		conditions[i] = QuoteIdentifier(key) + " = " + QuoteValue(value)
+	}
+	return strings.Join(conditions, "\n  AND ")
+}
@@ -0,0 +1,119 @@
+package dataviewer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FormatFile formats the rows in the format of the file extension: .csv, .tsv, .json, or .md.
+func FormatFile(path string, headers []string, rows []map[string]string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		return FormatCSV(headers, rows, ','), nil
+	case ".tsv":
+		return FormatCSV(headers, rows, '\t'), nil
+	case ".json":
+		return FormatJSON(headers, rows)
+	case ".md":
+		return FormatMarkdown(headers, rows), nil
+	default:
+		return "", fmt.Errorf("dataviewer: unknown export format %q, use .csv, .tsv, .json, or .md", ext)
+	}
+}
+
+// FormatCSV formats the rows with a header line, separated by the given comma, e.g. '\t' for TSV.
+func FormatCSV(headers []string, rows []map[string]string, comma rune) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Comma = comma
+
+	w.Write(headers)
+	record := make([]string, len(headers))
+	for _, r := range rows {
+		for i, header := range headers {
+			record[i] = r[header]
+		}
+		w.Write(record)
+	}
+	w.Flush()
+
+	return b.String()
+}
+
+// FormatInList formats the distinct values as a quoted, comma-separated list for a WHERE ... IN clause.
+func FormatInList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		v = QuoteValue(v)
+		if !slices.Contains(quoted, v) {
+			quoted = append(quoted, v)
+		}
+	}
+	return "(" + strings.Join(quoted, ", ") + ")"
+}
+
+// FormatJSON formats the rows as an array of objects, keeping the column order.
+func FormatJSON(headers []string, rows []map[string]string) (string, error) {
+	var b strings.Builder
+	b.WriteString("[")
+	for i, r := range rows {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for j, header := range headers {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			k, err := json.Marshal(header)
+			if err != nil {
+				return "", fmt.Errorf("dataviewer: error encoding column %s: %w", header, err)
+			}
+			v, err := json.Marshal(r[header])
+			if err != nil {
+				return "", fmt.Errorf("dataviewer: error encoding value of %s: %w", header, err)
+			}
+			b.Write(k)
+			b.WriteString(": ")
+			b.Write(v)
+		}
+		b.WriteString("}")
+	}
+	if len(rows) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+	return b.String(), nil
+}
+
+// FormatMarkdown formats the rows as a markdown table.
+func FormatMarkdown(headers []string, rows []map[string]string) string {
+	cell := strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+	var b strings.Builder
+	line := func(values []string) {
+		b.WriteString("|")
+		for _, v := range values {
+			b.WriteString(" " + cell.Replace(v) + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	line(headers)
+	b.WriteString("|")
+	for range headers {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	values := make([]string, len(headers))
+	for _, r := range rows {
+		for i, header := range headers {
+			values[i] = r[header]
+		}
+		line(values)
+	}
+	return b.String()
+}
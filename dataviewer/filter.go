@@ -0,0 +1,157 @@
+package dataviewer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Filter is a column filter expression, e.g. ">100", "~foo", or "is null".
+	Filter struct {
+		expr  string
+		op    string
+		value string
+	}
+)
+
+// filterOps is ordered so that the longer operator is matched first.
+var filterOps = []string{"!=", ">=", "<=", "!~", "=", ">", "<", "~"}
+
+// ParseFilter parses a filter expression, a value without operator is matched with "=".
+// NULL is loaded as an empty value, so "is null" matches empty values.
+func ParseFilter(expr string) (Filter, error) {
+	expr = strings.TrimSpace(expr)
+	f := Filter{expr: expr}
+
+	switch strings.Join(strings.Fields(strings.ToLower(expr)), " ") {
+	case "is null":
+		f.op = "is null"
+		return f, nil
+	case "is not null":
+		f.op = "is not null"
+		return f, nil
+	}
+
+	f.op = "="
+	f.value = expr
+	for _, op := range filterOps {
+		if strings.HasPrefix(expr, op) {
+			f.op = op
+			f.value = strings.TrimSpace(expr[len(op):])
+			break
+		}
+	}
+	if f.value == "" {
+		return f, fmt.Errorf("dataviewer: missing value in filter %q", expr)
+	}
+	return f, nil
+}
+
+func (f Filter) String() string {
+	return f.expr
+}
+
+// Match reports whether the value satisfies the filter, values are compared as numbers when both are numeric.
+func (f Filter) Match(v string) bool {
+	switch f.op {
+	case "is null":
+		return v == ""
+	case "is not null":
+		return v != ""
+	case "~":
+		return strings.Contains(strings.ToLower(v), strings.ToLower(f.value))
+	case "!~":
+		return !strings.Contains(strings.ToLower(v), strings.ToLower(f.value))
+	}
+
+	cmp := strings.Compare(v, f.value)
+	a, errA := strconv.ParseFloat(v, 64)
+	b, errB := strconv.ParseFloat(f.value, 64)
+	if errA == nil && errB == nil {
+		cmp = 0
+		if a < b {
+			cmp = -1
+		} else if a > b {
+			cmp = 1
+		}
+	}
+
+	switch f.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// SetFilter sets the filter expression of the column, an empty expression removes it.
+func (d *Dataviewer) SetFilter(header, expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		delete(d.filters, header)
+		d.applyView()
+		return nil
+	}
+
+	f, err := ParseFilter(expr)
+	if err != nil {
+		return err
+	}
+	d.filters[header] = f
+	d.applyView()
+	return nil
+}
+
+func (d *Dataviewer) ClearFilters() {
+	clear(d.filters)
+	d.applyView()
+}
+
+// FilterColumn asks for the filter expression of the current column.
+func (d *Dataviewer) FilterColumn() {
+	if d.filterPromptFunc == nil || d.cursor[1] >= len(d.headers) {
+		return
+	}
+
+	header := d.headers[d.cursor[1]]
+	d.filterPromptFunc(header, d.filters[header].String())
+}
+
+// FilterSummary returns the active filters combined with AND with the matching row count,
+// and the sort columns, empty if there's none. The sort of the loaded rows is marked client,
+// the order of the query server.
+func (d *Dataviewer) FilterSummary() string {
+	var summary []string
+	if len(d.filters) > 0 {
+		var conditions []string
+		for _, header := range d.headers {
+			if f, ok := d.filters[header]; ok {
+				conditions = append(conditions, header+" "+f.String())
+			}
+		}
+		summary = append(summary, fmt.Sprintf("filter: %s (%d/%d rows)", strings.Join(conditions, " AND "), len(d.rows), len(d.loadedRows)))
+	}
+	if d.serverSortHeader != "" {
+		summary = append(summary, "sort: "+d.serverSortHeader+" "+sortOrder(d.serverSortDesc)+" (server)")
+	}
+	if d.sortHeader != "" {
+		summary = append(summary, "sort: "+d.sortHeader+" "+sortOrder(d.sortDesc)+" (client)")
+	}
+	return strings.Join(summary, "  ")
+}
+
+func sortOrder(desc bool) string {
+	if desc {
+		return "desc"
+	}
+	return "asc"
+}
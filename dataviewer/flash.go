@@ -0,0 +1,158 @@
+package dataviewer
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ngavinsir/sqluy/editor"
+	"github.com/ngavinsir/sqluy/flash"
+	"github.com/ngavinsir/sqluy/vim"
+	"github.com/rivo/tview"
+)
+
+// Flash shows the flash editor on the bottom line, the visible cells containing the typed text are labeled
+// and typing a label moves the cursor to its cell.
+func (d *Dataviewer) Flash() [2]int {
+	exit := func() {
+		d.searchEditor = nil
+		d.ResetAction()
+		d.resetFlash()
+	}
+	se := editor.NewPrompt("flash: ",
+		editor.WithKeymapper(d.keymapper),
+		editor.WithDoneFunc(func(*editor.Editor, string) { exit() }),
+	)
+	se.SetExitFunc(exit)
+	se.SetTextChangedFunc(d.updateFlash)
+	editor.SetPromptRect(se, d.Box)
+	d.searchEditor = se
+	d.waitingForMotion = true
+	return vim.AsyncMotion
+}
+
+// updateFlash labels the visible cells containing the flash text, ignoring case, the closest to the cursor first.
+// The last typed character jumps to the cell of its label instead.
+func (d *Dataviewer) updateFlash(s string) {
+	if s == "" {
+		d.resetFlash()
+		return
+	}
+
+	if d.flashLabels != nil && len(s) > d.flashQueryLen {
+		runes := []rune(s)
+		label := d.flashPrefix + string(runes[len(runes)-1])
+		if c, ok := d.flashLabels[label]; ok {
+			d.searchEditor = nil
+			if d.operatorRunner[d.pendingAction] != nil {
+				d.operatorRunner[d.pendingAction](c)
+			}
+			d.ResetAction()
+			d.resetFlash()
+			return
+		}
+		// the first character of a two character label waits for the second one,
+		// anything else after it ends the flash
+		if d.flashPrefix == "" && d.hasFlashPrefix(label) {
+			d.flashPrefix = label
+			d.flashQueryLen = len(s)
+			return
+		}
+		if d.flashPrefix != "" {
+			d.searchEditor = nil
+			d.ResetAction()
+			d.resetFlash()
+			return
+		}
+	}
+
+	d.resetFlash()
+	d.flashQueryLen = len(s)
+	query := strings.ToLower(s)
+	// characters continuing the text in a cell can't start a label
+	next := make(map[rune]struct{})
+	var matches [][2]int
+	for _, c := range d.drawnCells {
+		text := strings.ToLower(d.cellAt(c))
+		if !strings.Contains(text, query) {
+			continue
+		}
+		matches = append(matches, c)
+		for rest := text; ; {
+			i := strings.Index(rest, query)
+			if i < 0 {
+				break
+			}
+			rest = rest[i+len(query):]
+			if r := []rune(rest); len(r) > 0 {
+				next[r[0]] = struct{}{}
+			}
+		}
+	}
+
+	distance := func(c [2]int) int {
+		return abs(c[0]-d.cursor[0]) + abs(c[1]-d.cursor[1])
+	}
+	slices.SortStableFunc(matches, func(a, b [2]int) int {
+		return distance(a) - distance(b)
+	})
+	labels := flash.Labels(d.flashOptions.Alphabet, d.flashOptions.MultiChar, len(matches), func(r rune) bool {
+		_, ok := next[unicode.ToLower(r)]
+		return ok
+	})
+	for i, label := range labels {
+		d.flashLabels[label] = matches[i]
+		d.flashCells[matches[i]] = label
+	}
+}
+
+// resetFlash clears the flash labels.
+func (d *Dataviewer) resetFlash() {
+	d.flashLabels = make(map[string][2]int)
+	d.flashCells = make(map[[2]int]string)
+	d.flashPrefix = ""
+	d.flashQueryLen = 0
+}
+
+// cellAt returns the text shown in the cell, the header name on the header row.
+func (d *Dataviewer) cellAt(c [2]int) string {
+	if c[0] == 0 {
+		return d.headers[c[1]]
+	}
+	return d.cellText(c[1], d.rows[c[0]-1][d.headers[c[1]]])
+}
+
+// drawFlashLabel draws the flash label of the cell on its first text line, at the start of the cell
+// or at its end depending on the label placement.
+func (d *Dataviewer) drawFlashLabel(screen tcell.Screen, c *Cell, cell [2]int, x, y, colWidth int) {
+	label, ok := d.flashCells[cell]
+	if !ok || !strings.HasPrefix(label, d.flashPrefix) {
+		return
+	}
+	runes := []rune(strings.TrimPrefix(label, d.flashPrefix))
+	if d.flashOptions.Placement != "before" {
+		x += max(colWidth-len(runes), 0)
+	}
+	style := tcell.StyleDefault.Background(tview.Styles.MoreContrastBackgroundColor).Foreground(tview.Styles.PrimitiveBackgroundColor)
+	for i, r := range runes {
+		c.setContent(screen, x+1+i, y+1, r, nil, style)
+	}
+}
+
+// hasFlashPrefix reports whether a two character label starts with the typed character.
+func (d *Dataviewer) hasFlashPrefix(prefix string) bool {
+	for label := range d.flashLabels {
+		if len(label) > len(prefix) && strings.HasPrefix(label, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,68 @@
+package dataviewer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	temporalTypes = []string{"DATE", "DATETIME", "TIMESTAMP", "TIME"}
+	timeLayouts   = []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04",
+		"2006-01-02",
+		"15:04:05.999999999",
+	}
+)
+
+// cellText returns the text shown in the grid for the value of the given column.
+// Binary value is replaced by its length, temporal value is formatted per the time settings.
+func (d *Dataviewer) cellText(colIndex int, v string) string {
+	if IsBinary(v) {
+		return fmt.Sprintf("<BLOB %d bytes>", len(v))
+	}
+	if d.isTemporalColumn(colIndex) {
+		return d.formatTime(v)
+	}
+	return v
+}
+
+func (d *Dataviewer) isTemporalColumn(colIndex int) bool {
+	if d.timeFormat == "" && d.timeLocation == nil {
+		return false
+	}
+	if colIndex < 0 || colIndex >= len(d.columnTypes) {
+		return false
+	}
+
+	t := strings.ToUpper(d.columnTypes[colIndex])
+	for _, temporalType := range temporalTypes {
+		if strings.HasPrefix(t, temporalType) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dataviewer) formatTime(v string) string {
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, v)
+		if err != nil {
+			continue
+		}
+
+		if d.timeLocation != nil {
+			t = t.In(d.timeLocation)
+		}
+		format := d.timeFormat
+		if format == "" {
+			format = layout
+		}
+		return t.Format(format)
+	}
+	return v
+}
@@ -0,0 +1,50 @@
+package dataviewer
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Frequencies counts the occurrence of each value of the column in the loaded rows,
+// sorted by the most frequent value first.
+func (d *Dataviewer) Frequencies(header string) ([]string, []map[string]string) {
+	countHeader := "count"
+	if header == countHeader {
+		countHeader = "count(*)"
+	}
+
+	counts := make(map[string]int)
+	for _, r := range d.rows {
+		counts[r[header]]++
+	}
+
+	values := make([]string, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if counts[values[i]] != counts[values[j]] {
+			return counts[values[i]] > counts[values[j]]
+		}
+		return values[i] < values[j]
+	})
+
+	rows := make([]map[string]string, len(values))
+	for i, v := range values {
+		rows[i] = map[string]string{
+			header:      v,
+			countHeader: strconv.Itoa(counts[v]),
+		}
+	}
+	return []string{header, countHeader}, rows
+}
+
+func (d *Dataviewer) ShowFrequencies() {
+	if d.frequencyFunc == nil || d.cursor[1] >= len(d.headers) {
+		return
+	}
+
+	header := d.headers[d.cursor[1]]
+	headers, rows := d.Frequencies(header)
+	d.frequencyFunc(header, headers, rows)
+}
@@ -0,0 +1,64 @@
+package dataviewer
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+type (
+	Inspector struct {
+		*tview.TextView
+		doneFunc func()
+		saveFunc func(header, value string)
+		header   string
+		value    string
+	}
+)
+
+func NewInspector(header, value string) *Inspector {
+	i := &Inspector{
+		TextView: tview.NewTextView().SetWrap(true),
+		header:   header,
+		value:    value,
+	}
+	i.TextView.SetBorder(true).SetTitle(" " + header + " ").SetTitleAlign(tview.AlignLeft)
+
+	if IsBinary(value) {
+		i.TextView.SetWrap(false)
+		i.TextView.SetText(fmt.Sprintf("length: %d bytes (w to save to file)\n\n%s", len(value), HexDump(value)))
+	} else {
+		i.TextView.SetText(value)
+	}
+
+	return i
+}
+
+func (i *Inspector) SetDoneFunc(fn func()) *Inspector {
+	i.doneFunc = fn
+	return i
+}
+
+func (i *Inspector) SetSaveFunc(fn func(header, value string)) *Inspector {
+	i.saveFunc = fn
+	return i
+}
+
+func (i *Inspector) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return i.TextView.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		switch {
+		case event.Key() == tcell.KeyEsc, event.Key() == tcell.KeyEnter, event.Key() == tcell.KeyRune && event.Rune() == 'q':
+			if i.doneFunc != nil {
+				i.doneFunc()
+			}
+			return
+		case event.Key() == tcell.KeyRune && event.Rune() == 'w':
+			if i.saveFunc != nil && IsBinary(i.value) {
+				i.saveFunc(i.header, i.value)
+			}
+			return
+		}
+		i.TextView.InputHandler()(event, setFocus)
+	})
+}
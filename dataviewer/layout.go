@@ -0,0 +1,80 @@
+package dataviewer
+
+import "github.com/rivo/uniseg"
+
+// updateContentWidths measures the content width of every column once, it must be called whenever
+// the headers, rows, column types, or width overrides change.
+func (d *Dataviewer) updateContentWidths() {
+	d.contentWidths = make([]int, len(d.headers))
+	for j := range d.headers {
+		d.contentWidths[j] = d.measureColumn(j)
+	}
+}
+
+func (d *Dataviewer) measureColumn(colIndex int) int {
+	header := d.headers[colIndex]
+	if width, ok := d.columnWidths[header]; ok {
+		return width
+	}
+
+	maxWidth := uniseg.StringWidth(header)
+	if d.isColumnTypesVisible() {
+		maxWidth = max(maxWidth, uniseg.StringWidth(d.columnTypes[colIndex]))
+	}
+	for _, r := range d.rows {
+		v, ok := r[header]
+		if !ok {
+			continue
+		}
+		maxWidth = max(maxWidth, uniseg.StringWidth(d.cellText(colIndex, v)))
+	}
+	return maxWidth
+}
+
+func (d *Dataviewer) getColTextWidth(colIndex int) int {
+	if colIndex < 0 || colIndex >= len(d.contentWidths) {
+		return 0
+	}
+	return d.contentWidths[colIndex]
+}
+
+// layoutColumns returns the drawn widths of the columns starting from the left column that fit in the viewport width,
+// the free space is spread evenly across them. A left column wider than the viewport is shrunk to fit.
+// Every column takes one more cell for its border, plus one for the closing border.
+func layoutColumns(contentWidths []int, left, width int) []int {
+	if left < 0 || left >= len(contentWidths) || width < 3 {
+		return nil
+	}
+
+	used := 0
+	right := left
+	for right < len(contentWidths) && used+contentWidths[right]+1 < width {
+		used += contentWidths[right] + 1
+		right++
+	}
+	if right == left {
+		return []int{width - 2}
+	}
+
+	widths := make([]int, right-left)
+	free := width - used - 1
+	for i := range widths {
+		widths[i] = contentWidths[left+i] + free/len(widths)
+	}
+	widths[len(widths)-1] += free % len(widths)
+	return widths
+}
+
+// scrollToCursorColumn adjusts the column offset so the cursor column is visible, returning the visible column widths.
+func (d *Dataviewer) scrollToCursorColumn(width int) []int {
+	if d.cursor[1] < d.offsets[1] {
+		d.offsets[1] = d.cursor[1]
+	}
+
+	widths := layoutColumns(d.contentWidths, d.offsets[1], width)
+	for d.offsets[1] < d.cursor[1] && d.cursor[1] >= d.offsets[1]+len(widths) {
+		d.offsets[1]++
+		widths = layoutColumns(d.contentWidths, d.offsets[1], width)
+	}
+	return widths
+}
@@ -0,0 +1,109 @@
+package dataviewer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ngavinsir/sqluy/keymap"
+)
+
+func TestLayoutColumns(t *testing.T) {
+	tests := []struct {
+		name          string
+		contentWidths []int
+		left, width   int
+		want          []int
+	}{
+		{"all fit, free space spread", []int{2, 3}, 0, 12, []int{4, 5}},
+		{"free space remainder on the last column", []int{2, 3}, 0, 13, []int{4, 6}},
+		{"exact fit has no free space", []int{2, 3}, 0, 8, []int{2, 3}},
+		{"columns past the width are left out", []int{4, 4, 4}, 0, 12, []int{4, 5}},
+		{"from the left column", []int{4, 4, 4}, 1, 12, []int{4, 5}},
+		{"last column", []int{4, 4, 4}, 2, 12, []int{10}},
+		{"wide column is shrunk", []int{40}, 0, 10, []int{8}},
+		{"wide column after the left one is left out", []int{2, 40}, 0, 10, []int{8}},
+		{"narrowest width", []int{5}, 0, 3, []int{1}},
+		{"too narrow", []int{5}, 0, 2, nil},
+		{"left past the columns", []int{5}, 1, 20, nil},
+		{"negative left", []int{5}, -1, 20, nil},
+		{"no columns", nil, 0, 20, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := layoutColumns(tt.contentWidths, tt.left, tt.width)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("layoutColumns(%v, %d, %d) = %v, want %v", tt.contentWidths, tt.left, tt.width, got, tt.want)
+			}
+			// the columns and their borders fill the width
+			if got != nil {
+				total := 1
+				for _, w := range got {
+					total += w + 1
+				}
+				if total != tt.width {
+					t.Errorf("columns take %d cells, want %d", total, tt.width)
+				}
+			}
+		})
+	}
+}
+
+func TestScrollToCursorColumn(t *testing.T) {
+	d := New(keymap.New(`{"keymaps": {}}`))
+	d.contentWidths = []int{6, 6, 6, 6, 6}
+
+	// 17 cells fit two columns of 6 and their borders
+	for _, tt := range []struct {
+		cursor, wantOffset, wantColumns int
+	}{
+		{0, 0, 2},
+		{1, 0, 2},
+		{2, 1, 2},
+		{4, 3, 2},
+		{3, 3, 2},
+		{1, 1, 2},
+		{0, 0, 2},
+	} {
+		d.cursor[1] = tt.cursor
+		widths := d.scrollToCursorColumn(17)
+		if d.offsets[1] != tt.wantOffset || len(widths) != tt.wantColumns {
+			t.Errorf("cursor column %d: offset %d with %d columns, want %d with %d", tt.cursor, d.offsets[1], len(widths), tt.wantOffset, tt.wantColumns)
+		}
+	}
+
+	// narrower than a column, only the cursor column is shown
+	for col := range d.contentWidths {
+		d.cursor[1] = col
+		widths := d.scrollToCursorColumn(5)
+		if d.offsets[1] != col || !slices.Equal(widths, []int{3}) {
+			t.Errorf("narrow, cursor column %d: offset %d with widths %v, want %d with [3]", col, d.offsets[1], widths, col)
+		}
+	}
+}
+
+func TestDrawNarrow(t *testing.T) {
+	headers, rows := benchData(20)
+	for width := 1; width <= 40; width++ {
+		for _, height := range []int{1, 3, 6, 12} {
+			screen := tcell.NewSimulationScreen("UTF-8")
+			if err := screen.Init(); err != nil {
+				t.Fatal(err)
+			}
+			screen.SetSize(width, height)
+
+			d := New(keymap.New(`{"keymaps": {}}`))
+			d.SetRect(0, 0, width, height)
+			d.SetData(headers, rows)
+			for _, cursor := range [][2]int{{0, 0}, {10, 2}, {20, 5}, {1, 5}} {
+				d.cursor = cursor
+				d.Draw(screen)
+				if d.offsets[1] > cursor[1] {
+					t.Errorf("%dx%d, cursor %v: column offset %d is past the cursor", width, height, cursor, d.offsets[1])
+				}
+			}
+			screen.Fini()
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package dataviewer
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/ngavinsir/sqluy/register"
+)
+
+func (d *Dataviewer) ToggleMark() {
+	if d.cursor[0] < 1 {
+		return
+	}
+
+	i := d.view[d.cursor[0]-1]
+	if _, marked := d.markedRows[i]; marked {
+		delete(d.markedRows, i)
+	} else {
+		d.markedRows[i] = struct{}{}
+	}
+	d.MoveCursorTo(d.GetDownCursor())
+}
+
+func (d *Dataviewer) ClearMarks() {
+	clear(d.markedRows)
+}
+
+// isMarked reports whether the visible row is marked.
+func (d *Dataviewer) isMarked(i int) bool {
+	_, marked := d.markedRows[d.view[i]]
+	return marked
+}
+
+// MarkedRows returns the visible marked rows in the view order, or in the loaded order with raw order,
+// or the current row if nothing is marked.
+func (d *Dataviewer) MarkedRows() []map[string]string {
+	if len(d.markedRows) == 0 {
+		row, ok := d.GetCurrentRow()
+		if !ok {
+			return nil
+		}
+		return []map[string]string{row}
+	}
+
+	indexes := make([]int, 0, len(d.markedRows))
+	for _, i := range d.view {
+		if _, marked := d.markedRows[i]; marked {
+			indexes = append(indexes, i)
+		}
+	}
+	if d.rawOrder {
+		sort.Ints(indexes)
+	}
+
+	rows := make([]map[string]string, len(indexes))
+	for i, idx := range indexes {
+		rows[i] = d.loadedRows[idx]
+	}
+	return rows
+}
+
+func (d *Dataviewer) YankRows() {
+	rows := d.MarkedRows()
+	if rows == nil {
+		return
+	}
+	register.Yank(register.Unnamed, FormatCSV(d.headers, rows, '\t'), register.Linewise)
+}
+
+// YankInList yanks the current column values of the marked rows, or of every visible row if nothing is marked,
+// as an IN-list.
+func (d *Dataviewer) YankInList() {
+	if d.cursor[1] >= len(d.headers) {
+		return
+	}
+
+	rows := d.rows
+	if len(d.markedRows) > 0 {
+		rows = d.MarkedRows()
+	}
+	header := d.headers[d.cursor[1]]
+	values := make([]string, len(rows))
+	for i, r := range rows {
+		values[i] = r[header]
+	}
+	register.Yank(register.Unnamed, FormatInList(values), register.Charwise)
+}
+
+// VisibleRows returns the headers and the rows passing the filters in the view order,
+// or in the loaded order with raw order.
+func (d *Dataviewer) VisibleRows() ([]string, []map[string]string) {
+	if !d.rawOrder {
+		return d.headers, d.rows
+	}
+
+	indexes := slices.Clone(d.view)
+	sort.Ints(indexes)
+	rows := make([]map[string]string, len(indexes))
+	for i, idx := range indexes {
+		rows[i] = d.loadedRows[idx]
+	}
+	return d.headers, rows
+}
+
+func (d *Dataviewer) ExportRows() {
+	rows := d.MarkedRows()
+	if rows == nil || d.exportFunc == nil {
+		return
+	}
+	d.exportFunc(d.headers, rows)
+}
+
+func (d *Dataviewer) DeleteRows() {
+	rows := d.MarkedRows()
+	if rows == nil || d.deleteRowsFunc == nil {
+		return
+	}
+	d.deleteRowsFunc(d.headers, rows)
+}
@@ -0,0 +1,52 @@
+package dataviewer
+
+import "maps"
+
+// valueOverhead is the rough size of a value besides its bytes, the string header and its map entry.
+const valueOverhead = 48
+
+// rowSize returns the approximate bytes held by a loaded row.
+func rowSize(row map[string]string) int {
+	size := 0
+	for _, v := range row {
+		size += len(v) + valueOverhead
+	}
+	return size
+}
+
+// MemoryUsage returns the approximate bytes held by the loaded rows.
+func (d *Dataviewer) MemoryUsage() int {
+	return d.memoryUsage
+}
+
+// Truncate keeps the first n loaded rows, it returns the number of rows kept.
+// The marks of the dropped rows are cleared, the filters and the sort are kept.
+func (d *Dataviewer) Truncate(n int) int {
+	if n < 0 || n >= len(d.loadedRows) {
+		return len(d.loadedRows)
+	}
+	d.loadedRows = d.loadedRows[:n:n]
+	maps.DeleteFunc(d.markedRows, func(i int, _ struct{}) bool { return i >= n })
+	d.updateMemoryUsage()
+	d.applyView()
+	return n
+}
+
+// RowsWithin returns the number of the first loaded rows fitting in the bytes.
+func (d *Dataviewer) RowsWithin(bytes int) int {
+	size := 0
+	for i, row := range d.loadedRows {
+		size += rowSize(row)
+		if size > bytes {
+			return i
+		}
+	}
+	return len(d.loadedRows)
+}
+
+func (d *Dataviewer) updateMemoryUsage() {
+	d.memoryUsage = 0
+	for _, row := range d.loadedRows {
+		d.memoryUsage += rowSize(row)
+	}
+}
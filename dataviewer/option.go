@@ -0,0 +1,41 @@
+package dataviewer
+
+import (
+	"time"
+
+	"github.com/ngavinsir/sqluy/config"
+)
+
+func WithTimeFormat(layout string) func(d *Dataviewer) {
+	return func(d *Dataviewer) {
+		d.timeFormat = layout
+	}
+}
+
+// WithRawOrder makes yanks and exports of marked rows use the loaded row order instead of the sorted view.
+func WithRawOrder(raw bool) func(d *Dataviewer) {
+	return func(d *Dataviewer) {
+		d.rawOrder = raw
+	}
+}
+
+// WithHeaderLines limits the header height, longer column names are cut with an ellipsis.
+// 0 wraps them fully.
+func WithHeaderLines(n int) func(d *Dataviewer) {
+	return func(d *Dataviewer) {
+		d.headerLines = n
+	}
+}
+
+// WithFlash sets the flash label alphabet and placement.
+func WithFlash(options config.Flash) func(d *Dataviewer) {
+	return func(d *Dataviewer) {
+		d.flashOptions = options
+	}
+}
+
+func WithTimeLocation(loc *time.Location) func(d *Dataviewer) {
+	return func(d *Dataviewer) {
+		d.timeLocation = loc
+	}
+}
@@ -0,0 +1,76 @@
+package dataviewer
+
+import (
+	"github.com/rivo/tview"
+)
+
+type (
+	RowForm struct {
+		*tview.Form
+		doneFunc   func(query, countQuery string)
+		cancelFunc func()
+		row        map[string]string
+		newRow     map[string]string
+		table      string
+		keys       []string
+		headers    []string
+	}
+)
+
+func NewRowForm(table string, keys, headers []string, row map[string]string) *RowForm {
+	f := &RowForm{
+		Form:    tview.NewForm(),
+		table:   table,
+		keys:    keys,
+		headers: headers,
+		row:     row,
+		newRow:  make(map[string]string, len(row)),
+	}
+	f.Form.SetBorder(true).SetTitle(" Edit " + table + " ").SetTitleAlign(tview.AlignLeft)
+
+	for _, header := range headers {
+		f.newRow[header] = row[header]
+		f.Form.AddInputField(header, row[header], 0, nil, func(text string) {
+			f.newRow[header] = text
+		})
+	}
+
+	f.Form.AddButton("Update", func() {
+		f.done(UpdateQuery(f.table, f.keys, f.headers, f.row, f.newRow))
+	})
+	f.Form.AddButton("Delete", func() {
+		f.done(DeleteQuery(f.table, f.keys, f.row))
+	})
+	f.Form.AddButton("Cancel", f.cancel)
+	f.Form.SetCancelFunc(f.cancel)
+
+	return f
+}
+
+// SetDoneFunc sets the function called with the UPDATE or DELETE statement of the row,
+// and the query counting the rows it changes.
+func (f *RowForm) SetDoneFunc(fn func(query, countQuery string)) *RowForm {
+	f.doneFunc = fn
+	return f
+}
+
+func (f *RowForm) SetCancelFunc(fn func()) *RowForm {
+	f.cancelFunc = fn
+	return f
+}
+
+func (f *RowForm) done(query string) {
+	if query == "" {
+		f.cancel()
+		return
+	}
+	if f.doneFunc != nil {
+		f.doneFunc(query, CountQuery(f.table, f.keys, []map[string]string{f.row}))
+	}
+}
+
+func (f *RowForm) cancel() {
+	if f.cancelFunc != nil {
+		f.cancelFunc()
+	}
+}
@@ -0,0 +1,159 @@
+package dataviewer
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/ngavinsir/sqluy/editor"
+	"github.com/ngavinsir/sqluy/vim"
+	"github.com/rivo/tview"
+)
+
+// EnableSearch shows the search editor on the bottom line, the cursor moves to the first cell
+// containing the search text once it's done.
+func (d *Dataviewer) EnableSearch() [2]int {
+	return d.enableSearch(false, "")
+}
+
+// EnableBackSearch is the ? search, it moves to the previous matching cell and reverses n and N.
+func (d *Dataviewer) EnableBackSearch() [2]int {
+	return d.enableSearch(true, "")
+}
+
+// EnableColumnSearch is the / search limited to the column under the cursor, n and N cycle through its matching cells.
+func (d *Dataviewer) EnableColumnSearch() [2]int {
+	if d.cursor[1] >= len(d.headers) {
+		return d.cursor
+	}
+	return d.enableSearch(false, d.headers[d.cursor[1]])
+}
+
+// enableSearch shows the search editor, the matches are limited to the column of the header if it's not empty.
+func (d *Dataviewer) enableSearch(backward bool, header string) [2]int {
+	prompt := "/"
+	if backward {
+		prompt = "?"
+	}
+	if header != "" {
+		prompt = header + prompt
+	}
+	se := editor.NewPrompt(prompt,
+		editor.WithKeymapper(d.keymapper),
+		editor.WithHistory(d.searchHistory),
+		editor.WithDoneFunc(func(_ *editor.Editor, s string) {
+			d.searchEditor = nil
+			d.searchQuery = s
+			d.searchHeader = header
+			d.searchBackward = backward
+			d.updateSearchMatches()
+			if d.operatorRunner[d.pendingAction] != nil {
+				d.operatorRunner[d.pendingAction](d.GetSearchCursor(d.searchDirection()))
+			}
+			d.ResetAction()
+		}),
+	)
+	se.SetExitFunc(func() {
+		d.searchEditor = nil
+		d.ResetAction()
+	})
+	editor.SetPromptRect(se, d.Box)
+	d.searchEditor = se
+	d.waitingForMotion = true
+	return vim.AsyncMotion
+}
+
+// updateSearchMatches finds the header and cells containing the search text, ignoring case,
+// in the order they're shown.
+func (d *Dataviewer) updateSearchMatches() {
+	d.searchMatches = nil
+	d.searchMatchSet = make(map[[2]int]struct{})
+	if d.searchQuery == "" {
+		return
+	}
+
+	query := strings.ToLower(d.searchQuery)
+	add := func(text string, c [2]int) {
+		if strings.Contains(strings.ToLower(text), query) {
+			d.searchMatches = append(d.searchMatches, c)
+			d.searchMatchSet[c] = struct{}{}
+		}
+	}
+	if d.searchHeader != "" {
+		j := slices.Index(d.headers, d.searchHeader)
+		if j < 0 {
+			return
+		}
+		for i, r := range d.rows {
+			add(d.cellText(j, r[d.searchHeader]), [2]int{i + 1, j})
+		}
+		return
+	}
+
+	for j, header := range d.headers {
+		add(header, [2]int{0, j})
+	}
+	for i, r := range d.rows {
+		for j, header := range d.headers {
+			add(d.cellText(j, r[header]), [2]int{i + 1, j})
+		}
+	}
+}
+
+// searchDirection is the direction of n, -1 after a ? search.
+func (d *Dataviewer) searchDirection() int {
+	if d.searchBackward {
+		return -1
+	}
+	return 1
+}
+
+func (d *Dataviewer) isSearchMatch(row, col int) bool {
+	_, ok := d.searchMatchSet[[2]int{row, col}]
+	return ok
+}
+
+// GetSearchCursor returns the n-th search match after the cursor, or before it if n is negative,
+// wrapping around the grid. The cursor doesn't move if there's no match.
+func (d *Dataviewer) GetSearchCursor(n int) [2]int {
+	if len(d.searchMatches) == 0 || n == 0 {
+		return d.cursor
+	}
+
+	// index of the first match after the cursor
+	next := len(d.searchMatches)
+	for i, m := range d.searchMatches {
+		if m[0] > d.cursor[0] || (m[0] == d.cursor[0] && m[1] > d.cursor[1]) {
+			next = i
+			break
+		}
+	}
+
+	idx := next + n - 1
+	if n < 0 {
+		// skip the match under the cursor when going backward
+		idx = next + n
+		if next > 0 && d.searchMatches[next-1] == d.cursor {
+			idx--
+		}
+	}
+	idx %= len(d.searchMatches)
+	if idx < 0 {
+		idx += len(d.searchMatches)
+	}
+	return d.searchMatches[idx]
+}
+
+func (d *Dataviewer) HasFocus() bool {
+	if d.searchEditor != nil && d.searchEditor.HasFocus() {
+		return true
+	}
+	return d.Box.HasFocus()
+}
+
+func (d *Dataviewer) Focus(delegate func(p tview.Primitive)) {
+	if d.searchEditor != nil {
+		delegate(d.searchEditor)
+		return
+	}
+	d.Box.Focus(delegate)
+}
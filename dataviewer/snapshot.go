@@ -0,0 +1,55 @@
+package dataviewer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Snapshot is a saved result set with the query and connection it came from.
+type Snapshot struct {
+	Query      string              `json:"query"`
+	Connection string              `json:"connection"`
+	Time       time.Time           `json:"time"`
+	Headers    []string            `json:"headers"`
+	Types      []string            `json:"types"`
+	Rows       []map[string]string `json:"rows"`
+}
+
+// Snapshot returns the loaded result set, unfiltered and in the fetched order.
+func (d *Dataviewer) Snapshot() Snapshot {
+	return Snapshot{
+		Time:    time.Now(),
+		Headers: d.headers,
+		Types:   d.columnTypes,
+		Rows:    d.loadedRows,
+	}
+}
+
+// Save writes the snapshot to the file as JSON.
+func (s Snapshot) Save(path string) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("dataviewer: error encoding snapshot: %w", err)
+	}
+	err = os.WriteFile(path, b, 0o644)
+	if err != nil {
+		return fmt.Errorf("dataviewer: error writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadSnapshot reads a snapshot saved by Save.
+func LoadSnapshot(path string) (Snapshot, error) {
+	var s Snapshot
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return s, fmt.Errorf("dataviewer: error reading %s: %w", path, err)
+	}
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return s, fmt.Errorf("dataviewer: error parsing %s: %w", path, err)
+	}
+	return s, nil
+}
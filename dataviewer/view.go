@@ -0,0 +1,99 @@
+package dataviewer
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+)
+
+// applyView rebuilds the visible rows from the loaded rows, keeping the rows matching every filter
+// sorted by the sort column. view holds the loaded row index of every visible row.
+func (d *Dataviewer) applyView() {
+	d.view = d.view[:0]
+	clear(d.cellOffsets)
+rows:
+	for i, r := range d.loadedRows {
+		for header, f := range d.filters {
+			if !f.Match(r[header]) {
+				continue rows
+			}
+		}
+		d.view = append(d.view, i)
+	}
+
+	if d.sortHeader != "" {
+		slices.SortStableFunc(d.view, func(a, b int) int {
+			c := compareValues(d.loadedRows[a][d.sortHeader], d.loadedRows[b][d.sortHeader])
+			if d.sortDesc {
+				return -c
+			}
+			return c
+		})
+	}
+
+	d.rows = make([]map[string]string, len(d.view))
+	for i, idx := range d.view {
+		d.rows[i] = d.loadedRows[idx]
+	}
+
+	d.cursor[0] = min(d.cursor[0], len(d.rows))
+	d.offsets[0] = 0
+	d.updateContentWidths()
+	d.updateSearchMatches()
+}
+
+// SortColumn cycles the sort of the current column through ascending, descending, and unsorted.
+func (d *Dataviewer) SortColumn() {
+	if d.cursor[1] >= len(d.headers) {
+		return
+	}
+
+	header := d.headers[d.cursor[1]]
+	switch {
+	case d.sortHeader != header:
+		d.sortHeader, d.sortDesc = header, false
+	case !d.sortDesc:
+		d.sortDesc = true
+	default:
+		d.sortHeader, d.sortDesc = "", false
+	}
+	d.applyView()
+}
+
+// ServerSortColumn cycles the order of the query by the current column through ascending, descending,
+// and unordered, running the query again. Unlike SortColumn, it sorts the rows that aren't loaded too.
+func (d *Dataviewer) ServerSortColumn() {
+	if d.cursor[1] >= len(d.headers) || d.serverSortFunc == nil {
+		return
+	}
+
+	header := d.headers[d.cursor[1]]
+	switch {
+	case d.serverSortHeader != header:
+		d.serverSortFunc(header, false)
+	case !d.serverSortDesc:
+		d.serverSortFunc(header, true)
+	default:
+		d.serverSortFunc("", false)
+	}
+}
+
+// SetServerSort sets the column the shown rows are ordered by in the query. The sort of the loaded rows is
+// cleared so the query order shows.
+func (d *Dataviewer) SetServerSort(header string, desc bool) {
+	d.serverSortHeader, d.serverSortDesc = header, desc
+	if header != "" && d.sortHeader != "" {
+		d.sortHeader, d.sortDesc = "", false
+		d.applyView()
+	}
+}
+
+// compareValues compares numerically when both values are numbers, otherwise as strings.
+func compareValues(a, b string) int {
+	x, errX := strconv.ParseFloat(a, 64)
+	y, errY := strconv.ParseFloat(b, 64)
+	if errX == nil && errY == nil {
+		return cmp.Compare(x, y)
+	}
+	return cmp.Compare(a, b)
+}
@@ -0,0 +1,88 @@
+package dataviewer
+
+import (
+	"github.com/ngavinsir/sqluy/register"
+)
+
+// VisualUntil starts selecting the cells from the cursor to the target, or stops selecting in the visual mode.
+func (d *Dataviewer) VisualUntil(until [2]int) {
+	if d.mode == visual {
+		d.mode = normal
+		return
+	}
+
+	d.visualStart = d.cursor
+	d.MoveCursorTo(until)
+	d.mode = visual
+}
+
+// VisualLine starts selecting whole rows from the cursor row, or stops selecting in the visual line mode.
+func (d *Dataviewer) VisualLine() {
+	if d.mode == vline {
+		d.mode = normal
+		return
+	}
+
+	d.visualStart = d.cursor
+	d.mode = vline
+}
+
+// selection returns the corners of the visual selection, the top left one first.
+// The visual line mode selects every column of the rows.
+func (d *Dataviewer) selection() ([2]int, [2]int) {
+	from := [2]int{min(d.cursor[0], d.visualStart[0]), min(d.cursor[1], d.visualStart[1])}
+	until := [2]int{max(d.cursor[0], d.visualStart[0]), max(d.cursor[1], d.visualStart[1])}
+	if d.mode == vline {
+		from[1], until[1] = 0, len(d.headers)-1
+	}
+	return from, until
+}
+
+// isSelected reports whether the cell is in the visual selection, row 0 being the header.
+func (d *Dataviewer) isSelected(row, col int) bool {
+	if d.mode != visual && d.mode != vline {
+		return false
+	}
+	from, until := d.selection()
+	return row >= from[0] && row <= until[0] && col >= from[1] && col <= until[1]
+}
+
+// rangeRows returns the headers of the columns and the rows between the two cells, the header row is left out.
+func (d *Dataviewer) rangeRows(from, until [2]int) ([]string, []map[string]string) {
+	if len(d.headers) == 0 {
+		return nil, nil
+	}
+	firstRow, lastRow := max(min(from[0], until[0]), 1), min(max(from[0], until[0]), len(d.rows))
+	firstCol, lastCol := min(from[1], until[1]), min(max(from[1], until[1]), len(d.headers)-1)
+
+	var rows []map[string]string
+	if firstRow <= lastRow {
+		rows = d.rows[firstRow-1 : lastRow]
+	}
+	return d.headers[firstCol : lastCol+1], rows
+}
+
+// YankUntil yanks the cells from the cursor to the target as tab separated values with a header line,
+// ready to paste into a spreadsheet. The cursor moves to the top left cell.
+func (d *Dataviewer) YankUntil(until [2]int) {
+	headers, rows := d.rangeRows(d.cursor, until)
+	if headers == nil {
+		return
+	}
+	register.Yank(register.Unnamed, FormatCSV(headers, rows, '\t'), register.Linewise)
+	d.cursor = [2]int{min(d.cursor[0], until[0]), min(d.cursor[1], until[1])}
+}
+
+// ExportSelection exports the cells of the visual selection, leaving the visual mode.
+func (d *Dataviewer) ExportSelection() {
+	if d.mode != visual && d.mode != vline {
+		return
+	}
+	from, until := d.selection()
+	d.mode = normal
+	headers, rows := d.rangeRows(from, until)
+	if headers == nil || d.exportFunc == nil {
+		return
+	}
+	d.exportFunc(headers, rows)
+}
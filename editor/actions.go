@@ -7,6 +7,18 @@ import (
 
 type Action uint64
 
+// MotionKind is how an operator applies to the text between the cursor and the motion target.
+type MotionKind int
+
+const (
+	// MotionExclusive leaves out the character on the target, e.g. w or b
+	MotionExclusive MotionKind = iota
+	// MotionInclusive takes the character on the target too, e.g. e or f
+	MotionInclusive
+	// MotionLinewise takes the whole lines from the cursor line to the target line, e.g. j or G
+	MotionLinewise
+)
+
 const (
 	ActionNone Action = iota
 	ActionMoveLeft
@@ -63,17 +75,44 @@ const (
 	ActionChange
 	ActionDelete
 	ActionYank
+	ActionCommand
+	ActionSelectRegister
+	ActionMoveLines
+	ActionRunCommand
+	ActionEnableBackSearch
+	ActionMovePageDown
+	ActionMovePageUp
+	ActionToggleBookmark
+	ActionMoveNextBookmark
+	ActionMovePrevBookmark
+	ActionVisualBlock
+	ActionBlockInsert
+	ActionBlockAppend
+	ActionIndent
+	ActionDedent
+	ActionIndentLine
+	ActionDedentLine
+	ActionIncrement
+	ActionDecrement
 )
 
-var OperatorActions = []Action{ActionChange, ActionDelete, ActionYank, ActionVisual}
+var OperatorActions = []Action{ActionChange, ActionDelete, ActionYank, ActionVisual, ActionIndent, ActionDedent}
 var MotionActions = []Action{ActionMoveLeft, ActionMoveRight, ActionMoveUp, ActionMoveDown, ActionMoveEndOfLine, ActionMoveStartOfLine, ActionMoveFirstNonWhitespace, ActionFlash,
-	ActionMoveLastLine, ActionMoveFirstLine, ActionMoveEndOfWord, ActionMoveStartOfWord, ActionMoveBackStartOfWord, ActionMoveBackEndOfWord, ActionEnableSearch, ActionTil,
-	ActionTilBack, ActionFind, ActionFindBack, ActionInside, ActionAround, ActionMoveStartOfBigWord, ActionMoveBackStartOfBigWord, ActionMoveEndOfBigWord, ActionMoveBackEndOfBigWord}
+	ActionMoveLastLine, ActionMoveFirstLine, ActionMoveEndOfWord, ActionMoveStartOfWord, ActionMoveBackStartOfWord, ActionMoveBackEndOfWord, ActionEnableSearch, ActionEnableBackSearch, ActionTil,
+	ActionTilBack, ActionFind, ActionFindBack, ActionInside, ActionAround, ActionMoveStartOfBigWord, ActionMoveBackStartOfBigWord, ActionMoveEndOfBigWord, ActionMoveBackEndOfBigWord,
+	ActionMoveNextBookmark, ActionMovePrevBookmark}
 var CountlessMotionActions = []Action{ActionMoveStartOfLine}
 var OperatorlessMotionActions = []Action{ActionMoveLeft, ActionMoveRight, ActionMoveUp, ActionMoveDown, ActionMoveEndOfLine, ActionMoveStartOfLine, ActionMoveFirstNonWhitespace,
-	ActionMoveLastLine, ActionMoveFirstLine, ActionMoveEndOfWord, ActionMoveStartOfWord, ActionMoveBackStartOfWord, ActionMoveBackEndOfWord, ActionEnableSearch, ActionTil,
-	ActionTilBack, ActionFind, ActionFindBack, ActionMoveStartOfBigWord, ActionMoveBackStartOfBigWord, ActionMoveEndOfBigWord, ActionMoveBackEndOfBigWord, ActionFlash}
+	ActionMoveLastLine, ActionMoveFirstLine, ActionMoveEndOfWord, ActionMoveStartOfWord, ActionMoveBackStartOfWord, ActionMoveBackEndOfWord, ActionEnableSearch, ActionEnableBackSearch, ActionTil,
+	ActionTilBack, ActionFind, ActionFindBack, ActionMoveStartOfBigWord, ActionMoveBackStartOfBigWord, ActionMoveEndOfBigWord, ActionMoveBackEndOfBigWord, ActionFlash,
+	ActionMoveNextBookmark, ActionMovePrevBookmark}
+var InclusiveMotionActions = []Action{ActionMoveEndOfWord, ActionMoveBackEndOfWord, ActionMoveEndOfBigWord, ActionMoveBackEndOfBigWord,
+	ActionTil, ActionFind, ActionInside, ActionAround}
+var LinewiseMotionActions = []Action{ActionMoveUp, ActionMoveDown, ActionMoveFirstLine, ActionMoveLastLine, ActionMoveNextBookmark, ActionMovePrevBookmark}
 var WaitingForRuneActions = []Action{ActionTil, ActionTilBack, ActionFind, ActionFindBack, ActionInside, ActionAround}
+var EditActions = []Action{ActionInsert, ActionRedo, ActionUndo, ActionDeleteUnderCursor, ActionInsertAfter, ActionInsertEndOfLine, ActionInsertBelow, ActionInsertAbove,
+	ActionChangeUntilEndOfLine, ActionDeleteUntilEndOfLine, ActionDeleteLine, ActionReplace, ActionPasteAfter, ActionPasteBefore, ActionChange, ActionDelete,
+	ActionBlockInsert, ActionBlockAppend, ActionIndent, ActionDedent, ActionIndentLine, ActionDedentLine, ActionIncrement, ActionDecrement}
 
 var actionMapper = map[Action]string{
 	ActionMoveLeft:               "move_left",
@@ -130,6 +169,25 @@ var actionMapper = map[Action]string{
 	ActionChange:                 "change",
 	ActionDelete:                 "delete",
 	ActionYank:                   "yank",
+	ActionCommand:                "command",
+	ActionSelectRegister:         "select_register",
+	ActionMoveLines:              "move_lines",
+	ActionRunCommand:             "run_command",
+	ActionEnableBackSearch:       "enable_back_search",
+	ActionMovePageDown:           "move_page_down",
+	ActionMovePageUp:             "move_page_up",
+	ActionToggleBookmark:         "toggle_bookmark",
+	ActionMoveNextBookmark:       "move_next_bookmark",
+	ActionMovePrevBookmark:       "move_prev_bookmark",
+	ActionVisualBlock:            "visual_block",
+	ActionBlockInsert:            "block_insert",
+	ActionBlockAppend:            "block_append",
+	ActionIndent:                 "indent",
+	ActionDedent:                 "dedent",
+	ActionIndentLine:             "indent_line",
+	ActionDedentLine:             "dedent_line",
+	ActionIncrement:              "increment",
+	ActionDecrement:              "decrement",
 }
 var reverseActionMapper map[string]Action
 var reverseActionMapperOnce sync.Once
@@ -157,10 +215,26 @@ func (a Action) IsCountlessMotion() bool {
 	return slices.Contains(CountlessMotionActions, a)
 }
 
+// MotionKind returns how an operator applies to the text the motion moves over.
+func (a Action) MotionKind() MotionKind {
+	switch {
+	case slices.Contains(InclusiveMotionActions, a):
+		return MotionInclusive
+	case slices.Contains(LinewiseMotionActions, a):
+		return MotionLinewise
+	}
+	return MotionExclusive
+}
+
 func (a Action) IsWaitingForRune() bool {
 	return slices.Contains(WaitingForRuneActions, a)
 }
 
+// IsEdit reports whether the action modifies the text.
+func (a Action) IsEdit() bool {
+	return slices.Contains(EditActions, a)
+}
+
 func ActionFromString(s string) Action {
 	reverseActionMapperOnce.Do(func() {
 		reverseActionMapper = make(map[string]Action, len(actionMapper))
@@ -0,0 +1,74 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// benchText returns a synthetic query text of n lines, past largeText from about 5000 lines.
+func benchText(n int) string {
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, "SELECT id, name, created_at FROM users WHERE id = %d AND name LIKE 'user_%d%%';\n", i, i)
+	}
+	return b.String()
+}
+
+// waitBackground waits for the motion indexes and the syntax parsed in the background of a large text, off the timer,
+// so an iteration doesn't leave a parse running into the next ones.
+func waitBackground(b *testing.B, e *Editor) {
+	b.StopTimer()
+	e.WaitMotionIndexes()
+	e.syntaxWg.Wait()
+	b.StartTimer()
+}
+
+func BenchmarkSetText(b *testing.B) {
+	for _, n := range []int{100, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("lines=%d", n), func(b *testing.B) {
+			e := New()
+			text := benchText(n)
+			b.SetBytes(int64(len(text)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				e.SetText(text, [2]int{n / 2, 0})
+				waitBackground(b, e)
+			}
+		})
+	}
+}
+
+func BenchmarkReplaceText(b *testing.B) {
+	for _, n := range []int{100, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("lines=%d", n), func(b *testing.B) {
+			e := New()
+			e.SetText(benchText(n), [2]int{})
+			waitBackground(b, e)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				e.ReplaceText("x", [2]int{n / 2, 7}, [2]int{n / 2, 9})
+				waitBackground(b, e)
+			}
+		})
+	}
+}
+
+func BenchmarkMotionIndexes(b *testing.B) {
+	for _, n := range []int{100, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("lines=%d", n), func(b *testing.B) {
+			e := New()
+			e.SetText(benchText(n), [2]int{})
+			waitBackground(b, e)
+			b.SetBytes(int64(len(e.text)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				e.buildWordMotionIndexes(e.editCount.Load())
+				e.WaitMotionIndexes()
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package editor
+
+import (
+	"math"
+	"strings"
+
+	"github.com/ngavinsir/sqluy/register"
+	"github.com/rivo/uniseg"
+)
+
+// visualBlock returns the rows and the screen columns [left, right) of the visual block selection,
+// the columns spanned by the visual start and the cursor. After $, right is math.MaxInt and the block
+// reaches the end of every line.
+func (e *Editor) visualBlock() (first, last, left, right int) {
+	first, last = min(e.visualStart[0], e.cursor[0]), max(e.visualStart[0], e.cursor[0])
+	startX, startWidth := e.screenColumn(e.visualStart)
+	cursorX, cursorWidth := e.screenColumn(e.cursor)
+	left, right = min(startX, cursorX), max(startX+startWidth, cursorX+cursorWidth)
+	if e.cursor == e.desiredCursor && e.desiredColumn == math.MaxInt {
+		right = math.MaxInt
+	}
+	return first, last, left, right
+}
+
+// screenColumn returns the screen column of the position in its line and the width of its character.
+func (e *Editor) screenColumn(c [2]int) (int, int) {
+	spans := e.spansPerLines[c[0]]
+	x := 0
+	for _, span := range spans[:c[1]] {
+		x += span.width
+	}
+	return x, max(spans[c[1]].width, 1)
+}
+
+// blockColumns returns the columns [start, end) of the row characters overlapping the screen columns
+// [left, right). A line ending before left has none, start and end are its end then.
+func (e *Editor) blockColumns(row, left, right int) (int, int) {
+	spans := e.spansPerLines[row]
+	start, end := -1, -1
+	x := 0
+	for col, span := range spans[:len(spans)-1] {
+		if x+span.width > left && x < right {
+			if start < 0 {
+				start = col
+			}
+			end = col + 1
+		}
+		x += span.width
+		if x >= right {
+			break
+		}
+	}
+	if start < 0 {
+		return len(spans) - 1, len(spans) - 1
+	}
+	return start, end
+}
+
+// blockText returns the text of every row of the visual block, joined with line breaks.
+func (e *Editor) blockText() string {
+	first, last, left, right := e.visualBlock()
+	lines := make([]string, 0, last-first+1)
+	for row := first; row <= last; row++ {
+		start, end := e.blockColumns(row, left, right)
+		lines = append(lines, e.textBefore([2]int{row, start}, [2]int{row, end}))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// YankBlock yanks the visual block selection and leaves the cursor on its top left corner.
+func (e *Editor) YankBlock() {
+	register.Yank(e.selectedRegister(), e.blockText(), register.Blockwise)
+	e.cursor = e.blockCorner()
+	e.mode = ModeNormal
+}
+
+// DeleteBlock deletes the visual block selection from every one of its rows as a single undo step.
+func (e *Editor) DeleteBlock() {
+	register.Delete(e.selectedRegister(), e.blockText(), register.Blockwise)
+	corner := e.blockCorner()
+	first, last, left, right := e.visualBlock()
+
+	// undo puts the cursor back on the corner
+	e.cursor = corner
+	e.Begin()
+	for row := last; row >= first; row-- {
+		start, end := e.blockColumns(row, left, right)
+		if start < end {
+			e.ReplaceText("", [2]int{row, start}, [2]int{row, end})
+		}
+	}
+	e.cursor = [2]int{corner[0], min(corner[1], max(len(e.spansPerLines[corner[0]])-2, 0))}
+	e.Commit()
+	e.mode = ModeNormal
+}
+
+// ChangeBlock deletes the visual block selection and starts inserting at its top left corner,
+// the text typed replaces the block on every row reaching it.
+func (e *Editor) ChangeBlock() {
+	first, last, left, _ := e.visualBlock()
+	corner := e.blockCorner()
+	e.DeleteBlock()
+	e.cursor = corner
+	e.blockInsert = &blockInsert{start: corner, first: first + 1, last: last, x: left}
+	e.mode = ModeInsert
+}
+
+// InsertBlock starts inserting before the visual block, or after it when appending. When the insert ends,
+// the text typed is inserted at the same column of the other rows of the block. Rows too short to reach
+// the block are skipped when inserting, and padded with spaces when appending.
+func (e *Editor) InsertBlock(appending bool) {
+	if e.mode != ModeVBlock {
+		return
+	}
+
+	first, last, left, right := e.visualBlock()
+	b := &blockInsert{first: first + 1, last: last, x: left}
+	if appending {
+		b.x, b.pad, b.toEnd = right, true, right == math.MaxInt
+	}
+
+	col, x := e.columnAt(first, b.x)
+	if b.toEnd {
+		col = len(e.spansPerLines[first]) - 1
+	} else if x < b.x && b.pad {
+		e.ReplaceText(strings.Repeat(" ", b.x-x), [2]int{first, col}, [2]int{first, col})
+		col += b.x - x
+	}
+	b.start = [2]int{first, col}
+	e.cursor = b.start
+	e.blockInsert = b
+	e.mode = ModeInsert
+}
+
+// repeatBlockInsert inserts the text typed on the first row of the block insert on its other rows.
+func (e *Editor) repeatBlockInsert(b *blockInsert, text string) {
+	e.Begin()
+	defer e.Commit()
+	for row := b.first; row <= b.last; row++ {
+		col, x := e.columnAt(row, b.x)
+		line := text
+		if b.toEnd {
+			col = len(e.spansPerLines[row]) - 1
+		} else if col == len(e.spansPerLines[row])-1 && !b.pad {
+			continue
+		} else if x < b.x {
+			line = strings.Repeat(" ", b.x-x) + line
+		}
+		e.ReplaceText(line, [2]int{row, col}, [2]int{row, col})
+	}
+}
+
+// columnAt returns the column of the first character of the row at or after the screen column x
+// and the screen column it's at, the end of the row and its width when it's shorter.
+func (e *Editor) columnAt(row, x int) (int, int) {
+	spans := e.spansPerLines[row]
+	col, lineX := 0, 0
+	for col < len(spans)-1 && lineX < x {
+		lineX += spans[col].width
+		col++
+	}
+	return col, lineX
+}
+
+// blockCorner returns the position of the top left corner of the visual block.
+func (e *Editor) blockCorner() [2]int {
+	first, _, left, right := e.visualBlock()
+	start, _ := e.blockColumns(first, left, right)
+	return [2]int{first, start}
+}
+
+// pasteBlock pastes blockwise text at the screen column of the cursor character, or after it, one line of
+// the text per row from the cursor row. Short rows are padded with spaces up to the column, and the lines
+// of the text are padded to the same width when there's text after them, keeping the block rectangular.
+func (e *Editor) pasteBlock(txt string, after bool) {
+	lines := strings.Split(txt, "\n")
+	width := 0
+	for _, line := range lines {
+		width = max(width, uniseg.StringWidth(line))
+	}
+	x, w := e.screenColumn(e.cursor)
+	if after && e.cursor[1] < len(e.spansPerLines[e.cursor[0]])-1 {
+		x += w
+	}
+
+	start := e.cursor
+	e.Begin()
+	for i, line := range lines {
+		row := start[0] + i
+		if row > len(e.spansPerLines)-1 {
+			end := [2]int{row - 1, len(e.spansPerLines[row-1]) - 1}
+			e.ReplaceText("\n", end, end)
+		}
+
+		col, lineX := e.columnAt(row, x)
+		if lineX < x {
+			line = strings.Repeat(" ", x-lineX) + line
+		}
+		if col < len(e.spansPerLines[row])-1 {
+			line += strings.Repeat(" ", width-uniseg.StringWidth(line))
+		}
+		e.ReplaceText(line, [2]int{row, col}, [2]int{row, col})
+	}
+	col, _ := e.blockColumns(start[0], x, x+1)
+	e.cursor = [2]int{start[0], col}
+	e.Commit()
+}
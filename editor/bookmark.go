@@ -0,0 +1,102 @@
+package editor
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ngavinsir/sqluy/theme"
+)
+
+// SignGroupBookmark is the sign group of the bookmarked lines.
+const SignGroupBookmark = "bookmark"
+
+// ToggleBookmark bookmarks the cursor line, or removes its bookmark.
+func (e *Editor) ToggleBookmark() {
+	row := e.cursor[0]
+	if i, found := slices.BinarySearch(e.buffer.bookmarks, row); found {
+		e.buffer.bookmarks = slices.Delete(e.buffer.bookmarks, i, i+1)
+	} else {
+		e.buffer.bookmarks = slices.Insert(e.buffer.bookmarks, i, row)
+	}
+	e.buffer.updateBookmarkSigns()
+}
+
+// Bookmarks returns the bookmarked 0-based rows, sorted.
+func (e *Editor) Bookmarks() []int {
+	return slices.Clone(e.buffer.bookmarks)
+}
+
+// SetBookmarks replaces the bookmarks, e.g. with the ones saved with the file. Rows past the text are dropped.
+func (e *Editor) SetBookmarks(rows []int) {
+	rows = slices.DeleteFunc(slices.Clone(rows), func(row int) bool { return row < 0 || row >= len(e.spansPerLines) })
+	slices.Sort(rows)
+	e.buffer.bookmarks = slices.Compact(rows)
+	e.buffer.updateBookmarkSigns()
+}
+
+// GetNextBookmarkCursor returns the start of the count-th bookmarked line after the cursor, wrapping around.
+func (e *Editor) GetNextBookmarkCursor() [2]int {
+	return e.bookmarkCursor(e.getActionCount())
+}
+
+// GetPrevBookmarkCursor returns the start of the count-th bookmarked line before the cursor, wrapping around.
+func (e *Editor) GetPrevBookmarkCursor() [2]int {
+	return e.bookmarkCursor(-e.getActionCount())
+}
+
+func (e *Editor) bookmarkCursor(n int) [2]int {
+	bookmarks := e.buffer.bookmarks
+	if len(bookmarks) == 0 {
+		if e.statusFunc != nil {
+			e.statusFunc("no bookmarks")
+		}
+		return e.cursor
+	}
+
+	// index of the first bookmark after the cursor line, and of the last one before it
+	next, _ := slices.BinarySearch(bookmarks, e.cursor[0]+1)
+	prev, _ := slices.BinarySearch(bookmarks, e.cursor[0])
+	idx := next + n - 1
+	if n < 0 {
+		idx = prev + n
+	}
+	idx %= len(bookmarks)
+	if idx < 0 {
+		idx += len(bookmarks)
+	}
+	return [2]int{bookmarks[idx], 0}
+}
+
+// shiftBookmarks keeps the bookmarks on their lines when lines are inserted or deleted before them.
+// A bookmark on a deleted line moves to the start of the change.
+func (b *buffer) shiftBookmarks(change Change) {
+	if len(b.bookmarks) == 0 {
+		return
+	}
+	delta := strings.Count(change.NewText, "\n") - strings.Count(change.OldText, "\n")
+	if delta == 0 {
+		return
+	}
+	for i, row := range b.bookmarks {
+		switch {
+		case row > change.Until[0]:
+			b.bookmarks[i] = row + delta
+		case row > change.From[0]:
+			b.bookmarks[i] = change.From[0] + min(row-change.From[0], max(change.Until[0]-change.From[0]+delta, 0))
+		}
+	}
+	b.bookmarks = slices.Compact(b.bookmarks)
+	b.updateBookmarkSigns()
+}
+
+// updateBookmarkSigns marks the bookmarked lines in every view of the text.
+func (b *buffer) updateBookmarkSigns() {
+	style := tcell.StyleDefault.Foreground(theme.Current().Accent)
+	for _, v := range b.views {
+		v.ClearSigns(SignGroupBookmark)
+		for _, row := range b.bookmarks {
+			v.PlaceSign(SignGroupBookmark, row, Sign{Glyph: '*', Style: style, Priority: 10})
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package editor
+
+import "slices"
+
+type (
+	// Change is a text change, From and Until are [row, col] positions in the old text with Until exclusive.
+	Change struct {
+		OldText string
+		NewText string
+		From    [2]int
+		Until   [2]int
+	}
+)
+
+// GetFullText returns the whole editor text.
+func (e *Editor) GetFullText() string {
+	return e.text
+}
+
+// SetTextAndNotify replaces the whole text like SetText, notifying the change subscribers.
+func (e *Editor) SetTextAndNotify(text string, cursor [2]int) *Editor {
+	change := Change{
+		From:    [2]int{0, 0},
+		Until:   e.endPosition(),
+		OldText: e.text,
+		NewText: text,
+	}
+	e.SetText(text, cursor)
+	e.notifyChange(change)
+	return e
+}
+
+// OnChange subscribes to the text changes made by editing, undo, redo, and SetTextAndNotify.
+// It returns a function to unsubscribe.
+func (e *Editor) OnChange(f func(Change)) func() {
+	h := &changeFunc{f: f}
+	e.buffer.changeFuncs = append(e.buffer.changeFuncs, h)
+	return func() {
+		// a copy, a change being notified goes on over the subscribers it started with
+		e.buffer.changeFuncs = slices.DeleteFunc(slices.Clone(e.buffer.changeFuncs), func(c *changeFunc) bool {
+			return c == h
+		})
+	}
+}
+
+func (e *Editor) notifyChange(change Change) {
+	if change.OldText == change.NewText {
+		return
+	}
+	e.buffer.shiftBookmarks(change)
+	for _, c := range e.buffer.changeFuncs {
+		c.f(change)
+	}
+}
+
+// endPosition returns the position after the last character.
+func (e *Editor) endPosition() [2]int {
+	e.ensureSpans()
+	if len(e.spansPerLines) == 0 {
+		return [2]int{0, 0}
+	}
+	row := len(e.spansPerLines) - 1
+	return [2]int{row, len(e.spansPerLines[row]) - 1}
+}
+
+// byteOffset returns the byte offset of the position in the text.
+func (e *Editor) byteOffset(pos [2]int) int {
+	e.ensureSpans()
+	offset := 0
+	for i, spans := range e.spansPerLines {
+		if i == pos[0] {
+			for _, span := range spans[:min(pos[1], len(spans))] {
+				offset += len(string(span.runes))
+			}
+			return offset
+		}
+		for _, span := range spans {
+			offset += len(string(span.runes))
+		}
+		// newline
+		offset++
+	}
+	return len(e.text)
+}
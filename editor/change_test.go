@@ -0,0 +1,58 @@
+package editor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetTextAndNotifyMeasuring(t *testing.T) {
+	e := newTestEditor(t, "", [2]int{})
+	// the queued draws never run, the spans are only set by reading them
+	e.SetDelayDrawFunc(func(time.Time, func()) {})
+
+	text := benchText(10_000)
+	e.SetText(text, [2]int{})
+	e.WaitMotionIndexes()
+
+	var changes []Change
+	e.OnChange(func(c Change) {
+		changes = append(changes, c)
+	})
+	e.SetTextAndNotify("select 1", [2]int{})
+	e.WaitMotionIndexes()
+
+	if len(changes) != 1 {
+		t.Fatalf("got %d changes, want 1", len(changes))
+	}
+	if want := [2]int{10_000, 0}; changes[0].Until != want {
+		t.Errorf("change until %v, want the end of the measured text %v", changes[0].Until, want)
+	}
+	if got := e.byteOffset([2]int{0, 6}); got != 6 {
+		t.Errorf("byteOffset = %d, want 6", got)
+	}
+}
+
+func TestOnChangeUnsubscribe(t *testing.T) {
+	e := newTestEditor(t, "a", [2]int{})
+	var got []int
+	unsubscribes := make([]func(), 3)
+	for i := range unsubscribes {
+		unsubscribes[i] = e.OnChange(func(Change) {
+			got = append(got, i)
+		})
+	}
+
+	unsubscribes[1]()
+	// unsubscribing twice leaves the others
+	unsubscribes[1]()
+	e.SetTextAndNotify("b", [2]int{})
+	if len(got) != 2 || got[0] != 0 || got[1] != 2 {
+		t.Errorf("notified %v, want [0 2]", got)
+	}
+
+	unsubscribes[0]()
+	unsubscribes[2]()
+	if len(e.buffer.changeFuncs) != 0 {
+		t.Errorf("%d subscribers left after unsubscribing all", len(e.buffer.changeFuncs))
+	}
+}
@@ -0,0 +1,45 @@
+package editor
+
+import "testing"
+
+func TestOperatorCount(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		cursor     [2]int
+		keys       string
+		wantText   string
+		wantCursor [2]int
+	}{
+		{"d2e", "one two three four\n", [2]int{}, "d2e", " three four\n", [2]int{0, 0}},
+		{"2de", "one two three four\n", [2]int{}, "2de", " three four\n", [2]int{0, 0}},
+		{"2d2w multiplies", "a b c d e f\n", [2]int{}, "2d2w", "e f\n", [2]int{0, 0}},
+		{"2dd", "a\nb\nc\n", [2]int{}, "2dd", "c\n", [2]int{0, 0}},
+		{"2dd is undone at once", "a\nb\nc\n", [2]int{}, "2ddu", "a\nb\nc\n", [2]int{0, 0}},
+		{"3dd past the end", "a\nb\nc", [2]int{1, 0}, "3dd", "a", [2]int{0, 0}},
+		{"3ce", "one two three four\n", [2]int{}, "3ceX<Esc>", "X four\n", [2]int{0, 1}},
+		{"3ce to the end of the line", "one two three\n", [2]int{}, "3ceX<Esc>", "X\n", [2]int{0, 0}},
+		{"ce of the last word", "select id\n", [2]int{0, 7}, "ceX<Esc>", "select X\n", [2]int{0, 7}},
+		{"d3w", "a b c d\n", [2]int{}, "d3w", "d\n", [2]int{0, 0}},
+		{"3x", "abcdef\n", [2]int{0, 1}, "3x", "aef\n", [2]int{0, 1}},
+		{"3x is undone at once", "abcdef\n", [2]int{0, 1}, "3xu", "abcdef\n", [2]int{0, 1}},
+		{"d2e is undone at once", "one two three\n", [2]int{}, "d2eu", "one two three\n", [2]int{0, 0}},
+		{"count after an operator resets", "a b c d\n", [2]int{}, "d2wdw", "d\n", [2]int{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t, tt.text, tt.cursor)
+			sendKeys(e, tt.keys)
+			if e.text != tt.wantText {
+				t.Errorf("text = %q, want %q", e.text, tt.wantText)
+			}
+			if e.cursor != tt.wantCursor {
+				t.Errorf("cursor = %v, want %v", e.cursor, tt.wantCursor)
+			}
+			if e.hasPending() {
+				t.Errorf("keys %v are still pending", e.pending)
+			}
+		})
+	}
+}
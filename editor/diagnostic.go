@@ -0,0 +1,88 @@
+package editor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ngavinsir/treesittergo"
+	"github.com/rivo/uniseg"
+)
+
+type (
+	// Diagnostic is a syntax error found by the parser at the 0-based row and grapheme column.
+	Diagnostic struct {
+		Row     int
+		Col     int
+		Message string
+	}
+)
+
+// String formats the diagnostic with the 1-based line and column, e.g. "2:7: expected identifier".
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s", d.Row+1, d.Col+1, d.Message)
+}
+
+// Diagnostics returns the syntax errors of the text, ordered by position.
+func (e *Editor) Diagnostics() []Diagnostic {
+	return e.diagnostics
+}
+
+// diagnostic returns the diagnostic of an error node, or of a missing node with the expected symbol as its kind.
+func diagnostic(text string, n treesittergo.Node) (Diagnostic, bool) {
+	ctx := context.Background()
+	start, err := n.StartByte(ctx)
+	if err != nil {
+		return Diagnostic{}, false
+	}
+	end, err := n.EndByte(ctx)
+	if err != nil {
+		return Diagnostic{}, false
+	}
+	kind, err := n.Kind(ctx)
+	if err != nil {
+		return Diagnostic{}, false
+	}
+	isError, err := n.IsError(ctx)
+	if err != nil {
+		return Diagnostic{}, false
+	}
+
+	var message string
+	switch {
+	case isError:
+		unexpected := strings.Join(strings.Fields(text[min(int(start), len(text)):min(int(end), len(text))]), " ")
+		if uniseg.GraphemeClusterCount(unexpected) > 20 {
+			unexpected = string([]rune(unexpected)[:20]) + "…"
+		}
+		message = fmt.Sprintf("unexpected %q", unexpected)
+	// a missing node takes no text
+	case start == end && kind != "" && kind != "program":
+		childCount, err := n.ChildCount(ctx)
+		if err != nil || childCount > 0 {
+			return Diagnostic{}, false
+		}
+		message = "expected " + symbolName(kind)
+	default:
+		return Diagnostic{}, false
+	}
+
+	row, col := textPosition(text, int(start))
+	return Diagnostic{Row: row, Col: col, Message: message}, true
+}
+
+// symbolName returns the readable name of a grammar symbol, keywords are upper cased.
+func symbolName(kind string) string {
+	if keyword, ok := strings.CutPrefix(kind, "keyword_"); ok {
+		return strings.ToUpper(keyword)
+	}
+	return strings.ReplaceAll(kind, "_", " ")
+}
+
+// textPosition maps a byte offset of the text to its row and grapheme column.
+func textPosition(text string, offset int) (int, int) {
+	before := text[:min(offset, len(text))]
+	row := strings.Count(before, "\n")
+	line := before[strings.LastIndex(before, "\n")+1:]
+	return row, uniseg.GraphemeClusterCount(line)
+}
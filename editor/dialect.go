@@ -0,0 +1,131 @@
+package editor
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type (
+	// Dialect is the SQL flavor of a connection, selecting the grammar and the highlight queries.
+	// There's only the combined SQL grammar yet, so a dialect rewrites its own syntax the grammar doesn't know
+	// into syntax of the same byte length it does, keeping the highlight positions.
+	Dialect struct {
+		Name       string
+		highlights string
+		rewrite    *regexp.Regexp
+		replace    func(match string) string
+	}
+)
+
+// rgDialectSkip matches the string literals and comments that rewrites must leave alone
+const rgDialectSkip = `'(?:[^']|'')*'|--[^\n]*|/\*(?s:.*?)\*/`
+
+var (
+	dialects = map[string]Dialect{
+		"sql": {Name: "sql", highlights: sqlHighlightsQuery},
+		// double quotes are identifiers, the grammar reads them as strings, and :name variables are unknown to it.
+		// The :: of a cast is matched first, so its type isn't read as a variable.
+		"postgres": {
+			Name:       "postgres",
+			highlights: sqlHighlightsQuery,
+			rewrite:    regexp.MustCompile(rgDialectSkip + `|"(?:[^"]|"")*"|::|:[A-Za-z_]\w*`),
+			replace: func(match string) string {
+				switch {
+				case match == "::":
+					return match
+				case strings.HasPrefix(match, ":"):
+					return "@" + match[1:]
+				}
+				return quotedIdentifier(match)
+			},
+		},
+		// double quotes are identifiers, :name parameters and the REGEXP operator are unknown to the grammar
+		"sqlite": {
+			Name:       "sqlite",
+			highlights: sqlHighlightsQuery,
+			rewrite:    regexp.MustCompile(rgDialectSkip + `|"(?:[^"]|"")*"|::|:[A-Za-z_]\w*|(?i:\bregexp\b)`),
+			replace: func(match string) string {
+				switch {
+				case match == "::":
+					return match
+				case strings.HasPrefix(match, ":"):
+					return "@" + match[1:]
+				case strings.EqualFold(match, "regexp"):
+					return "like  "
+				}
+				return quotedIdentifier(match)
+			},
+		},
+		// backticks are already identifiers, the REGEXP operator is unknown to the grammar
+		"mysql": {
+			Name:       "mysql",
+			highlights: sqlHighlightsQuery,
+			rewrite:    regexp.MustCompile(rgDialectSkip + `|(?i:\bregexp\b)`),
+			replace: func(match string) string {
+				if strings.EqualFold(match, "regexp") {
+					return "like  "
+				}
+				return match
+			},
+		},
+	}
+)
+
+// Dialects returns the dialect names, sorted.
+func Dialects() []string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SetDialect selects the SQL dialect by its name and highlights the text again.
+func (e *Editor) SetDialect(name string) error {
+	d, ok := dialects[name]
+	if !ok {
+		return fmt.Errorf("editor: unknown dialect %s", name)
+	}
+	// the dialect is of the text, shared by the split views
+	for _, v := range e.buffer.views {
+		v.dialect = d
+		if !v.oneLineMode {
+			v.highlightIndexes = make(map[[2]int]string)
+			v.buildTreesitter(v.text)
+		}
+	}
+	return nil
+}
+
+// Dialect returns the selected dialect name.
+func (e *Editor) Dialect() string {
+	return e.dialect.Name
+}
+
+// parseText returns the text as the grammar should parse it, with the same byte length.
+func (d Dialect) parseText(text string) string {
+	if d.rewrite == nil {
+		return text
+	}
+	return d.rewrite.ReplaceAllStringFunc(text, func(match string) string {
+		if strings.HasPrefix(match, "'") || strings.HasPrefix(match, "--") || strings.HasPrefix(match, "/*") {
+			return match
+		}
+		return d.replace(match)
+	})
+}
+
+// quotedIdentifier turns a double quoted identifier into a backtick quoted one.
+func quotedIdentifier(match string) string {
+	if !strings.HasPrefix(match, `"`) {
+		return match
+	}
+	inner := match[1 : len(match)-1]
+	if strings.Contains(inner, "`") {
+		return match
+	}
+	return "`" + inner + "`"
+}
@@ -1,10 +1,13 @@
 package editor
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"regexp"
 	"slices"
@@ -18,7 +21,11 @@ import (
 	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v2"
-	"github.com/ngavinsir/sqluy/clipboard"
+	"github.com/ngavinsir/sqluy/config"
+	"github.com/ngavinsir/sqluy/flash"
+	"github.com/ngavinsir/sqluy/keymap"
+	"github.com/ngavinsir/sqluy/register"
+	"github.com/ngavinsir/sqluy/theme"
 	"github.com/ngavinsir/sqluy/vim"
 	"github.com/ngavinsir/treesittergo"
 	"github.com/rivo/tview"
@@ -28,11 +35,8 @@ import (
 type (
 	keymapper interface {
 		Get(keys []string, group string) ([]string, bool)
-	}
-
-	undoStackItem struct {
-		text   string
-		cursor [2]int
+		Args(keys []string, group, action string) keymap.Args
+		Macro(keys []string, group string) []string
 	}
 
 	span struct {
@@ -46,51 +50,130 @@ type (
 		text  string
 	}
 
+	// blockInsert is an insert started from the visual block mode, what's typed on the first row
+	// is inserted on the other rows of the block too when the insert ends
+	blockInsert struct {
+		start [2]int // where the typing starts
+		first int    // the other rows
+		last  int
+		x     int  // the screen column to insert at
+		pad   bool // short rows are padded up to the column instead of skipped
+		toEnd bool // insert at the end of the rows, e.g. A after $
+	}
+
+	// highlight is a highlighted byte range of the text
+	highlight struct {
+		start int
+		end   int
+		kind  string
+	}
+
+	// syntax is the highlights and the diagnostics of a text version
+	syntax struct {
+		editCount   uint64
+		text        string
+		highlights  map[[2]int]string
+		diagnostics []Diagnostic
+	}
+
 	decorator func(x, y, width, height int)
 
 	Editor struct {
 		mutex             sync.Mutex
 		keymapper         keymapper
 		viewModalFunc     func(string)
+		statusFunc        func(string)
+		commandFunc       func(string)
+		completeFunc      func(string) []string
+		validateFunc      func(string) error
+		statuslineFuncs   map[string]func() string
 		onDoneFunc        func(*Editor, string)
 		onTextChangedFunc func(string)
 		delayDrawFunc     func(time.Time, func())
 		onExitFunc        func()
+		viewOptions       []func(*Editor)
+		buffer            *buffer
 		*tview.Box
 		searchEditor        *Editor
+		signs               map[string]map[int]Sign
 		actionRunner        map[Action]func()
 		operatorRunner      map[Action]func(target [2]int)
 		motionRunner        map[Action]func() [2]int
 		runeRunner          map[Action]func(r rune)
 		motionIndexes       map[rune][][3]int
-		flashIndexes        map[rune][2]int
-		reverseFlashIndexes map[[2]int]rune
-		motionIndexesMutex  *sync.RWMutex
-		decorations         map[[2]int]decoration
-		highlightIndexes    map[[2]int]string
+		flashIndexes        map[string][2]int
+		reverseFlashIndexes map[[2]int]string
+		flashQueryLen       int
+		flashPrefix         string
+		flashOptions        config.Flash
+		desiredCursor       [2]int
+		desiredColumn       int
+		searchBackward      bool
+		// searchWrapped is set when the last search motion went around the start or the end of the text
+		searchWrapped      bool
+		motionIndexesMutex *sync.RWMutex
+		motionIndexesWg    sync.WaitGroup
+		deferredIndexes    bool
+		decorations        map[[2]int]decoration
+		highlightIndexes   map[[2]int]string
+		pendingSyntax      *syntax
+		syntaxMutex        sync.Mutex
+		syntaxWg           sync.WaitGroup
+		syntaxLoading      atomic.Bool
+		syntaxProgress     atomic.Int64
+		// measured is the spans of a large text measured in the background, nil once they're set
+		measured            chan [][]span
+		measuredCursor      [2]int
+		highlightsPerLine   [][]highlight
+		lineStarts          []int
+		diagnostics         []Diagnostic
 		text                string
 		spansPerLines       [][]span
 		pending             []string
-		undoStack           []undoStackItem
 		decorators          []decorator
 		cursor              [2]int
 		disabled            bool
+		readOnly            bool
 		visualStart         [2]int
 		offsets             [2]int
 		pendingCount        int
-		tabSize             int
+		operatorCount       int
+		operatorKeys        int
+		options             config.Editor
 		editCount           atomic.Uint64
-		undoOffset          int
+		byteMapperEditCount uint64
+		byteMapper          [][2]int
+		undoDepth           int
+		undoBefore          [2]int
 		pendingAction       Action
+		motion              Action // the motion of the pending operator, it decides how the operator applies
+		actionArgs          keymap.Args
+		macroDepth          int
 		lastMotion          Action
 		mode                mode
 		oneLineMode         bool
+		prompt              string
+		promptErr           error
+		history             *History
+		searchHistory       *History
+		commandHistory      *History
+		historyIndex        int
+		historyDraft        string
 		waitingForMotion    bool
+		waitingForRegister  bool
+		register            rune
+		completions         []string
+		completionIndex     int
 		yankOnVisual        bool // for yank indicator utilizng ModeVisual mode
+		linewiseObject      bool // the last inside or around text object takes whole lines, e.g. ip
+		blockInsert         *blockInsert
+		substitution        *substitution // the :s being confirmed
+		lastVisual          *[2]int       // the first and last rows of the last visual selection, for '<,'>
 
 		parser  treesittergo.Parser
 		ts      treesittergo.Treesitter
 		sqlLang treesittergo.Language
+		dialect Dialect
 	}
 )
 
@@ -98,8 +181,6 @@ var (
 	//go:embed sql.highlights.scm
 	sqlHighlightsQuery string
 
-	flashAlphabet = "abcdefghijkmnpqrtwxyzABCDEFGHJKLMNPQRTUVWXY"
-
 	matchBlocks              = []rune{'{', '}', '[', ']', '(', ')', '"', '\'', '`'}
 	directionlessMatchBlocks = []rune{'"', '`', '\''}
 	matchBlockDirection      = map[rune]int{
@@ -122,22 +203,6 @@ var (
 		'`':  '`',
 	}
 
-	colorMap = map[string]tcell.Style{
-		"variable":              tcell.StyleDefault.Foreground(tcell.NewHexColor(0xc0caf5)),
-		"function.call":         tcell.StyleDefault.Foreground(tcell.NewHexColor(0x7aa2f7)),
-		"keyword.operator":      tcell.StyleDefault.Foreground(tcell.NewHexColor(0x89ddff)),
-		"keyword":               tcell.StyleDefault.Foreground(tcell.NewHexColor(0x9d7cd8)),
-		"type":                  tcell.StyleDefault.Foreground(tcell.NewHexColor(0x2ac3de)),
-		"variable.member":       tcell.StyleDefault.Foreground(tcell.NewHexColor(0x73daca)),
-		"type.builtin":          tcell.StyleDefault.Foreground(tcell.NewHexColor(0x2ac3de)),
-		"string":                tcell.StyleDefault.Foreground(tcell.NewHexColor(0x9ece6a)),
-		"operator":              tcell.StyleDefault.Foreground(tcell.NewHexColor(0x89ddff)),
-		"keyword.modifier":      tcell.StyleDefault.Foreground(tcell.NewHexColor(0x9d7cd8)),
-		"punctuation.bracket":   tcell.StyleDefault.Foreground(tcell.NewHexColor(0xa9b1d6)),
-		"punctuation.delimiter": tcell.StyleDefault.Foreground(tcell.NewHexColor(0x89ddff)),
-		"error":                 tcell.StyleDefault.Underline(tcell.UnderlineStyleCurly, tcell.ColorRed),
-	}
-
 	rgFirstNonWhitespace = regexp.MustCompile(`\S`)
 	rgMotioneOne         = regexp.MustCompile(`([^a-zA-Z0-9_À-ÿ\s])(?:[a-zA-Z0-9_À-ÿ\s]|$)`)
 	rgMotioneTwo         = regexp.MustCompile(`([a-zA-Z0-9_À-ÿ])(?:[^a-zA-Z0-9_À-ÿ]|$)`)
@@ -145,6 +210,16 @@ var (
 	rgMotionwTwo         = regexp.MustCompile(`(?:^|[a-zA-Z0-9_À-ÿ\s])([^a-zA-Z0-9_À-ÿ\s])`)
 	rgMotionW            = regexp.MustCompile(`\s(\S)`)
 	rgMotionE            = regexp.MustCompile(`\S(?:[^\S\n]|$)`)
+
+	errStaleText = errors.New("editor: text edited while processing it")
+)
+
+const (
+	// largeText is the text size from which the spans are measured and the syntax is parsed in the background,
+	// and the word motion indexes are built on their first use
+	largeText = 256 << 10
+	// spanChunk is the number of lines measured by a goroutine
+	spanChunk = 4096
 )
 
 func New(options ...func(*Editor)) *Editor {
@@ -163,14 +238,21 @@ func New(options ...func(*Editor)) *Editor {
 	parser.SetLanguage(context.Background(), sqlLang)
 
 	e := &Editor{
-		tabSize:          4,
+		options:          config.Default().Editor,
+		flashOptions:     config.Default().Flash,
 		Box:              tview.NewBox().SetBorder(true).SetTitle("Editor").SetTitleAlign(tview.AlignLeft),
 		decorations:      make(map[[2]int]decoration),
 		highlightIndexes: make(map[[2]int]string),
 		ts:               ts,
 		parser:           parser,
 		sqlLang:          sqlLang,
+		dialect:          dialects["sql"],
+		viewOptions:      options,
+		buffer:           &buffer{},
+		searchHistory:    NewHistory(),
+		commandHistory:   NewHistory(),
 	}
+	e.buffer.views = []*Editor{e}
 	for _, option := range options {
 		option(e)
 	}
@@ -219,7 +301,19 @@ AND start_city = (SELECT city_id FROM cities WHERE name = 'Edinburgh');`, [2]int
 	}
 
 	e.actionRunner = map[Action]func(){
-		ActionDone: e.Done,
+		ActionDone:    e.Done,
+		ActionCommand: e.EnableCommand,
+		ActionMoveLines: func() {
+			e.MoveCursorTo(e.GetLineCursor(e.cursor[0] + e.actionArgs.Int("n", 1)*e.getActionCount()))
+		},
+		ActionRunCommand: func() {
+			if e.commandFunc != nil && e.actionArgs.String("cmd") != "" {
+				e.commandFunc(e.actionArgs.String("cmd"))
+			}
+		},
+		ActionSelectRegister: func() {
+			e.waitingForRegister = true
+		},
 		ActionExit: e.Exit,
 		ActionInsert: func() {
 			e.ChangeMode(ModeInsert)
@@ -228,6 +322,9 @@ AND start_city = (SELECT city_id FROM cities WHERE name = 'Edinburgh');`, [2]int
 		ActionUndo:                 e.Undo,
 		ActionMoveHalfPageDown:     e.MoveCursorHalfPageDown,
 		ActionMoveHalfPageUp:       e.MoveCursorHalfPageUp,
+		ActionMovePageDown:         e.MoveCursorPageDown,
+		ActionMovePageUp:           e.MoveCursorPageUp,
+		ActionToggleBookmark:       e.ToggleBookmark,
 		ActionDeleteUnderCursor:    e.DeleteUnderCursor,
 		ActionInsertAfter:          e.InsertAfter,
 		ActionInsertEndOfLine:      e.InsertEndOfLine,
@@ -236,18 +333,41 @@ AND start_city = (SELECT city_id FROM cities WHERE name = 'Edinburgh');`, [2]int
 		ActionChangeUntilEndOfLine: e.ChangeUntilEndOfLine,
 		ActionDeleteUntilEndOfLine: e.DeleteUntilEndOfLine,
 		ActionDeleteLine: func() {
-			for range e.getActionCount() {
+			if len(e.spansPerLines) < 1 {
+				return
+			}
+			// the count stops at the last line, the lines above stay
+			first := e.cursor[0]
+			last := min(first+e.getActionCount(), len(e.spansPerLines)) - 1
+			register.Delete(e.selectedRegister(), e.GetText([2]int{first, 0}, [2]int{last, len(e.spansPerLines[last]) - 1}), register.Linewise)
+			// the lines are undone at once
+			e.Begin()
+			for range last - first + 1 {
 				e.DeleteLine()
 			}
+			e.Commit()
+		},
+		ActionIndentLine: func() {
+			e.shiftLines(e.cursor[0], e.cursor[0]+e.getActionCount()-1, 1)
+		},
+		ActionDedentLine: func() {
+			e.shiftLines(e.cursor[0], e.cursor[0]+e.getActionCount()-1, -1)
+		},
+		ActionIncrement: func() {
+			e.AddNumber(e.getActionCount())
+		},
+		ActionDecrement: func() {
+			e.AddNumber(-e.getActionCount())
 		},
 		ActionPasteBefore: func() {
-			txt, _ := clipboard.Read()
+			txt, typ := register.Get(e.selectedRegister())
 			if txt == "" {
 				return
 			}
 
-			hasNewLine := uniseg.HasTrailingLineBreakInString(txt)
-			if hasNewLine {
+			if typ == register.Blockwise {
+				e.pasteBlock(txt, false)
+			} else if typ == register.Linewise {
 				c := [2]int{e.cursor[0], 0}
 				e.ReplaceText(txt, c, c)
 			} else {
@@ -259,13 +379,19 @@ AND start_city = (SELECT city_id FROM cities WHERE name = 'Edinburgh');`, [2]int
 			}
 		},
 		ActionPasteAfter: func() {
-			txt, _ := clipboard.Read()
+			txt, typ := register.Get(e.selectedRegister())
 			if txt == "" {
 				return
 			}
 
-			hasNewLine := uniseg.HasTrailingLineBreakInString(txt)
-			if hasNewLine {
+			linewise := typ == register.Linewise
+			if typ == register.Blockwise {
+				e.pasteBlock(txt, true)
+			} else if linewise && e.cursor[0] == len(e.spansPerLines)-1 {
+				// there's no line below the last line to paste before
+				c := [2]int{e.cursor[0], len(e.spansPerLines[e.cursor[0]]) - 1}
+				e.ReplaceText("\n"+strings.TrimSuffix(txt, "\n"), c, c)
+			} else if linewise {
 				c := [2]int{e.cursor[0] + 1, 0}
 				e.ReplaceText(txt, c, c)
 			} else {
@@ -281,6 +407,19 @@ AND start_city = (SELECT city_id FROM cities WHERE name = 'Edinburgh');`, [2]int
 			e.visualStart = [2]int{e.cursor[0], 0}
 			e.ChangeMode(ModeVLine)
 		},
+		ActionVisualBlock: func() {
+			if e.mode == ModeVBlock {
+				e.ChangeMode(ModeNormal)
+				return
+			}
+			// switching from the visual mode keeps its start
+			if e.mode != ModeVisual {
+				e.visualStart = e.cursor
+			}
+			e.ChangeMode(ModeVBlock)
+		},
+		ActionBlockInsert: func() { e.InsertBlock(false) },
+		ActionBlockAppend: func() { e.InsertBlock(true) },
 		ActionMoveMatchBlock: func() {
 			e.MoveCursorTo(e.GetMatchingBlock(e.cursor))
 		},
@@ -288,13 +427,13 @@ AND start_city = (SELECT city_id FROM cities WHERE name = 'Edinburgh');`, [2]int
 			e.ChangeMode(ModeReplace)
 		},
 		ActionMoveNextSearch: func() {
-			e.MoveMotion('n', e.getActionCount())
+			e.MoveCursorTo(e.getSearchCursor(e.searchDirection() * e.getActionCount()))
 		},
 		ActionMovePrevSearch: func() {
-			e.MoveMotion('n', -e.getActionCount())
+			e.MoveCursorTo(e.getSearchCursor(-e.searchDirection() * e.getActionCount()))
 		},
 		ActionSwitchVisualStart: func() {
-			if e.mode != ModeVisual {
+			if e.mode != ModeVisual && e.mode != ModeVBlock {
 				return
 			}
 
@@ -334,6 +473,8 @@ AND start_city = (SELECT city_id FROM cities WHERE name = 'Edinburgh');`, [2]int
 		ActionMoveRight:              e.GetRightCursor,
 		ActionMoveLastLine:           e.GetLastLineCursor,
 		ActionMoveFirstLine:          e.GetFirstLineCursor,
+		ActionMoveNextBookmark:       e.GetNextBookmarkCursor,
+		ActionMovePrevBookmark:       e.GetPrevBookmarkCursor,
 		ActionMoveStartOfWord:        e.GetStartOfWordCursor,
 		ActionMoveStartOfBigWord:     e.GetStartOfBigWordCursor,
 		ActionMoveEndOfBigWord:       e.GetEndOfBigWordCursor,
@@ -343,6 +484,7 @@ AND start_city = (SELECT city_id FROM cities WHERE name = 'Edinburgh');`, [2]int
 		ActionMoveBackEndOfWord:      e.GetBackEndOfWordCursor,
 		ActionMoveBackStartOfWord:    e.GetBackStartOfWordCursor,
 		ActionEnableSearch:           e.EnableSearch,
+		ActionEnableBackSearch:       e.EnableBackSearch,
 		ActionFlash:                  e.Flash,
 		ActionTil:                    e.GetTilCursor,
 		ActionTilBack:                e.GetTilBackCursor,
@@ -358,6 +500,8 @@ AND start_city = (SELECT city_id FROM cities WHERE name = 'Edinburgh');`, [2]int
 		ActionDelete: e.DeleteUntil,
 		ActionYank:   e.YankUntil,
 		ActionVisual: e.VisualUntil,
+		ActionIndent: e.IndentUntil,
+		ActionDedent: e.DedentUntil,
 	}
 
 	e.runeRunner = map[Action]func(r rune){
@@ -374,6 +518,7 @@ AND start_city = (SELECT city_id FROM cities WHERE name = 'Edinburgh');`, [2]int
 		e.searchDecorator,
 		e.visualDecorator,
 		e.flashDecorator,
+		e.substituteDecorator,
 	}
 
 	return e
@@ -387,46 +532,206 @@ func (e *Editor) SetOneLineMode(b bool) *Editor {
 	return e
 }
 
+// SetPrompt sets the text shown before the input in one line mode, e.g. "/" or ":".
+func (e *Editor) SetPrompt(prompt string) *Editor {
+	e.prompt = prompt
+	return e
+}
+
 func (e *Editor) SetViewModalFunc(f func(string)) *Editor {
 	e.viewModalFunc = f
 	return e
 }
 
+// SetCommandFunc sets a handler for the command entered in the command line, without the leading colon.
+func (e *Editor) SetCommandFunc(f func(string)) *Editor {
+	e.commandFunc = f
+	return e
+}
+
+// SetCompleteFunc sets the completion of the command line, returning the completed command lines.
+// Tab and Shift-Tab cycle through them.
+func (e *Editor) SetCompleteFunc(f func(string) []string) *Editor {
+	e.completeFunc = f
+	return e
+}
+
+// SetOptions applies the editor options, e.g. from the config or :set.
+func (e *Editor) SetOptions(options config.Editor) *Editor {
+	e.ensureSpans()
+	tabStopChanged := options.TabStop != e.options.TabStop
+	e.options = options
+	// tab width is measured when the text is set
+	if tabStopChanged && e.spansPerLines != nil {
+		e.SetText(e.text, e.cursor)
+	}
+	return e
+}
+
+func (e *Editor) Options() config.Editor {
+	return e.options
+}
+
+// SetStatusFunc sets a handler showing a short message, e.g. when an edit is rejected in read-only mode.
+func (e *Editor) SetStatusFunc(f func(string)) *Editor {
+	e.statusFunc = f
+	return e
+}
+
+// SetTextChangedFunc sets a handler called with the new text whenever the text is set, e.g. to follow a prompt as it's typed.
+func (e *Editor) SetTextChangedFunc(f func(string)) *Editor {
+	e.onTextChangedFunc = f
+	return e
+}
+
+func (e *Editor) SetExitFunc(f func()) *Editor {
+	e.onExitFunc = f
+	return e
+}
+
 func (e *Editor) SetDelayDrawFunc(f func(time.Time, func())) *Editor {
 	e.delayDrawFunc = f
 	return e
 }
 
+// GetCursor returns the cursor [row, col] position.
+func (e *Editor) GetCursor() [2]int {
+	return e.cursor
+}
+
+// ModeName returns the name of the current mode shown in the status line, e.g. NORMAL.
+func (e *Editor) ModeName() string {
+	return e.mode.String()
+}
+
+// WaitMotionIndexes blocks until the motion indexes of the current text are built.
+func (e *Editor) WaitMotionIndexes() {
+	e.motionIndexesWg.Wait()
+}
+
 func (e *Editor) SetText(text string, cursor [2]int) *Editor {
+	oldText := e.text
+	e.setText(text, cursor)
+	e.syncViews(oldText)
+	return e
+}
+
+// setText sets the text of this view only.
+func (e *Editor) setText(text string, cursor [2]int) {
+	e.promptErr = nil
 	if e.onTextChangedFunc != nil {
 		e.onTextChangedFunc(text)
 	}
 
-	editCount := e.editCount.Add(1)
+	e.editCount.Add(1)
 	clear(e.spansPerLines)
 
 	lines := strings.Split(text, "\n")
 	if e.oneLineMode {
 		lines = lines[:1]
 	}
-	e.spansPerLines = make([][]span, len(lines))
-	e.cursor = cursor
 	e.text = text
+	e.motionIndexes = make(map[rune][][3]int)
+	e.highlightIndexes = make(map[[2]int]string)
 
-	for i, line := range lines {
-		text = line
+	// a large text is blank until its spans are measured in the background, the queued draw sets them
+	if len(text) >= largeText && !e.oneLineMode && e.delayDrawFunc != nil {
+		measured := make(chan [][]span, 1)
+		e.measured, e.measuredCursor = measured, cursor
+		e.spansPerLines = [][]span{{{runes: nil, width: 1}}}
+		e.cursor = [2]int{}
+		tabStop, delayDraw := e.options.TabStop, e.delayDrawFunc
+		go func() {
+			measured <- measureText(lines, tabStop)
+			delayDraw(time.Now(), func() {
+				// a newer text has its own measuring
+				if e.measured == measured {
+					e.ensureSpans()
+				}
+			})
+		}()
+		return
+	}
+
+	e.measured = nil
+	e.setSpans(measureText(lines, e.options.TabStop), cursor)
+}
+
+// ensureSpans sets the spans of the text measured in the background, waiting for them.
+// Anything reading the spans calls it first, before the queued draw sets them.
+func (e *Editor) ensureSpans() {
+	if e.measured == nil {
+		return
+	}
+	spansPerLines := <-e.measured
+	e.measured = nil
+
+	cursor := e.measuredCursor
+	cursor[0] = max(min(cursor[0], len(spansPerLines)-1), 0)
+	cursor[1] = max(min(cursor[1], len(spansPerLines[cursor[0]])-1), 0)
+	e.setSpans(spansPerLines, cursor)
+}
+
+// setSpans sets the spans of the text with the cursor, then builds the motion indexes and the syntax of it.
+func (e *Editor) setSpans(spansPerLines [][]span, cursor [2]int) {
+	e.spansPerLines = spansPerLines
+	e.cursor = cursor
+
+	// an edit forgets the column kept by vertical motions
+	e.desiredCursor = [2]int{-1, -1}
+	e.MoveCursorToLine(cursor[0])
+
+	e.deferredIndexes = len(e.text) >= largeText
+	if !e.deferredIndexes {
+		e.buildWordMotionIndexes(e.editCount.Load())
+	}
+
+	if !e.oneLineMode {
+		e.buildTreesitter(e.text)
+	}
+}
+
+// measureText returns the spans of the lines, long texts are measured in chunks of lines in parallel.
+func measureText(lines []string, tabStop int) [][]span {
+	spansPerLines := make([][]span, len(lines))
+	if len(lines) <= spanChunk {
+		measureLines(spansPerLines, lines, 0, len(lines), tabStop)
+		return spansPerLines
+	}
+
+	var wg sync.WaitGroup
+	for from := 0; from < len(lines); from += spanChunk {
+		until := min(from+spanChunk, len(lines))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			measureLines(spansPerLines, lines, from, until, tabStop)
+		}()
+	}
+	wg.Wait()
+	return spansPerLines
+}
+
+// measureLines sets the spans of the lines from until the index.
+func measureLines(spansPerLines [][]span, lines []string, from, until, tabStop int) {
+	for i, line := range lines[from:until] {
+		i += from
+		text := line
 		spans := make([]span, uniseg.GraphemeClusterCount(text)+1)
 		state := -1
 		cluster := ""
 		boundaries := 0
 		j := 0
+		lineWidth := 0
 		for text != "" {
 			cluster, text, boundaries, state = uniseg.StepString(text, state)
 
 			width := boundaries >> uniseg.ShiftWidth
+			// tab spans until the next tab stop
 			if cluster == "\t" {
-				width = e.tabSize
+				width = tabStop - lineWidth%tabStop
 			}
+			lineWidth += width
 			_, bytesWidth := utf8.DecodeRuneInString(cluster)
 			span := span{
 				width:      width,
@@ -437,33 +742,85 @@ func (e *Editor) SetText(text string, cursor [2]int) *Editor {
 			j++
 		}
 		spans[j] = span{runes: nil, width: 1}
-		e.spansPerLines[i] = spans
+		spansPerLines[i] = spans
 	}
+}
 
-	e.MoveCursorToLine(cursor[0])
-
-	e.motionIndexes = make(map[rune][][3]int)
-	e.highlightIndexes = make(map[[2]int]string)
+// buildWordMotionIndexes builds the w, e, W, and E motion indexes of the text in the background.
+func (e *Editor) buildWordMotionIndexes(editCount uint64) {
 	spansPerLines := append([][]span{}, e.spansPerLines...)
-	go e.buildMotionwIndexes(editCount, e.text, spansPerLines)
-	go e.buildMotioneIndexes(editCount, e.text, spansPerLines)
-	go e.buildMotionWIndexes(editCount, e.text, spansPerLines)
-	go e.buildMotionEIndexes(editCount, e.text, spansPerLines)
-
-	if !e.oneLineMode {
-		e.buildTreesitter(e.text)
+	text := e.text
+	for _, build := range []func(uint64, string, [][]span){e.buildMotionwIndexes, e.buildMotioneIndexes, e.buildMotionWIndexes, e.buildMotionEIndexes} {
+		e.motionIndexesWg.Add(1)
+		go func() {
+			defer e.motionIndexesWg.Done()
+			build(editCount, text, spansPerLines)
+		}()
 	}
+}
 
-	return e
+// ensureMotionIndexes builds the word motion indexes deferred for a large text, waiting for them.
+func (e *Editor) ensureMotionIndexes(m rune) {
+	if !e.deferredIndexes || !strings.ContainsRune("weWE", m) {
+		return
+	}
+	e.deferredIndexes = false
+	e.buildWordMotionIndexes(e.editCount.Load())
+	e.motionIndexesWg.Wait()
 }
 
 func (e *Editor) buildTreesitter(text string) {
-	tree, err := e.parser.ParseString(context.Background(), text)
+	editCount := e.editCount.Load()
+	d := e.dialect
+	if len(text) < largeText {
+		syntax, _ := e.parseSyntax(editCount, text, d)
+		e.applySyntax(syntax)
+		return
+	}
+
+	// large texts are plain until they're parsed in the background, Draw applies the result
+	e.highlightIndexes = make(map[[2]int]string)
+	e.diagnostics = nil
+	e.updateDiagnosticSigns()
+	e.indexHighlights(text)
+	e.syntaxProgress.Store(0)
+	e.syntaxLoading.Store(true)
+	e.syntaxWg.Add(1)
+	go func() {
+		defer e.syntaxWg.Done()
+		syntax, ok := e.parseSyntax(editCount, text, d)
+		if !ok {
+			return
+		}
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		e.pendingSyntax = &syntax
+	}()
+}
+
+// parseSyntax parses the highlights and the diagnostics of the text version,
+// ok is false if the text is edited before the parsing is done.
+func (e *Editor) parseSyntax(editCount uint64, text string, d Dialect) (syntax, bool) {
+	e.syntaxMutex.Lock()
+	defer e.syntaxMutex.Unlock()
+
+	s := syntax{editCount: editCount, text: text, highlights: make(map[[2]int]string)}
+	if e.editCount.Load() != editCount {
+		return s, false
+	}
+	// the query matches are the first half of the progress, the tree walk the second
+	progress := func(offset uint64, half int64) {
+		if len(text) > 0 {
+			e.syntaxProgress.Store(half*50 + int64(offset)*50/int64(len(text)))
+		}
+	}
+
+	tree, err := e.parser.ParseString(context.Background(), d.parseText(text))
 	if err != nil {
 		panic(err)
 	}
 
-	q, err := e.ts.NewQuery(context.Background(), sqlHighlightsQuery, e.sqlLang)
+	q, err := e.ts.NewQuery(context.Background(), d.highlights, e.sqlLang)
 	if err != nil {
 		panic(err)
 	}
@@ -506,12 +863,22 @@ func (e *Editor) buildTreesitter(text string) {
 				panic(err)
 			}
 			lastEnd = nodeEndByte
-			e.highlightIndexes[[2]int{int(nodeStartByte), int(nodeEndByte)}] = captureName
+			s.highlights[[2]int{int(nodeStartByte), int(nodeEndByte)}] = captureName
+			progress(nodeStartByte, 0)
+		}
+		if e.editCount.Load() != editCount {
+			return s, false
 		}
 	}
 
-	i := e.ts.NewIterator(rootNode, treesittergo.DFSMode)
-	i.ForEach(context.Background(), func(n treesittergo.Node) error {
+	err = walkTree(rootNode, func(n treesittergo.Node) error {
+		if e.editCount.Load() != editCount {
+			return errStaleText
+		}
+		if d, ok := diagnostic(text, n); ok {
+			s.diagnostics = append(s.diagnostics, d)
+		}
+
 		nodeIsError, err := n.IsError(context.Background())
 		if err != nil {
 			panic(err)
@@ -525,10 +892,108 @@ func (e *Editor) buildTreesitter(text string) {
 			if err != nil {
 				panic(err)
 			}
-			e.highlightIndexes[[2]int{int(nodeStartByte), int(nodeEndByte)}] = "error"
+			s.highlights[[2]int{int(nodeStartByte), int(nodeEndByte)}] = "error"
+			progress(nodeStartByte, 1)
 		}
 		return nil
 	})
+	return s, err == nil
+}
+
+// applySyntax shows the parsed highlights and diagnostics if they're of the current text.
+func (e *Editor) applySyntax(s syntax) {
+	if s.editCount != e.editCount.Load() {
+		return
+	}
+	e.highlightIndexes = s.highlights
+	e.diagnostics = s.diagnostics
+	e.updateDiagnosticSigns()
+	e.indexHighlights(s.text)
+	e.syntaxLoading.Store(false)
+}
+
+// applyPendingSyntax applies the syntax parsed in the background, if it's done.
+func (e *Editor) applyPendingSyntax() {
+	e.mutex.Lock()
+	s := e.pendingSyntax
+	e.pendingSyntax = nil
+	e.mutex.Unlock()
+	if s != nil {
+		e.applySyntax(*s)
+	}
+}
+
+// SyntaxProgress returns the percentage of the syntax parsed in the background, ok is false if it's not parsing.
+func (e *Editor) SyntaxProgress() (percent int, ok bool) {
+	return int(e.syntaxProgress.Load()), e.syntaxLoading.Load()
+}
+
+// walkTree calls fn for the node and its descendants depth first, in text order.
+// It keeps its own stack, the treesittergo iterator copies its whole queue on every node.
+func walkTree(root treesittergo.Node, fn func(treesittergo.Node) error) error {
+	ctx := context.Background()
+	stack := []treesittergo.Node{root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		err := fn(n)
+		if err != nil {
+			return err
+		}
+
+		childCount, err := n.ChildCount(ctx)
+		if err != nil {
+			return err
+		}
+		for i := childCount; i > 0; i-- {
+			c, err := n.Child(ctx, i-1)
+			if err != nil {
+				return err
+			}
+			stack = append(stack, c)
+		}
+	}
+	return nil
+}
+
+// indexHighlights indexes the highlight ranges by every line they cover, so drawing only visits the visible lines.
+// Errors are indexed last to be drawn over the other highlights.
+func (e *Editor) indexHighlights(text string) {
+	e.lineStarts = e.lineStarts[:0]
+	e.lineStarts = append(e.lineStarts, 0)
+	for i := range len(text) {
+		if text[i] == '\n' {
+			e.lineStarts = append(e.lineStarts, i+1)
+		}
+	}
+	// the end of the last line, past its missing newline
+	e.lineStarts = append(e.lineStarts, len(text)+1)
+
+	highlights := make([]highlight, 0, len(e.highlightIndexes))
+	for byteRange, kind := range e.highlightIndexes {
+		highlights = append(highlights, highlight{start: byteRange[0], end: byteRange[1], kind: kind})
+	}
+	slices.SortFunc(highlights, func(a, b highlight) int {
+		if (a.kind == "error") != (b.kind == "error") {
+			if a.kind == "error" {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.start, b.start)
+	})
+
+	e.highlightsPerLine = make([][]highlight, len(e.lineStarts)-1)
+	for _, h := range highlights {
+		if h.end <= h.start {
+			continue
+		}
+		first, _ := slices.BinarySearch(e.lineStarts, h.start+1)
+		last, _ := slices.BinarySearch(e.lineStarts, h.end)
+		for row := first - 1; row < last && row < len(e.highlightsPerLine); row++ {
+			e.highlightsPerLine[row] = append(e.highlightsPerLine[row], h)
+		}
+	}
 }
 
 func (e *Editor) buildSearchIndexes(group rune, query string, offset, y, maxY int) bool {
@@ -780,63 +1245,66 @@ func (e *Editor) buildMotioneIndexes(editCount uint64, text string, spansPerLine
 }
 
 func (e *Editor) Draw(screen tcell.Screen) {
+	e.applyPendingSyntax()
 	e.Box.DrawForSubclass(screen, e)
 
 	x, y, w, h := e.Box.GetInnerRect()
 
 	// print mode
 	if e.oneLineMode {
-		tview.Print(screen, "("+e.mode.ShortString()+") ", x, y, 4, tview.AlignLeft, tcell.ColorYellow)
+		tview.Print(screen, "("+e.mode.ShortString()+") ", x, y, 4, tview.AlignLeft, theme.Current().Accent)
 		x += 4
 		w -= 4
+		if e.prompt != "" {
+			_, promptWidth := tview.Print(screen, tview.Escape(e.prompt), x, y, w, tview.AlignLeft, tview.Styles.PrimaryTextColor)
+			x += promptWidth
+			w -= promptWidth
+		}
+		if e.promptErr != nil {
+			_, errWidth := tview.Print(screen, tview.Escape(e.promptErr.Error()), x, y, w, tview.AlignRight, theme.Current().Error)
+			w -= errWidth + 1
+		}
 	} else if e.searchEditor != nil {
 		defer e.searchEditor.Draw(screen)
 	} else {
-		modeColor := tcell.ColorLightGray
-		// modeBg := tcell.ColorWhite
-		if e.mode == ModeInsert {
-			modeColor = tcell.ColorGreen
-			// modeBg = tcell.ColorLightGreen
-		} else if e.mode == ModeReplace {
-			modeColor = tcell.ColorPink
-			// modeBg = tcell.ColorPurple
-		}
-		_, modeWidth := tview.Print(screen, e.mode.String(), x, y+h-1, w, tview.AlignLeft, modeColor)
-		_, modeTxtWidth := tview.Print(screen, " mode", x+modeWidth, y+h-1, w-modeWidth, tview.AlignLeft, tcell.ColorWhite)
-		pendingWidth := 0
-		if len(e.pending) > 0 || e.pendingCount > 0 || e.pendingAction != ActionNone {
-			pendingCountTxt := ""
-			if e.pendingCount > 0 {
-				pendingCountTxt = strconv.Itoa(e.pendingCount)
-			}
-			_, pendingWidth = tview.Print(screen, "("+pendingCountTxt+strings.Join(e.pending, "")+")", x+modeWidth+modeTxtWidth+1, y+h-1, w-(x+modeWidth+modeTxtWidth), tview.AlignLeft, tcell.ColorYellow)
-		}
-		posText := fmt.Sprintf("x: %d/%d y: %d/%d", e.cursor[1]+1, len(e.spansPerLines[e.cursor[0]]), e.cursor[0]+1, len(e.spansPerLines))
-		tview.Print(screen, posText, x+modeWidth+modeTxtWidth+pendingWidth+1, y+h-1, w-(x+modeWidth+modeTxtWidth+pendingWidth+1), tview.AlignRight, tcell.ColorWhite)
+		left := e.statuslineText(e.options.Statusline.Left)
+		right := e.statuslineText(e.options.Statusline.Right)
+		_, leftWidth := tview.Print(screen, left, x, y+h-1, w, tview.AlignLeft, tview.Styles.PrimaryTextColor)
+		tview.Print(screen, right, x+leftWidth+1, y+h-1, w-leftWidth-1, tview.AlignRight, tview.Styles.PrimaryTextColor)
 		h--
 	}
+	// nothing fits, e.g. in the middle of a terminal resize
+	if w <= 0 || h <= 0 {
+		return
+	}
 
-	// fix offsets position so the cursor is visible
+	// fix offsets position so the cursor is visible, keeping scrolloff lines around it
+	scrollOff := min(e.options.ScrollOff, (h-1)/2)
 	// cursor is above row offset, set row offset to cursor row
-	if e.cursor[0] < e.offsets[0] {
-		e.offsets[0] = e.cursor[0]
+	if e.cursor[0] < e.offsets[0]+scrollOff {
+		e.offsets[0] = e.cursor[0] - scrollOff
 	}
 	// cursor is below row offset
-	if e.cursor[0] >= e.offsets[0]+h {
-		e.offsets[0] = e.cursor[0] - h + 1
+	if e.cursor[0] >= e.offsets[0]+h-scrollOff {
+		e.offsets[0] = e.cursor[0] - h + scrollOff + 1
 	}
 	// adjust offset so there's no empty line
 	if e.offsets[0]+h > len(e.spansPerLines) {
 		e.offsets[0] = len(e.spansPerLines) - h
-		if e.offsets[0] < 0 {
-			e.offsets[0] = 0
-		}
+	}
+	if e.offsets[0] < 0 {
+		e.offsets[0] = 0
 	}
 
 	cursorX := 0
 	for _, span := range e.spansPerLines[e.cursor[0]][:e.cursor[1]] {
 		cursorX += span.width
 	}
+	// the wrapped lines are never scrolled sideways
+	wrap := e.options.Wrap && !e.oneLineMode
+	if wrap {
+		e.offsets[1] = 0
+	}
 	// cursor is before column offset
 	if cursorX < e.offsets[1] {
 		e.offsets[1] = cursorX - 1
@@ -845,15 +1313,35 @@ func (e *Editor) Draw(screen tcell.Screen) {
 		}
 	}
 
+	showLineNumber := !e.oneLineMode && (e.options.Number || e.options.RelativeNumber)
 	lineNumberDigit := len(strconv.Itoa(len(e.spansPerLines)))
 	lineNumberWidth := 0
-	if !e.oneLineMode {
+	if showLineNumber {
 		lineNumberWidth = lineNumberDigit + 1
 	}
+	// the sign column is before the line numbers, the gutter is both
+	showSigns := !e.oneLineMode && e.hasSigns()
+	gutterWidth := lineNumberWidth
+	if showSigns {
+		gutterWidth += signWidth
+	}
 
 	// cursor is after column offset
-	if cursorX > e.offsets[1]+w {
-		e.offsets[1] = cursorX - w + 1
+	if textWidth := w - gutterWidth; !wrap && cursorX >= e.offsets[1]+textWidth {
+		e.offsets[1] = cursorX - textWidth + 1
+	}
+	if wrap && w > gutterWidth {
+		e.scrollWrapped(w-gutterWidth, h, scrollOff)
+	}
+	// scroll back when the one line text no longer fills the width, e.g. after deleting
+	if e.oneLineMode && e.offsets[1] > 0 {
+		lineWidth := 0
+		for _, span := range e.spansPerLines[0] {
+			lineWidth += span.width
+		}
+		if lineWidth-e.offsets[1] < w {
+			e.offsets[1] = max(lineWidth-w, 0)
+		}
 	}
 
 	textX := x
@@ -868,39 +1356,69 @@ func (e *Editor) Draw(screen tcell.Screen) {
 		decorator(e.offsets[1], e.offsets[0], w, h)
 	}
 
+	// cursorScreen is where the cursor is drawn, found while drawing the wrapped lines
+	cursorScreen := [2]int{cursorX + x + gutterWidth - e.offsets[1], e.cursor[0] + y - e.offsets[0]}
 	for row, spans := range e.spansPerLines[e.offsets[0]:lastLine] {
 		row += e.offsets[0]
+		if textY >= y+h {
+			break
+		}
 
 		// highlight current cursor line
 		if e.HasFocus() && !e.oneLineMode && row == e.cursor[0] {
 			highlightWidth := w
 			if !e.oneLineMode {
-				highlightWidth += lineNumberWidth
+				highlightWidth += gutterWidth
 			}
 			for i := range w {
-				screen.SetContent(x+i, textY, ' ', nil, tcell.StyleDefault.Background(tcell.ColorGray).Foreground(tcell.ColorWhite))
+				screen.SetContent(x+i, textY, ' ', nil, tcell.StyleDefault.Background(theme.Current().CursorLine).Foreground(tview.Styles.PrimaryTextColor))
 			}
 		}
 
-		// print line numbers
-		if !e.oneLineMode {
-			lineNumber := row - e.cursor[0]
-			if lineNumber < 0 {
-				lineNumber *= -1
+		// print signs
+		if showSigns {
+			if sign, ok := e.signAt(row); ok {
+				screen.SetContent(textX, textY, sign.Glyph, nil, sign.Style)
 			}
-			if e.cursor[0] == row {
-				lineNumber = row + 1
+			textX += signWidth
+		}
+
+		// print line numbers
+		if showLineNumber {
+			lineNumber := row + 1
+			if e.options.RelativeNumber && (row != e.cursor[0] || !e.options.Number) {
+				lineNumber = row - e.cursor[0]
+				if lineNumber < 0 {
+					lineNumber *= -1
+				}
 			}
 			lineNumberText := fmt.Sprintf("%*d", lineNumberDigit, lineNumber)
-			lineNumberColor := tcell.ColorSlateGray
+			lineNumberColor := theme.Current().LineNumber
 			if e.HasFocus() && row == e.cursor[0] {
-				lineNumberColor = tcell.ColorOrange
+				lineNumberColor = theme.Current().CurrentLineNumber
 			}
-			tview.Print(screen, lineNumberText, x, textY, lineNumberWidth, tview.AlignLeft, lineNumberColor)
+			tview.Print(screen, lineNumberText, textX, textY, lineNumberWidth, tview.AlignLeft, lineNumberColor)
 			textX += lineNumberWidth
 		}
 
 		for col, span := range spans {
+			isCursor := row == e.cursor[0] && col == e.cursor[1]
+			// continue on the next screen row, the end of line sentinel only for the cursor
+			if wrap && (span.runes != nil || isCursor) && wrapsBefore(span, textX, x+gutterWidth, x+w) {
+				textY++
+				textX = x + gutterWidth
+				if textY >= y+h {
+					break
+				}
+				if e.HasFocus() && row == e.cursor[0] {
+					for i := range w {
+						screen.SetContent(x+i, textY, ' ', nil, tcell.StyleDefault.Background(theme.Current().CursorLine).Foreground(tview.Styles.PrimaryTextColor))
+					}
+				}
+			}
+			if wrap && isCursor {
+				cursorScreen = [2]int{textX, textY}
+			}
 			// draw end of line sentinel decoration if exist, else can break
 			if span.runes == nil && col > 0 {
 				d, hasDecoration := e.decorations[[2]int{row, col}]
@@ -913,7 +1431,7 @@ func (e *Editor) Draw(screen tcell.Screen) {
 				if bg == tcell.ColorDefault {
 					d.style = d.style.Background(tview.Styles.PrimitiveBackgroundColor)
 					if e.HasFocus() && e.cursor[0] == row {
-						d.style = d.style.Background(tcell.ColorGray)
+						d.style = d.style.Background(theme.Current().CursorLine)
 					}
 				}
 				screen.SetContent(
@@ -958,7 +1476,7 @@ func (e *Editor) Draw(screen tcell.Screen) {
 			if hasDecoration {
 				_, bg, _ := d.style.Decompose()
 				if e.HasFocus() && !e.oneLineMode && row == e.cursor[0] && bg == tcell.ColorDefault {
-					bg = tcell.ColorGray
+					bg = theme.Current().CursorLine
 				}
 				for i := range span.width {
 					screen.SetContent(
@@ -976,14 +1494,14 @@ func (e *Editor) Draw(screen tcell.Screen) {
 				dBg := tcell.ColorDefault
 				style := tcell.StyleDefault.Background(tview.Styles.PrimitiveBackgroundColor).Foreground(tview.Styles.PrimaryTextColor)
 				if hasDecoration && d.text == "" {
-					_, dBg, _ := d.style.Decompose()
+					_, dBg, _ = d.style.Decompose()
 					style = d.style
 					if dBg == tcell.ColorDefault {
 						style = style.Background(tview.Styles.PrimitiveBackgroundColor)
 					}
 				}
 				if e.HasFocus() && !e.oneLineMode && row == e.cursor[0] && dBg == tcell.ColorDefault {
-					style = style.Background(tcell.ColorGray)
+					style = style.Background(theme.Current().CursorLine)
 				}
 
 				if runes != nil && runes[0] != '\t' {
@@ -995,9 +1513,14 @@ func (e *Editor) Draw(screen tcell.Screen) {
 						style,
 					)
 				} else {
-					for i := range e.tabSize {
+					for i := range width {
+						// skip tab cells hidden behind the line numbers or on the right edge
+						tabX := textX - e.offsets[1] + i
+						if tabX < x+gutterWidth || tabX >= x+w {
+							continue
+						}
 						screen.SetContent(
-							textX-e.offsets[1]+i,
+							tabX,
 							textY,
 							' ',
 							nil,
@@ -1020,7 +1543,7 @@ func (e *Editor) Draw(screen tcell.Screen) {
 
 	// draw cursor
 	if e.HasFocus() && e.searchEditor == nil {
-		newCursor := [2]int{cursorX + x + lineNumberWidth - e.offsets[1], e.cursor[0] + y - e.offsets[0]}
+		newCursor := cursorScreen
 		cursorStyle := tcell.CursorStyleSteadyBlock
 		if e.mode == ModeInsert {
 			cursorStyle = tcell.CursorStyleSteadyBar
@@ -1047,11 +1570,37 @@ func (e *Editor) SetDisabled(b bool) {
 	e.disabled = b
 }
 
+// SetReadOnly allows navigation, search, and yank, but rejects every edit.
+func (e *Editor) SetReadOnly(b bool) *Editor {
+	e.readOnly = b
+	if b && (e.mode == ModeInsert || e.mode == ModeReplace) {
+		e.ChangeMode(ModeNormal)
+	}
+	return e
+}
+
+func (e *Editor) IsReadOnly() bool {
+	return e.readOnly
+}
+
 func (e *Editor) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
 	return e.Box.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
 		if e.disabled {
 			return
 		}
+		// a key waits for the spans of a large text still measured in the background
+		e.ensureSpans()
+
+		// any other key accepts the completion
+		if event.Key() != tcell.KeyTab && event.Key() != tcell.KeyBacktab {
+			e.completions = nil
+		}
+
+		// esc stops confirming a substitution right away
+		if e.substitution != nil && e.searchEditor != nil && (event.Key() == tcell.KeyEsc || event.Key() == tcell.KeyCtrlC) {
+			e.searchEditor.Exit()
+			return
+		}
 
 		// embedded search editor is not null, send input event to it
 		if e.searchEditor != nil {
@@ -1059,6 +1608,39 @@ func (e *Editor) InputHandler() func(event *tcell.EventKey, setFocus func(p tvie
 			return
 		}
 
+		// esc and ctrl-c cancel whatever is pending before they're mapped to anything else
+		if key := event.Key(); key == tcell.KeyEsc || key == tcell.KeyCtrlC {
+			if e.hasPending() {
+				e.CancelPending()
+				return
+			}
+			if key == tcell.KeyCtrlC {
+				e.interrupt()
+				return
+			}
+		}
+
+		// the rune after " selects the register for the next action
+		if e.waitingForRegister {
+			e.waitingForRegister = false
+			if event.Key() == tcell.KeyRune && register.Valid(event.Rune()) {
+				e.register = event.Rune()
+			}
+			return
+		}
+
+		// up and down go through the prompt history
+		if e.oneLineMode && e.history != nil && !e.hasPending() && (e.mode == ModeInsert || e.mode == ModeNormal) {
+			switch event.Key() {
+			case tcell.KeyUp, tcell.KeyCtrlP:
+				e.recall(-1)
+				return
+			case tcell.KeyDown, tcell.KeyCtrlN:
+				e.recall(1)
+				return
+			}
+		}
+
 		// handle unkeymappable actions first, e.g. rune events on insert mode
 		switch e.mode {
 		case ModeReplace:
@@ -1078,34 +1660,42 @@ func (e *Editor) InputHandler() func(event *tcell.EventKey, setFocus func(p tvie
 		case ModeInsert:
 			switch key := event.Key(); key {
 			case tcell.KeyEsc:
-				e.mode = ModeNormal
-				if e.cursor[1] == len(e.spansPerLines[e.cursor[0]])-1 {
-					e.MoveCursorLeft()
-				}
+				e.leaveInsert()
 				return
 			case tcell.KeyRune:
 				text := string(event.Rune())
+				e.Begin()
 				e.ReplaceText(text, e.cursor, e.cursor)
 				e.MoveCursorRight()
-				e.SaveChanges()
-				e.undoOffset--
+				e.Commit()
 				return
 			case tcell.KeyEnter:
 				if e.oneLineMode && e.onDoneFunc != nil {
-					e.onDoneFunc(e, e.text)
+					e.submit(e.text)
 					return
 				}
+				e.Begin()
 				e.ReplaceText("\n", e.cursor, e.cursor)
 				e.MoveCursorDown()
 				e.cursor[1] = 0
-				e.SaveChanges()
-				e.undoOffset--
+				e.Commit()
 				return
-			case tcell.KeyTab:
+			case tcell.KeyTab, tcell.KeyBacktab:
+				if e.oneLineMode && e.completeFunc != nil {
+					if key == tcell.KeyTab {
+						e.complete(1)
+					} else {
+						e.complete(-1)
+					}
+					return
+				}
+				if key == tcell.KeyBacktab {
+					return
+				}
+				e.Begin()
 				e.ReplaceText("\t", e.cursor, e.cursor)
 				e.MoveCursorRight()
-				e.SaveChanges()
-				e.undoOffset--
+				e.Commit()
 				return
 			case tcell.KeyBackspace, tcell.KeyBackspace2:
 				if e.cursor[0] == 0 && e.cursor[1] == 0 {
@@ -1119,10 +1709,10 @@ func (e *Editor) InputHandler() func(event *tcell.EventKey, setFocus func(p tvie
 					from = [2]int{aboveRow, len(e.spansPerLines[aboveRow]) - 1}
 					until = [2]int{e.cursor[0], 0}
 				}
+				e.Begin()
 				e.ReplaceText("", from, until)
 				e.cursor = from
-				e.SaveChanges()
-				e.undoOffset--
+				e.Commit()
 				return
 			}
 		}
@@ -1130,12 +1720,7 @@ func (e *Editor) InputHandler() func(event *tcell.EventKey, setFocus func(p tvie
 		isDigit := event.Key() == tcell.KeyRune && unicode.IsDigit(event.Rune())
 
 		// append to pending
-		eventName := event.Name()
-		if event.Key() == tcell.KeyRune {
-			eventName = string(event.Rune())
-		} else {
-			eventName = strings.ToLower(eventName)
-		}
+		eventName := keymap.EventName(event)
 		e.pending = append(e.pending, eventName)
 		log.Printf("event name: %s\n", eventName)
 		log.Printf("event key: %d\n", event.Key())
@@ -1146,6 +1731,22 @@ func (e *Editor) InputHandler() func(event *tcell.EventKey, setFocus func(p tvie
 			group = "o" + e.mode.ShortString()
 		}
 
+		// expand macros into their keys
+		if macro := e.keymapper.Macro(e.pending, group); macro != nil {
+			// the selected register applies to the macro keys
+			register := e.register
+			e.ResetAction()
+			e.register = register
+			if e.macroDepth < keymap.MaxMacroDepth {
+				e.macroDepth++
+				for _, key := range macro {
+					e.InputHandler()(keymap.Event(key), setFocus)
+				}
+				e.macroDepth--
+			}
+			return
+		}
+
 		// parse action first try
 		actionStrings, anyStartWith := e.keymapper.Get(e.pending, group)
 		if actionStrings == nil {
@@ -1166,6 +1767,10 @@ func (e *Editor) InputHandler() func(event *tcell.EventKey, setFocus func(p tvie
 						break
 					}
 				}
+				// wait for the rest of a motion of several keys, e.g. dgg
+				if action == ActionNone {
+					anyStartWith = anyStartWith || anyStartWith2
+				}
 			}
 
 			// if waitingForMotion is true but the event is not a rune event, reset the action state
@@ -1179,9 +1784,21 @@ func (e *Editor) InputHandler() func(event *tcell.EventKey, setFocus func(p tvie
 				action = e.lastMotion
 			}
 
+			// reject edits in read-only mode
+			if e.readOnly && action.IsEdit() {
+				if e.statusFunc != nil {
+					e.statusFunc("editor is read-only")
+				}
+				if e.mode == ModeVisual || e.mode == ModeVLine || e.mode == ModeVBlock {
+					e.ChangeMode(ModeNormal)
+				}
+				e.ResetAction()
+				return
+			}
+
 			// handle operators actions
 			// no need to wait for motion action in ModeVisual mode
-			if action.IsOperator() && (e.mode == ModeVisual || e.mode == ModeVLine) && action != ActionVisual && action != ActionVisualLine {
+			if action.IsOperator() && (e.mode == ModeVisual || e.mode == ModeVLine || e.mode == ModeVBlock) && action != ActionVisual && action != ActionVisualLine {
 				prevMode := e.mode
 
 				if e.mode == ModeVLine {
@@ -1202,6 +1819,9 @@ func (e *Editor) InputHandler() func(event *tcell.EventKey, setFocus func(p tvie
 			// save operator action in pendingAction, wait for the next motion action
 			if action.IsOperator() {
 				e.pendingAction = action
+				// the count typed before the operator multiplies the count of its motion, e.g. 2d3w deletes 6 words
+				e.operatorCount, e.pendingCount = e.pendingCount, 0
+				e.operatorKeys = len(e.pending)
 				return
 			}
 
@@ -1209,11 +1829,16 @@ func (e *Editor) InputHandler() func(event *tcell.EventKey, setFocus func(p tvie
 			// ignore countless motion (e.g. start of line motion) if pending count is not zero
 			if action.IsMotion() && (!action.IsCountlessMotion() || e.pendingCount == 0) &&
 				e.motionRunner[action] != nil && (action.IsOperatorlessMotion() || e.pendingAction != ActionNone) {
+				e.motion = action
 				m := e.motionRunner[action]()
 				if vim.IsAsyncMotion(m) {
 					e.lastMotion = action
 					return
 				}
+				if vim.IsFailedMotion(m) {
+					e.ResetAction()
+					return
+				}
 
 				e.operatorRunner[e.pendingAction](m)
 				e.ResetAction()
@@ -1222,6 +1847,7 @@ func (e *Editor) InputHandler() func(event *tcell.EventKey, setFocus func(p tvie
 
 			// handle the other action
 			if e.actionRunner[action] != nil {
+				e.actionArgs = e.keymapper.Args(e.pending, group, actionString)
 				e.actionRunner[action]()
 				e.ResetAction()
 				return
@@ -1245,19 +1871,26 @@ func (e *Editor) InputHandler() func(event *tcell.EventKey, setFocus func(p tvie
 }
 
 func (e *Editor) getActionCount() int {
-	n := 1 + e.pendingCount
-	if e.pendingCount > 0 {
-		n--
+	return max(e.typedCount(), 1)
+}
+
+// typedCount returns the count typed for the action, 0 if there's none.
+// A count before the operator multiplies the count after it.
+func (e *Editor) typedCount() int {
+	if e.operatorCount == 0 {
+		return e.pendingCount
 	}
-	return n
+	return e.operatorCount * max(e.pendingCount, 1)
 }
 
 func (e *Editor) MoveCursorTo(to [2]int) {
+	e.ensureSpans()
 	e.cursor = to
 	e.MoveCursorToLine(e.cursor[0])
 }
 
 func (e *Editor) GetNextMotionCursor(m rune, n int, cursor [2]int, inclusive bool) ([2]int, bool) {
+	e.ensureMotionIndexes(m)
 	if e.motionIndexes[m] == nil {
 		return cursor, false
 	}
@@ -1298,6 +1931,7 @@ func (e *Editor) GetNextMotionCursor(m rune, n int, cursor [2]int, inclusive boo
 
 // n must be greater or equal to 1
 func (e *Editor) GetPrevMotionCursor(m rune, n int, cursor [2]int, inclusive bool) ([2]int, bool) {
+	e.ensureMotionIndexes(m)
 	if e.motionIndexes[m] == nil {
 		return cursor, false
 	}
@@ -1370,11 +2004,16 @@ func (e *Editor) MoveCursorEndOfLine() {
 }
 
 func (e *Editor) GetEndOfLineCursor() [2]int {
-	if e.cursor[1] >= len(e.spansPerLines[e.cursor[0]])-1 {
+	// a count goes to the end of the line count - 1 lines below
+	row := min(e.cursor[0]+e.getActionCount()-1, len(e.spansPerLines)-1)
+	if row == e.cursor[0] && e.cursor[1] >= len(e.spansPerLines[e.cursor[0]])-1 {
+		e.setDesiredColumn(e.cursor, math.MaxInt)
 		return e.cursor
 	}
 
-	return [2]int{e.cursor[0], len(e.spansPerLines[e.cursor[0]]) - 1}
+	c := [2]int{row, len(e.spansPerLines[row]) - 1}
+	e.setDesiredColumn(c, math.MaxInt)
+	return c
 }
 
 func (e *Editor) MoveCursorLeft() {
@@ -1416,47 +2055,28 @@ func (e *Editor) GetDownCursor() [2]int {
 }
 
 func (e *Editor) MoveCursorHalfPageDown() {
+	_, _, _, h := e.Box.GetInnerRect()
+	e.scrollLines((h - 1) / 2)
+}
+
+// MoveCursorPageDown scrolls a page forward keeping two lines of context, like ctrl-f.
+func (e *Editor) MoveCursorPageDown() {
+	_, _, _, h := e.Box.GetInnerRect()
+	e.scrollLines(max(h-1-2, 1))
+}
+
+// scrollLines moves the cursor n lines down, or up if n is negative, scrolling the view along
+// so the cursor keeps its distance from the top.
+func (e *Editor) scrollLines(n int) {
 	_, _, _, h := e.Box.GetInnerRect()
 	h-- // exclude status line
 
-	if e.cursor[0] >= len(e.spansPerLines)-1 {
+	if n > 0 && e.cursor[0] >= len(e.spansPerLines)-1 || n < 0 && e.cursor[0] < 1 {
 		return
 	}
 
-	currentRowWidth := 0
-	for _, span := range e.spansPerLines[e.cursor[0]][:e.cursor[1]] {
-		currentRowWidth += span.width
-	}
-
-	blockOffset := 0
-	if e.mode == ModeInsert {
-		blockOffset = 1
-	}
-	halfPageDownRowX := 0
-	halfPageDownRowWidth := 0
-	halfPageDownIdx := e.cursor[0] + h/2
-	if halfPageDownIdx > len(e.spansPerLines)-1 {
-		halfPageDownIdx = len(e.spansPerLines) - 1
-	}
-	halfPageDownRowSpans := e.spansPerLines[halfPageDownIdx]
-	maxOffset := len(halfPageDownRowSpans) - 2 + blockOffset
-	if maxOffset < 0 {
-		maxOffset = 0
-	}
-	for _, span := range halfPageDownRowSpans[:maxOffset] {
-		if span.runes == nil {
-			break
-		}
-		if halfPageDownRowWidth+span.width > currentRowWidth {
-			break
-		}
-		halfPageDownRowX++
-		halfPageDownRowWidth += span.width
-	}
-
 	distanceFromTop := e.cursor[0] - e.offsets[0]
-	e.cursor[0] = halfPageDownIdx
-	e.cursor[1] = halfPageDownRowX
+	e.cursor = e.GetLineCursor(max(min(e.cursor[0]+n, len(e.spansPerLines)-1), 0))
 
 	newRowOffset := e.cursor[0] - distanceFromTop
 	if newRowOffset > len(e.spansPerLines)-h {
@@ -1472,15 +2092,15 @@ func (e *Editor) MoveCursorLastLine() {
 }
 
 func (e *Editor) GetLastLineCursor() [2]int {
-	if e.pendingCount > 0 {
-		return e.GetLineCursor(e.pendingCount - 1)
+	if n := e.typedCount(); n > 0 {
+		return e.GetLineCursor(n - 1)
 	}
 	return e.GetLineCursor(len(e.spansPerLines) - 1)
 }
 
 func (e *Editor) GetFirstLineCursor() [2]int {
-	if e.pendingCount > 0 {
-		return e.GetLineCursor(e.pendingCount - 1)
+	if n := e.typedCount(); n > 0 {
+		return e.GetLineCursor(n - 1)
 	}
 	return e.GetLineCursor(0)
 }
@@ -1496,54 +2116,13 @@ func (e *Editor) GetUpCursor() [2]int {
 
 func (e *Editor) MoveCursorHalfPageUp() {
 	_, _, _, h := e.Box.GetInnerRect()
-	h-- // exclude status line
-
-	if e.cursor[0] < 1 {
-		return
-	}
-
-	currentRowWidth := 0
-	for _, span := range e.spansPerLines[e.cursor[0]][:e.cursor[1]] {
-		currentRowWidth += span.width
-	}
-
-	blockOffset := 0
-	if e.mode == ModeInsert {
-		blockOffset = 1
-	}
-	halfPageUpRowX := 0
-	halfPageUpRowWidth := 0
-	halfPageUpIdx := e.cursor[0] - h/2
-	if halfPageUpIdx < 0 {
-		halfPageUpIdx = 0
-	}
-	halfPageUpRowSpans := e.spansPerLines[halfPageUpIdx]
-	maxOffset := len(halfPageUpRowSpans) - 2 + blockOffset
-	if maxOffset < 0 {
-		maxOffset = 0
-	}
-	for _, span := range halfPageUpRowSpans[:maxOffset] {
-		if span.runes == nil {
-			break
-		}
-		if halfPageUpRowWidth+span.width > currentRowWidth {
-			break
-		}
-		halfPageUpRowX++
-		halfPageUpRowWidth += span.width
-	}
-
-	distanceFromTop := e.cursor[0] - e.offsets[0]
-	e.cursor[0] = halfPageUpIdx
-	e.cursor[1] = halfPageUpRowX
+	e.scrollLines(-(h - 1) / 2)
+}
 
-	newRowOffset := e.cursor[0] - distanceFromTop
-	if newRowOffset > len(e.spansPerLines)-h {
-		newRowOffset = len(e.spansPerLines) - h
-	} else if newRowOffset < 0 {
-		newRowOffset = 0
-	}
-	e.offsets[0] = newRowOffset
+// MoveCursorPageUp scrolls a page backward keeping two lines of context, like ctrl-b.
+func (e *Editor) MoveCursorPageUp() {
+	_, _, _, h := e.Box.GetInnerRect()
+	e.scrollLines(-max(h-1-2, 1))
 }
 
 func (e *Editor) MoveCursorToLine(n int) {
@@ -1551,6 +2130,7 @@ func (e *Editor) MoveCursorToLine(n int) {
 }
 
 func (e *Editor) GetLineCursor(n int) [2]int {
+	e.ensureSpans()
 	if n < 0 {
 		n = 0
 	}
@@ -1558,13 +2138,10 @@ func (e *Editor) GetLineCursor(n int) [2]int {
 		n = len(e.spansPerLines) - 1
 	}
 
-	currentRowWidth := 0
-	for _, span := range e.spansPerLines[e.cursor[0]][:e.cursor[1]] {
-		currentRowWidth += span.width
-	}
+	currentRowWidth := e.desiredWidth()
 
 	blockOffset := 0
-	if e.mode == ModeInsert || e.mode == ModeVLine || e.mode == ModeVisual || e.pendingAction == ActionVisual || e.pendingAction == ActionVisualLine {
+	if e.mode == ModeInsert || e.mode == ModeVLine || e.mode == ModeVisual || e.mode == ModeVBlock || e.pendingAction == ActionVisual || e.pendingAction == ActionVisualLine {
 		blockOffset = 1
 	}
 	targetRowX := 0
@@ -1585,10 +2162,34 @@ func (e *Editor) GetLineCursor(n int) [2]int {
 		targetRowWidth += span.width
 	}
 
-	return [2]int{n, targetRowX}
+	c := [2]int{n, targetRowX}
+	e.setDesiredColumn(c, currentRowWidth)
+	return c
+}
+
+// desiredWidth returns the screen column vertical motions aim for: the one kept by the last vertical motion
+// if the cursor is still where it moved, otherwise the cursor column.
+func (e *Editor) desiredWidth() int {
+	if e.cursor == e.desiredCursor {
+		return e.desiredColumn
+	}
+
+	width := 0
+	for _, span := range e.spansPerLines[e.cursor[0]][:e.cursor[1]] {
+		width += span.width
+	}
+	return width
+}
+
+// setDesiredColumn keeps the column aimed for at the cursor, until the cursor moves elsewhere.
+// math.MaxInt aims for the end of the lines, like after $.
+func (e *Editor) setDesiredColumn(cursor [2]int, width int) {
+	e.desiredCursor = cursor
+	e.desiredColumn = width
 }
 
 func (e *Editor) ReplaceText(s string, from, until [2]int) {
+	e.ensureSpans()
 	if from[0] > until[0] || from[0] == until[0] && from[1] > until[1] {
 		from, until = until, from
 	}
@@ -1628,11 +2229,21 @@ func (e *Editor) ReplaceText(s string, from, until [2]int) {
 		}
 	}
 
-	e.SaveChanges()
+	change := Change{
+		From:    from,
+		Until:   until,
+		OldText: e.text[e.byteOffset(from):e.byteOffset(until)],
+		NewText: s,
+	}
+
+	e.Begin()
 	e.SetText(b.String(), from)
+	e.notifyChange(change)
+	e.Commit()
 }
 
 func (e *Editor) GetText(from, until [2]int) string {
+	e.ensureSpans()
 	if from[0] > until[0] || from[0] == until[0] && from[1] > until[1] {
 		from, until = until, from
 	}
@@ -1659,65 +2270,111 @@ func (e *Editor) GetText(from, until [2]int) string {
 	return b.String()
 }
 
-func (e *Editor) SaveChanges() {
-	maxUndoOffset := e.undoOffset + 1
-	if maxUndoOffset > len(e.undoStack) {
-		maxUndoOffset = len(e.undoStack)
-	}
-	e.undoStack = e.undoStack[:maxUndoOffset]
-	e.undoStack = append(e.undoStack, undoStackItem{
-		text:   e.text,
-		cursor: [2]int{e.cursor[0], e.cursor[1]},
-	})
-	e.undoOffset = maxUndoOffset
-}
-
 func (e *Editor) Done() {
 	if e.onDoneFunc == nil {
 		return
 	}
 
-	e.onDoneFunc(e, e.text)
+	if e.oneLineMode {
+		e.submit(e.TrimWhitespace())
+		return
+	}
+	e.onDoneFunc(e, e.TrimWhitespace())
 }
 
-func (e *Editor) Exit() {
-	if e.onExitFunc == nil {
-		return
+// TrimWhitespace strips the trailing whitespace of the lines and keeps a single trailing newline
+// when the trimwhitespace option is set, as one undo step. It returns the text.
+func (e *Editor) TrimWhitespace() string {
+	e.ensureSpans()
+	if !e.options.TrimWhitespace {
+		return e.text
+	}
+	text := trimWhitespace(e.text)
+	if text == e.text || e.readOnly {
+		return text
 	}
 
-	e.onExitFunc()
+	// keep the cursor inside the trimmed text
+	lines := strings.Split(text, "\n")
+	row := min(e.cursor[0], len(lines)-1)
+	cursor := [2]int{row, min(e.cursor[1], uniseg.GraphemeClusterCount(lines[row]))}
+
+	e.Begin()
+	e.SetTextAndNotify(text, cursor)
+	e.Commit()
+	return e.text
 }
 
-func (e *Editor) Redo() {
-	if len(e.undoStack) < 1 {
-		return
+// trimWhitespace strips the trailing whitespace of the lines and the trailing blank lines,
+// ending a non empty text with a single newline.
+func trimWhitespace(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, unicode.IsSpace)
 	}
-	if e.undoOffset+2 > len(e.undoStack)-1 {
-		return
+	text = strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	if text == "" {
+		return ""
 	}
-	n := e.getActionCount() + e.undoOffset + 1
-	if n > len(e.undoStack)-1 {
-		n = len(e.undoStack) - 1
+	return text + "\n"
+}
+
+func (e *Editor) Exit() {
+	if e.onExitFunc == nil {
+		return
 	}
-	redo := e.undoStack[n]
-	e.undoOffset = n - 1
-	e.SetText(redo.text, redo.cursor)
+
+	e.onExitFunc()
 }
 
 func (e *Editor) EnableSearch() [2]int {
-	x, y, w, h := e.Box.GetInnerRect()
-	se := New(WithKeymapper(e.keymapper)).SetOneLineMode(true)
-	se.SetText("", [2]int{0, 0})
-	se.SetRect(x, y+h-1, w, 1)
+	return e.enableSearch(false)
+}
+
+// EnableBackSearch is the ? search, it moves to the previous match and reverses n and N.
+func (e *Editor) EnableBackSearch() [2]int {
+	return e.enableSearch(true)
+}
+
+// enableSearch shows the search prompt. The search is incremental, the matches of the typed text are highlighted
+// and the cursor previews the match it moves to, going back to where it was when the search is cancelled.
+func (e *Editor) enableSearch(backward bool) [2]int {
+	prompt := "/"
+	if backward {
+		prompt = "?"
+	}
+	origin, originBackward, originMatches := e.cursor, e.searchBackward, e.motionIndexes['n']
+	se := NewPrompt(prompt, WithKeymapper(e.keymapper), WithHistory(e.searchHistory))
+	SetPromptRect(se, e.Box)
 	se.SetDelayDrawFunc(e.delayDrawFunc)
-	se.mode = ModeInsert
+	se.onTextChangedFunc = func(s string) {
+		e.cursor = origin
+		if s == "" {
+			e.motionIndexes['n'] = originMatches
+			e.updateSearchSigns()
+			return
+		}
+		e.buildSearchIndexes('n', e.searchQuery(s), 0, 0, 0)
+		e.updateSearchSigns()
+		e.searchBackward = backward
+		if matches := e.motionIndexes['n']; len(matches) > 0 {
+			idx, wrapped := e.searchMatch(e.searchDirection())
+			e.searchWrapped = wrapped
+			e.cursor = [2]int{matches[idx][0], matches[idx][1]}
+		}
+	}
 	se.onDoneFunc = func(_ *Editor, s string) {
-		e.buildSearchIndexes('n', regexp.QuoteMeta(s), 0, 0, 0)
+		e.cursor = origin
+		e.buildSearchIndexes('n', e.searchQuery(s), 0, 0, 0)
+		e.updateSearchSigns()
+		e.searchBackward = backward
 		e.operatorRunner[e.pendingAction](e.GetSearchCursor())
 		e.searchEditor = nil
 		e.ResetAction()
 	}
 	se.onExitFunc = func() {
+		e.cursor, e.searchBackward, e.motionIndexes['n'] = origin, originBackward, originMatches
+		e.updateSearchSigns()
 		e.searchEditor = nil
 		e.ResetAction()
 	}
@@ -1726,46 +2383,87 @@ func (e *Editor) EnableSearch() [2]int {
 	return vim.AsyncMotion
 }
 
+// EnableCommand shows the command line on the bottom line, the command is passed to the command handler once it's done.
+// A substitute command is run by the editor. From the visual modes, the command line starts with the '<,'> range
+// of the selected lines.
+func (e *Editor) EnableCommand() {
+	se := NewPrompt(":", WithKeymapper(e.keymapper), WithHistory(e.commandHistory), WithCompleteFunc(e.completeFunc))
+	SetPromptRect(se, e.Box)
+	se.SetDelayDrawFunc(e.delayDrawFunc)
+	if isVisual(e.mode) {
+		e.ChangeMode(ModeNormal)
+		se.SetText("'<,'>", [2]int{0, 5})
+	}
+	se.onDoneFunc = func(_ *Editor, s string) {
+		e.searchEditor = nil
+		e.ResetAction()
+		sub, ok, err := e.parseSubstitute(strings.TrimSpace(s))
+		if ok {
+			if err == nil {
+				err = e.substitute(sub)
+			}
+			if err != nil && e.statusFunc != nil {
+				e.statusFunc(err.Error())
+			}
+			return
+		}
+		if e.commandFunc != nil && strings.TrimSpace(s) != "" {
+			e.commandFunc(strings.TrimSpace(s))
+		}
+	}
+	se.onExitFunc = func() {
+		e.searchEditor = nil
+		e.ResetAction()
+	}
+	e.searchEditor = se
+}
+
 func (e *Editor) Flash() [2]int {
-	x, y, w, h := e.Box.GetInnerRect()
-	se := New(WithKeymapper(e.keymapper)).SetOneLineMode(true)
-	se.SetText("", [2]int{0, 0})
-	se.SetRect(x, y+h-1, w, 1)
+	_, _, _, h := e.Box.GetInnerRect()
+	se := NewPrompt("flash: ", WithKeymapper(e.keymapper))
+	SetPromptRect(se, e.Box)
 	se.SetDelayDrawFunc(e.delayDrawFunc)
-	se.mode = ModeInsert
 	se.onDoneFunc = func(_ *Editor, s string) {
 		e.searchEditor = nil
 		e.ResetAction()
-		e.flashIndexes = make(map[rune][2]int)
-		e.reverseFlashIndexes = make(map[[2]int]rune)
-		e.motionIndexes['Z'] = nil
+		e.resetFlash()
 	}
 	se.onTextChangedFunc = func(s string) {
 		if len(s) < 1 {
-			e.flashIndexes = make(map[rune][2]int)
-			e.reverseFlashIndexes = make(map[[2]int]rune)
-			e.motionIndexes['Z'] = nil
+			e.resetFlash()
 			return
 		}
 
-		if e.flashIndexes != nil && len(s) > e.flashIndexes['#'][0] {
+		if e.flashIndexes != nil && len(s) > e.flashQueryLen {
 			runes := []rune(s)
-			r := runes[len(runes)-1]
-			flash, hasFlash := e.flashIndexes[r]
-			if hasFlash {
+			label := e.flashPrefix + string(runes[len(runes)-1])
+			if flash, hasFlash := e.flashIndexes[label]; hasFlash {
 				e.operatorRunner[e.pendingAction](flash)
 				e.searchEditor = nil
 				e.ResetAction()
-				e.flashIndexes = make(map[rune][2]int)
-				e.reverseFlashIndexes = make(map[[2]int]rune)
-				e.motionIndexes['Z'] = nil
+				e.resetFlash()
+				return
+			}
+			// the first character of a two character label waits for the second one,
+			// anything else after it ends the flash
+			if e.flashPrefix == "" && e.hasFlashPrefix(label) {
+				e.flashPrefix = label
+				e.flashQueryLen = len(s)
+				return
+			}
+			if e.flashPrefix != "" {
+				e.searchEditor = nil
+				e.ResetAction()
+				e.resetFlash()
+				return
 			}
 		}
 
-		e.flashIndexes = make(map[rune][2]int)
-		e.reverseFlashIndexes = make(map[[2]int]rune)
+		e.flashIndexes = make(map[string][2]int)
+		e.reverseFlashIndexes = make(map[[2]int]string)
+		e.flashPrefix = ""
 		// record last flash query len
-		e.flashIndexes['#'] = [2]int{len(s), 0}
+		e.flashQueryLen = len(s)
 		e.buildSearchIndexes('Z', regexp.QuoteMeta(s), 0, e.offsets[0], e.offsets[0]+h-1)
 		if e.motionIndexes['Z'] == nil {
 			return
@@ -1801,34 +2499,45 @@ func (e *Editor) Flash() [2]int {
 			return xDistance1+yDistance1 < xDistance2+yDistance2
 		})
 
-		i := 0
-		for _, r := range flashAlphabet {
-			if i > len(flashIndexesClosestCursor)-1 {
-				break
-			}
+		labels := flash.Labels(e.flashOptions.Alphabet, e.flashOptions.MultiChar, len(flashIndexesClosestCursor), func(r rune) bool {
 			_, invalid := invalidFlash[r]
-			if invalid {
-				continue
-			}
-
+			return invalid
+		})
+		for i, label := range labels {
 			c := [2]int{flashIndexesClosestCursor[i][0], flashIndexesClosestCursor[i][1]}
-			e.flashIndexes[r] = c
-			e.reverseFlashIndexes[c] = r
-			i++
+			e.flashIndexes[label] = c
+			e.reverseFlashIndexes[c] = label
 		}
 	}
 	se.onExitFunc = func() {
 		e.searchEditor = nil
 		e.ResetAction()
-		e.flashIndexes = make(map[rune][2]int)
-		e.reverseFlashIndexes = make(map[[2]int]rune)
-		e.motionIndexes['Z'] = nil
+		e.resetFlash()
 	}
 	e.searchEditor = se
 	e.waitingForMotion = true
 	return vim.AsyncMotion
 }
 
+// resetFlash clears the flash matches and labels.
+func (e *Editor) resetFlash() {
+	e.flashIndexes = make(map[string][2]int)
+	e.reverseFlashIndexes = make(map[[2]int]string)
+	e.flashPrefix = ""
+	e.flashQueryLen = 0
+	e.motionIndexes['Z'] = nil
+}
+
+// hasFlashPrefix reports whether a two character label starts with the typed character.
+func (e *Editor) hasFlashPrefix(prefix string) bool {
+	for label := range e.flashIndexes {
+		if len(label) > len(prefix) && strings.HasPrefix(label, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *Editor) WaitingForMotion() [2]int {
 	e.waitingForMotion = true
 	return vim.AsyncMotion
@@ -1855,6 +2564,12 @@ func (e *Editor) AcceptRuneAround(r rune) {
 }
 
 func (e *Editor) buildSurroundIndexes(r rune, inside bool) {
+	e.linewiseObject = r == 'p'
+	if r == 'p' {
+		e.buildParagraphIndexes(inside)
+		return
+	}
+
 	if r == 'w' {
 		openingCursor, foundOpening := e.GetPrevMotionCursor('w', 1, e.cursor, true)
 		closingCursor, foundClosing := e.GetNextMotionCursor('e', 1, e.cursor, true)
@@ -1955,7 +2670,11 @@ func (e *Editor) buildSurroundIndexes(r rune, inside bool) {
 	}
 }
 
+// ChangeMode switches to the mode, leaving a visual mode keeps the selected rows for '<,'>.
 func (e *Editor) ChangeMode(m mode) {
+	if isVisual(e.mode) && !isVisual(m) {
+		e.lastVisual = &[2]int{min(e.visualStart[0], e.cursor[0]), max(e.visualStart[0], e.cursor[0])}
+	}
 	e.mode = m
 }
 
@@ -1965,81 +2684,196 @@ func (e *Editor) DeleteUnderCursor() {
 		n = len(e.spansPerLines[e.cursor[0]]) - 1
 	}
 	until := [2]int{e.cursor[0], n}
-	e.ReplaceText("", e.cursor, until)
-}
-
-func (e *Editor) Undo() {
-	if len(e.undoStack) < 1 {
-		return
-	}
-	if e.undoOffset < 0 {
+	if until == e.cursor {
 		return
 	}
-	n := e.undoOffset - e.getActionCount() + 1
-	if n < 0 {
-		n = 0
-	}
-	undo := e.undoStack[n]
-	e.undoOffset = n - 1
-	e.SetText(undo.text, undo.cursor)
+	register.Delete(e.selectedRegister(), e.textBefore(e.cursor, until), register.Charwise)
+	e.ReplaceText("", e.cursor, until)
 }
 
 func (e *Editor) InsertBelow() {
 	e.MoveCursorEndOfLine()
 	e.cursor[1]++
+	e.Begin()
 	e.ReplaceText("\n", e.cursor, e.cursor)
 	e.MoveCursorDown()
 	e.cursor[1] = 0
-	e.SaveChanges()
-	e.undoOffset--
+	e.Commit()
 	e.mode = ModeInsert
 }
 
 func (e *Editor) InsertAbove() {
 	e.MoveCursorStartOfLine()
+	e.Begin()
 	e.ReplaceText("\n", e.cursor, e.cursor)
 	e.cursor[1] = 0
-	e.SaveChanges()
-	e.undoOffset--
+	e.Commit()
 	e.mode = ModeInsert
 }
 
 func (e *Editor) ChangeUntil(until [2]int) {
+	if e.mode == ModeVBlock {
+		e.ChangeBlock()
+		return
+	}
+	from, until, linewise := e.operatorRange(until)
+	if linewise {
+		register.Delete(e.selectedRegister(), e.linesText(from[0], until[0]), register.Linewise)
+		// the lines are replaced with an empty line to type on
+		from, until = [2]int{from[0], 0}, [2]int{until[0], len(e.spansPerLines[until[0]]) - 1}
+	} else if from != until {
+		register.Delete(e.selectedRegister(), e.textBefore(from, until), register.Charwise)
+	}
+	if from != until {
+		e.ReplaceText("", from, until)
+	}
+	// the text is typed where the changed text was, past the last character when it ended the line
+	e.cursor = from
 	e.mode = ModeInsert
-	e.DeleteUntil(until)
 }
 
 func (e *Editor) DeleteUntil(until [2]int) {
-	from := e.cursor
+	if e.mode == ModeVBlock {
+		e.DeleteBlock()
+		return
+	}
+	from, until, linewise := e.operatorRange(until)
+	if linewise {
+		register.Delete(e.selectedRegister(), e.linesText(from[0], until[0]), register.Linewise)
+		from, until = e.linesRange(from[0], until[0])
+	} else if from == until {
+		return
+	} else {
+		register.Delete(e.selectedRegister(), e.textBefore(from, until), register.Charwise)
+	}
+	e.ReplaceText("", from, until)
+}
+
+// operatorRange returns the text the pending operator applies to, from the cursor to the motion target,
+// as a ReplaceText range, or as the first and last rows when it's linewise.
+// A visual selection is inclusive and a visual line selection is linewise, whatever the motion.
+func (e *Editor) operatorRange(target [2]int) ([2]int, [2]int, bool) {
+	from, until := e.cursor, target
 	if until[0] < from[0] || (until[0] == from[0] && until[1] < from[1]) {
 		from, until = until, from
 	}
-	clipboard.Write(e.GetText(from, until))
-	e.ReplaceText("", from, until)
+
+	kind := e.motion.MotionKind()
+	if e.isLinewiseObject() {
+		kind = MotionLinewise
+	}
+	switch e.mode {
+	case ModeVisual:
+		kind = MotionInclusive
+	case ModeVLine:
+		kind = MotionLinewise
+	}
+
+	switch kind {
+	case MotionLinewise:
+		return from, until, true
+	case MotionInclusive:
+		return from, e.positionAfter(until), false
+	}
+
+	// an exclusive motion ending at the start of a line ends at the end of the line above, so dw on the last
+	// word of a line keeps its line break. Starting at or before the first non-blank, it becomes linewise,
+	// unless it's w or W moving over a word.
+	if until[1] == 0 && until[0] > from[0] {
+		until = [2]int{until[0] - 1, len(e.spansPerLines[until[0]-1]) - 1}
+		overWord := (e.motion == ActionMoveStartOfWord || e.motion == ActionMoveStartOfBigWord) && until != from
+		if !overWord && from[1] <= e.firstNonBlank(from[0]) {
+			return from, until, true
+		}
+	}
+	return from, until, false
+}
+
+// positionAfter returns the position of the next character, the start of the next line after a line break.
+func (e *Editor) positionAfter(c [2]int) [2]int {
+	if c[1] < len(e.spansPerLines[c[0]])-1 {
+		return [2]int{c[0], c[1] + 1}
+	}
+	if c[0] < len(e.spansPerLines)-1 {
+		return [2]int{c[0] + 1, 0}
+	}
+	return c
+}
+
+// positionBefore returns the position of the previous character, the line break of the line above
+// at the start of a line.
+func (e *Editor) positionBefore(c [2]int) [2]int {
+	if c[1] > 0 {
+		return [2]int{c[0], c[1] - 1}
+	}
+	if c[0] > 0 {
+		return [2]int{c[0] - 1, len(e.spansPerLines[c[0]-1]) - 1}
+	}
+	return c
+}
+
+// firstNonBlank returns the column of the first non-blank character of the row, its end if it's blank.
+func (e *Editor) firstNonBlank(row int) int {
+	spans := e.spansPerLines[row]
+	for i, span := range spans {
+		if span.runes != nil && !unicode.IsSpace(span.runes[0]) {
+			return i
+		}
+	}
+	return len(spans) - 1
+}
+
+// textBefore returns the text from the cursor up to, but not including, the until cursor,
+// the text ReplaceText replaces.
+func (e *Editor) textBefore(from, until [2]int) string {
+	if until == from {
+		return ""
+	}
+	return e.GetText(from, e.positionBefore(until))
+}
+
+// linesText returns the rows from first to last with their line breaks.
+func (e *Editor) linesText(first, last int) string {
+	return e.GetText([2]int{first, 0}, [2]int{last, len(e.spansPerLines[last]) - 1})
 }
 
 func (e *Editor) YankUntil(until [2]int) {
-	e.VisualUntil(until)
-	e.yankOnVisual = true
-	if e.delayDrawFunc != nil {
-		e.delayDrawFunc(time.Now().Add(100*time.Millisecond), func() {
-			if e.yankOnVisual || e.mode == ModeVisual || e.mode == ModeVLine {
-				e.yankOnVisual = false
-				if e.mode != ModeVisual && e.mode != ModeVLine {
-					return
-				}
+	if e.mode == ModeVBlock {
+		e.YankBlock()
+		return
+	}
+	from, until, linewise := e.operatorRange(until)
+	if linewise {
+		register.Yank(e.selectedRegister(), e.linesText(from[0], until[0]), register.Linewise)
+	} else if from != until {
+		register.Yank(e.selectedRegister(), e.textBefore(from, until), register.Charwise)
+	}
 
-				e.mode = ModeNormal
-				until := e.cursor
-				from := e.visualStart
-				if until[0] < from[0] || (until[0] == from[0] && until[1] < from[1]) {
-					from, until = until, from
-				}
-				clipboard.Write(e.GetText(from, until))
-				e.ResetMotionIndexes()
-			}
-		})
+	// a visual selection ends right away, the text of a motion is shown selected for a moment
+	if e.mode == ModeVisual || e.mode == ModeVLine || e.delayDrawFunc == nil {
+		e.mode = ModeNormal
+		e.cursor = from
+		return
+	}
+	if linewise {
+		e.visualStart, e.cursor = [2]int{from[0], 0}, [2]int{until[0], 0}
+		e.mode = ModeVLine
+	} else {
+		e.visualStart, e.cursor = from, e.positionBefore(until)
+		e.mode = ModeVisual
 	}
+	e.yankOnVisual = true
+	e.delayDrawFunc(time.Now().Add(100*time.Millisecond), func() {
+		if !e.yankOnVisual {
+			return
+		}
+		e.yankOnVisual = false
+		if e.mode == ModeVisual || e.mode == ModeVLine {
+			e.mode = ModeNormal
+			e.cursor = from
+		}
+		e.ResetMotionIndexes()
+	})
 }
 
 func (e *Editor) VisualUntil(until [2]int) {
@@ -2048,28 +2882,40 @@ func (e *Editor) VisualUntil(until [2]int) {
 		return
 	}
 
+	if e.isLinewiseObject() {
+		e.visualStart = [2]int{e.cursor[0], 0}
+		e.MoveCursorTo(until)
+		e.ChangeMode(ModeVLine)
+		return
+	}
 	e.visualStart = e.cursor
 	e.MoveCursorTo(until)
 	e.ChangeMode(ModeVisual)
 }
 
+// isLinewiseObject reports whether the motion is a text object taking whole lines, e.g. ip.
+func (e *Editor) isLinewiseObject() bool {
+	return e.linewiseObject && (e.motion == ActionInside || e.motion == ActionAround)
+}
+
 func (e *Editor) ChangeUntilEndOfLine() {
 	e.ChangeUntil(e.GetEndOfLineCursor())
 }
 
 func (e *Editor) DeleteUntilEndOfLine() {
-	if len(e.spansPerLines[e.cursor[0]]) <= 1 {
+	from := e.cursor
+	until := e.GetEndOfLineCursor()
+	if until == from {
 		return
 	}
-	from := e.cursor
-	until := [2]int{e.cursor[0], len(e.spansPerLines[e.cursor[0]]) - 1}
+	register.Delete(e.selectedRegister(), e.textBefore(from, until), register.Charwise)
+	e.Begin()
 	e.ReplaceText("", from, until)
 	e.cursor[1]--
 	if e.cursor[1] < 0 {
 		e.cursor[1] = 0
 	}
-	e.SaveChanges()
-	e.undoOffset--
+	e.Commit()
 }
 
 func (e *Editor) DeleteLine() {
@@ -2077,18 +2923,23 @@ func (e *Editor) DeleteLine() {
 		return
 	}
 
-	from := [2]int{e.cursor[0], 0}
-	until := [2]int{e.cursor[0] + 1, 0}
-	if e.cursor[0] == len(e.spansPerLines)-1 {
-		if e.cursor[0] != 0 {
-			aboveRow := e.cursor[0] - 1
+	from, until := e.linesRange(e.cursor[0], e.cursor[0])
+	e.ReplaceText("", from, until)
+}
+
+// linesRange returns the range ReplaceText replaces to delete the rows from first to last with their line breaks.
+// Deleting until the last row takes the line break before the first row instead.
+func (e *Editor) linesRange(first, last int) ([2]int, [2]int) {
+	from := [2]int{first, 0}
+	until := [2]int{last + 1, 0}
+	if last == len(e.spansPerLines)-1 {
+		if first != 0 {
+			aboveRow := first - 1
 			from = [2]int{aboveRow, len(e.spansPerLines[aboveRow]) - 1}
 		}
-		until = [2]int{e.cursor[0], len(e.spansPerLines[e.cursor[0]]) - 1}
+		until = [2]int{last, len(e.spansPerLines[last]) - 1}
 	}
-	e.ReplaceText("", from, until)
-	e.SaveChanges()
-	e.undoOffset--
+	return from, until
 }
 
 func (e *Editor) InsertAfter() {
@@ -2124,13 +2975,13 @@ func (e *Editor) MoveMotion(motion rune, n int) {
 
 func (e *Editor) GetEndOfWordCursor() [2]int {
 	c, _ := e.GetNextMotionCursor('e', e.getActionCount(), e.cursor, false)
-	if e.pendingAction != ActionNone && e.pendingAction != ActionVisual && e.pendingAction != ActionYank {
-		c[1]++
-	}
 	return c
 }
 
 func (e *Editor) GetStartOfWordCursor() [2]int {
+	if e.pendingAction == ActionChange && !e.onBlank() {
+		return e.getChangeWordCursor('e')
+	}
 	c, _ := e.GetNextMotionCursor('w', e.getActionCount(), e.cursor, false)
 	return c
 }
@@ -2146,10 +2997,33 @@ func (e *Editor) GetBackEndOfBigWordCursor() [2]int {
 }
 
 func (e *Editor) GetStartOfBigWordCursor() [2]int {
+	if e.pendingAction == ActionChange && !e.onBlank() {
+		return e.getChangeWordCursor('E')
+	}
 	c, _ := e.GetNextMotionCursor('W', e.getActionCount(), e.cursor, false)
 	return c
 }
 
+// getChangeWordCursor returns the end of cw and cW, they change until the end of the word like ce and cE,
+// but a cursor already on the end of a word stays on it, e.g. cw on a one letter word changes only that letter.
+func (e *Editor) getChangeWordCursor(motion rune) [2]int {
+	e.motion = ActionMoveEndOfWord
+	if motion == 'E' {
+		e.motion = ActionMoveEndOfBigWord
+	}
+	c, _ := e.GetNextMotionCursor(motion, e.getActionCount(), e.cursor, true)
+	return c
+}
+
+// onBlank returns whether the cursor is on a white space or an empty line.
+func (e *Editor) onBlank() bool {
+	spans := e.spansPerLines[e.cursor[0]]
+	if e.cursor[1] >= len(spans) || spans[e.cursor[1]].runes == nil {
+		return true
+	}
+	return unicode.IsSpace(spans[e.cursor[1]].runes[0])
+}
+
 func (e *Editor) GetBackStartOfBigWordCursor() [2]int {
 	c, _ := e.GetPrevMotionCursor('W', e.getActionCount(), e.cursor, false)
 	return c
@@ -2165,9 +3039,93 @@ func (e *Editor) GetBackEndOfWordCursor() [2]int {
 	return c
 }
 
+// searchDirection is the direction of n, -1 after a ? search.
+func (e *Editor) searchDirection() int {
+	if e.searchBackward {
+		return -1
+	}
+	return 1
+}
+
+// GetSearchCursor returns the count-th match in the search direction.
 func (e *Editor) GetSearchCursor() [2]int {
-	c, _ := e.GetNextMotionCursor('n', e.getActionCount(), e.cursor, false)
-	return c
+	return e.getSearchCursor(e.searchDirection() * e.getActionCount())
+}
+
+// getSearchCursor returns the n-th match after the cursor, or before it if n is negative, counting around
+// the text as many times as needed. Passing the end or the start of the text is shown in the status line.
+func (e *Editor) getSearchCursor(n int) [2]int {
+	matches := e.motionIndexes['n']
+	if len(matches) == 0 || n == 0 {
+		return e.cursor
+	}
+
+	idx, wrapped := e.searchMatch(n)
+	e.searchWrapped = wrapped
+	if e.statusFunc != nil && wrapped && n > 0 {
+		e.statusFunc("search hit BOTTOM, continuing at TOP")
+	} else if e.statusFunc != nil && wrapped {
+		e.statusFunc("search hit TOP, continuing at BOTTOM")
+	}
+	return [2]int{matches[idx][0], matches[idx][1]}
+}
+
+// searchMatch returns the index of the n-th match after the cursor, or before it if n is negative,
+// and whether it went around the start or the end of the text.
+func (e *Editor) searchMatch(n int) (int, bool) {
+	matches := e.motionIndexes['n']
+
+	// before is the number of matches before the cursor, after is the index of the first match after it
+	before, after := len(matches), len(matches)
+	for i, m := range matches {
+		if before == len(matches) && (m[0] > e.cursor[0] || m[0] == e.cursor[0] && m[1] >= e.cursor[1]) {
+			before = i
+		}
+		if m[0] > e.cursor[0] || m[0] == e.cursor[0] && m[1] > e.cursor[1] {
+			after = i
+			break
+		}
+	}
+
+	idx := after + n - 1
+	if n < 0 {
+		idx = before + n
+	}
+	wrapped := idx >= len(matches) || idx < 0
+
+	idx %= len(matches)
+	if idx < 0 {
+		idx += len(matches)
+	}
+	return idx, wrapped
+}
+
+// searchPosition returns the 1-based index of the search match under the cursor and the number of matches,
+// the index is 0 when the cursor isn't on a match.
+func (e *Editor) searchPosition() (int, int) {
+	matches := e.motionIndexes['n']
+	i, found := slices.BinarySearchFunc(matches, e.cursor, func(m [3]int, cursor [2]int) int {
+		return cmp.Or(cmp.Compare(m[0], cursor[0]), cmp.Compare(m[1], cursor[1]))
+	})
+	if !found {
+		return 0, len(matches)
+	}
+	return i + 1, len(matches)
+}
+
+// searchQuery returns the regexp of the searched text, ignoring the case with the ignorecase option.
+func (e *Editor) searchQuery(s string) string {
+	query := regexp.QuoteMeta(s)
+	if e.ignoreCase(s) {
+		query = "(?i)" + query
+	}
+	return query
+}
+
+// ignoreCase reports whether a search of the text ignores the case, with the ignorecase option unless
+// the smartcase option is set and the text has an uppercase letter.
+func (e *Editor) ignoreCase(s string) bool {
+	return e.options.IgnoreCase && !(e.options.SmartCase && strings.IndexFunc(s, unicode.IsUpper) >= 0)
 }
 
 func (e *Editor) GetInsideOrAroundCursor() [2]int {
@@ -2176,7 +3134,7 @@ func (e *Editor) GetInsideOrAroundCursor() [2]int {
 	}
 
 	if e.motionIndexes['s'] == nil || len(e.motionIndexes['s']) != 2 {
-		return e.cursor
+		return vim.FailedMotion
 	}
 
 	mode := e.mode
@@ -2184,11 +3142,7 @@ func (e *Editor) GetInsideOrAroundCursor() [2]int {
 	e.MoveCursorTo([2]int{e.motionIndexes['s'][0][0], e.motionIndexes['s'][0][1]})
 	e.ChangeMode(mode)
 
-	c := [2]int{e.motionIndexes['s'][1][0], e.motionIndexes['s'][1][1]}
-	if e.pendingAction != ActionNone && e.pendingAction != ActionVisual && e.pendingAction != ActionYank {
-		c[1]++
-	}
-	return c
+	return [2]int{e.motionIndexes['s'][1][0], e.motionIndexes['s'][1][1]}
 }
 
 func (e *Editor) GetTilCursor() [2]int {
@@ -2197,8 +3151,8 @@ func (e *Editor) GetTilCursor() [2]int {
 	}
 
 	c, found := e.GetNextMotionCursor('t', e.getActionCount(), e.cursor, false)
-	if found && e.pendingAction != ActionNone && c != e.cursor && e.pendingAction != ActionVisual && e.pendingAction != ActionYank {
-		c[1]++
+	if !found {
+		return vim.FailedMotion
 	}
 	return c
 }
@@ -2209,8 +3163,8 @@ func (e *Editor) GetTilBackCursor() [2]int {
 	}
 
 	c, found := e.GetPrevMotionCursor('T', e.getActionCount(), e.cursor, false)
-	if found && e.pendingAction != ActionNone && c != e.cursor && e.pendingAction != ActionVisual && e.pendingAction != ActionYank {
-		c[1]++
+	if !found {
+		return vim.FailedMotion
 	}
 	return c
 }
@@ -2221,8 +3175,8 @@ func (e *Editor) GetFindCursor() [2]int {
 	}
 
 	c, found := e.GetNextMotionCursor('f', e.getActionCount(), e.cursor, false)
-	if found && e.pendingAction != ActionNone && c != e.cursor && e.pendingAction != ActionVisual && e.pendingAction != ActionYank {
-		c[1]++
+	if !found {
+		return vim.FailedMotion
 	}
 	return c
 }
@@ -2232,7 +3186,10 @@ func (e *Editor) GetFindBackCursor() [2]int {
 		return e.WaitingForMotion()
 	}
 
-	c, _ := e.GetPrevMotionCursor('f', e.getActionCount(), e.cursor, false)
+	c, found := e.GetPrevMotionCursor('f', e.getActionCount(), e.cursor, false)
+	if !found {
+		return vim.FailedMotion
+	}
 	return c
 }
 
@@ -2379,15 +3336,73 @@ func (e *Editor) flashDecorator(x, y, width, height int) {
 			break
 		}
 
-		r, hasFlash := e.reverseFlashIndexes[[2]int{idx[0], idx[1]}]
-		if hasFlash {
-			e.decorations[[2]int{idx[0], idx[2] + 1}] = decoration{style: style1, text: string(r)}
+		label, hasFlash := e.reverseFlashIndexes[[2]int{idx[0], idx[1]}]
+		if !hasFlash || !strings.HasPrefix(label, e.flashPrefix) {
+			continue
+		}
+		label = strings.TrimPrefix(label, e.flashPrefix)
+		col := idx[2] + 1
+		if e.flashOptions.Placement == "before" {
+			col = max(idx[1]-len([]rune(label)), 0)
+		}
+		spans := e.spansPerLines[idx[0]]
+		for i, r := range []rune(label) {
+			// the end of line decoration prints the rest of the label
+			if col+i >= len(spans)-1 {
+				e.decorations[[2]int{idx[0], len(spans) - 1}] = decoration{style: style1, text: string([]rune(label)[i:])}
+				break
+			}
+			e.decorations[[2]int{idx[0], col + i}] = decoration{style: style1, text: string(r)}
+		}
+	}
+}
+
+// selectionSize returns the number of lines, characters, and bytes selected in the visual modes,
+// the line breaks are counted as a character.
+func (e *Editor) selectionSize() (lines, chars, bytes int) {
+	from := e.visualStart
+	until := e.cursor
+	if from[0] > until[0] || from[0] == until[0] && from[1] > until[1] {
+		from, until = until, from
+	}
+	if e.mode == ModeVLine {
+		from[1], until[1] = 0, len(e.spansPerLines[until[0]])-1
+	}
+	_, _, left, right := e.visualBlock()
+
+	for row := from[0]; row <= until[0]; row++ {
+		spans := e.spansPerLines[row]
+		start, end := 0, len(spans)-1
+		if row == from[0] {
+			start = from[1]
+		}
+		if row == until[0] {
+			end = min(until[1], end)
+		}
+		if e.mode == ModeVBlock {
+			// the block has no line breaks
+			start, end = e.blockColumns(row, left, right)
+			end--
+		}
+		for col := start; col <= end; col++ {
+			chars++
+			if col == len(spans)-1 {
+				// the line break, the last line has none
+				if row == len(e.spansPerLines)-1 {
+					chars--
+				} else {
+					bytes++
+				}
+				continue
+			}
+			bytes += len(string(spans[col].runes))
 		}
 	}
+	return until[0] - from[0] + 1, chars, bytes
 }
 
 func (e *Editor) visualDecorator(x, y, width, height int) {
-	if e.mode != ModeVisual && e.mode != ModeVLine {
+	if e.mode != ModeVisual && e.mode != ModeVLine && e.mode != ModeVBlock {
 		return
 	}
 
@@ -2398,6 +3413,7 @@ func (e *Editor) visualDecorator(x, y, width, height int) {
 	}
 
 	style := tcell.StyleDefault.Background(tview.Styles.MoreContrastBackgroundColor).Foreground(tview.Styles.PrimitiveBackgroundColor)
+	_, _, left, right := e.visualBlock()
 	for row := range until[0] - from[0] + 1 {
 		row += from[0]
 		lineWidth := 0
@@ -2408,6 +3424,7 @@ func (e *Editor) visualDecorator(x, y, width, height int) {
 			break
 		}
 
+		blockStart, blockEnd := e.blockColumns(row, left, right)
 		for col, span := range e.spansPerLines[row] {
 			lineWidth += span.width
 			if lineWidth < x {
@@ -2417,6 +3434,13 @@ func (e *Editor) visualDecorator(x, y, width, height int) {
 				break
 			}
 
+			if e.mode == ModeVBlock {
+				if col >= blockStart && col < blockEnd {
+					e.decorations[[2]int{row, col}] = decoration{style: style, text: ""}
+				}
+				continue
+			}
+
 			if (e.mode == ModeVisual &&
 				(row == from[0] && col >= from[1] && row == until[0] && col <= until[1]) ||
 				(row == from[0] && row < until[0] && col >= from[1]) ||
@@ -2430,37 +3454,58 @@ func (e *Editor) visualDecorator(x, y, width, height int) {
 }
 
 func (e *Editor) highlightDecorator(x, y, width, height int) {
-	byte := 0
-	byteMapper := make(map[int][2]int)
-	for row, spans := range e.spansPerLines {
-		for col, span := range spans {
-			for i := range span.bytesWidth {
-				byteMapper[byte+i] = [2]int{row, col}
+	byteMapper := e.getByteMapper()
+	errorStyle := tcell.StyleDefault.Foreground(theme.Current().Error).Underline(tcell.UnderlineStyleCurly, theme.Current().Error)
+
+	for row := max(y, 0); row < y+height && row < len(e.highlightsPerLine); row++ {
+		lineStart, lineEnd := e.lineStarts[row], e.lineStarts[row+1]
+		for _, h := range e.highlightsPerLine[row] {
+			style, hasStyle := theme.Current().Syntax[h.kind]
+			if !hasStyle {
+				continue
+			}
+
+			for i := max(h.start, lineStart); i < min(h.end, lineEnd) && i < len(byteMapper); i++ {
+				e.decorations[byteMapper[i]] = decoration{style: style, text: ""}
 			}
-			byte += span.bytesWidth
 		}
-		byte += 1
 	}
 
-	for byteRange, kind := range e.highlightIndexes {
-		style, hasStyle := colorMap[kind]
-		if !hasStyle {
+	// show the first diagnostic of a row after its end
+	shown := make(map[int]bool)
+	for _, d := range e.diagnostics {
+		if d.Row < y || d.Row >= y+height || d.Row >= len(e.spansPerLines) || shown[d.Row] {
 			continue
 		}
+		shown[d.Row] = true
+		e.decorations[[2]int{d.Row, len(e.spansPerLines[d.Row]) - 1}] = decoration{style: errorStyle, text: "     " + d.String()}
+	}
+}
 
-		for i := range byteRange[1] - byteRange[0] {
-			c := byteMapper[i+byteRange[0]]
-			e.decorations[c] = decoration{style: style, text: ""}
+// getByteMapper returns the [row, col] of every text byte, rebuilt only when the text is edited.
+func (e *Editor) getByteMapper() [][2]int {
+	editCount := e.editCount.Load()
+	if e.byteMapper != nil && e.byteMapperEditCount == editCount {
+		return e.byteMapper
+	}
 
-			if kind == "error" {
-				e.decorations[[2]int{c[0], len(e.spansPerLines[c[0]]) - 1}] = decoration{style: tcell.StyleDefault.Foreground(tcell.ColorRed).Underline(tcell.UnderlineStyleCurly, tcell.ColorRed), text: "     syntax error"}
+	e.byteMapper = e.byteMapper[:0]
+	for row, spans := range e.spansPerLines {
+		for col, span := range spans {
+			for range span.bytesWidth {
+				e.byteMapper = append(e.byteMapper, [2]int{row, col})
 			}
 		}
+		// newline
+		e.byteMapper = append(e.byteMapper, [2]int{row, len(spans) - 1})
 	}
+	e.byteMapperEditCount = editCount
+	return e.byteMapper
 }
 
 func (e *Editor) ResetMotionIndexes() {
 	e.motionIndexes['n'] = nil
+	e.ClearSigns(SignGroupSearch)
 	e.motionIndexes['t'] = nil
 	e.motionIndexes['T'] = nil
 	e.motionIndexes['f'] = nil
@@ -2470,9 +3515,65 @@ func (e *Editor) ResetMotionIndexes() {
 func (e *Editor) ResetAction() {
 	e.pendingAction = ActionNone
 	e.lastMotion = ActionNone
+	e.motion = ActionNone
 	e.pending = nil
-	e.pendingCount = 0
+	e.operatorCount = 0
+	e.operatorKeys = 0
 	e.waitingForMotion = false
+	// the register and a count typed before " apply to the next action, e.g. 2"ayy
+	if !e.waitingForRegister {
+		e.register = 0
+		e.pendingCount = 0
+	}
+}
+
+// hasPending reports whether a register, count, operator or motion is waiting for more keys.
+func (e *Editor) hasPending() bool {
+	return e.waitingForRegister || e.waitingForMotion || e.register != 0 || e.pendingCount != 0 ||
+		e.pendingAction != ActionNone || e.lastMotion != ActionNone || len(e.pending) > 0
+}
+
+// CancelPending drops the pending register, count, operator and motion, the mode is kept.
+func (e *Editor) CancelPending() {
+	e.waitingForRegister = false
+	e.ResetAction()
+}
+
+// interrupt handles ctrl-c with nothing pending, it leaves the current mode like esc
+// and closes a one-line editor.
+func (e *Editor) interrupt() {
+	if e.oneLineMode {
+		e.Exit()
+		return
+	}
+	switch e.mode {
+	case ModeInsert:
+		e.leaveInsert()
+	case ModeReplace, ModeVisual, ModeVLine, ModeVBlock:
+		e.ChangeMode(ModeNormal)
+	}
+}
+
+func (e *Editor) leaveInsert() {
+	if b := e.blockInsert; b != nil {
+		e.blockInsert = nil
+		if e.cursor[0] == b.start[0] && e.cursor[1] > b.start[1] {
+			e.repeatBlockInsert(b, e.textBefore(b.start, e.cursor))
+			e.cursor = b.start
+		}
+	}
+	e.mode = ModeNormal
+	if e.cursor[1] == len(e.spansPerLines[e.cursor[0]])-1 {
+		e.MoveCursorLeft()
+	}
+}
+
+// selectedRegister returns the register selected with ", the unnamed register if there's none.
+func (e *Editor) selectedRegister() rune {
+	if e.register == 0 {
+		return register.Unnamed
+	}
+	return e.register
 }
 
 func WriteFile(text string) {
@@ -2483,3 +3584,19 @@ func WriteFile(text string) {
 
 	fmt.Fprint(f, text)
 }
+
+// complete cycles the one line text through the completions of the text typed before the first Tab,
+// the typed text is the last one.
+func (e *Editor) complete(delta int) {
+	if e.completions == nil {
+		e.completions = append(e.completeFunc(e.text), e.text)
+		e.completionIndex = len(e.completions) - 1
+	}
+	if len(e.completions) == 1 {
+		return
+	}
+
+	e.completionIndex = (e.completionIndex + delta + len(e.completions)) % len(e.completions)
+	text := e.completions[e.completionIndex]
+	e.SetText(text, [2]int{0, uniseg.GraphemeClusterCount(text)})
+}
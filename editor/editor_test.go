@@ -0,0 +1,30 @@
+package editor
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ngavinsir/sqluy/keymap"
+	"github.com/rivo/tview"
+)
+
+// newTestEditor returns an editor with the default keymap and the text, its motion indexes built.
+func newTestEditor(t testing.TB, text string, cursor [2]int) *Editor {
+	t.Helper()
+	b, err := os.ReadFile("../app/keymap.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(WithKeymapper(keymap.New(string(b))))
+	e.SetText(text, cursor)
+	e.WaitMotionIndexes()
+	return e
+}
+
+// sendKeys sends the keys in vim notation, e.g. "d2e<Esc>", waiting for the motion indexes after every key.
+func sendKeys(e *Editor, keys string) {
+	for _, key := range keymap.ParseKeys(keys) {
+		e.InputHandler()(keymap.Event(key), func(p tview.Primitive) {})
+		e.WaitMotionIndexes()
+	}
+}
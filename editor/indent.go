@@ -0,0 +1,78 @@
+package editor
+
+import (
+	"strings"
+)
+
+// IndentUntil shifts the lines from the cursor line to the target line right by the shiftwidth,
+// a visual selection is shifted count times.
+func (e *Editor) IndentUntil(until [2]int) {
+	e.shiftUntil(until, 1)
+}
+
+// DedentUntil shifts the lines from the cursor line to the target line left by the shiftwidth,
+// a visual selection is shifted count times.
+func (e *Editor) DedentUntil(until [2]int) {
+	e.shiftUntil(until, -1)
+}
+
+func (e *Editor) shiftUntil(until [2]int, direction int) {
+	if isVisual(e.mode) {
+		direction *= e.getActionCount()
+	}
+	e.shiftLines(min(e.cursor[0], until[0]), max(e.cursor[0], until[0]), direction)
+}
+
+// shiftLines changes the indent of the rows from first to last by levels shiftwidths, rewriting it with spaces.
+// Blank rows are left alone, and the cursor moves to the first non-blank of the first row.
+func (e *Editor) shiftLines(first, last, levels int) {
+	last = min(last, len(e.spansPerLines)-1)
+	if first > last {
+		return
+	}
+	shiftWidth := e.options.ShiftWidth
+	if shiftWidth == 0 {
+		shiftWidth = e.options.TabStop
+	}
+
+	e.Begin()
+	for row := first; row <= last; row++ {
+		spans := e.spansPerLines[row]
+		blanks, width := e.indent(row)
+		if blanks == len(spans)-1 {
+			continue
+		}
+		indent := strings.Repeat(" ", max(width+levels*shiftWidth, 0))
+		e.ReplaceText(indent, [2]int{row, 0}, [2]int{row, blanks})
+	}
+	e.cursor = [2]int{first, e.firstNonBlank(first)}
+	e.Commit()
+
+	if lines := last - first + 1; lines > 2 && e.statusFunc != nil {
+		if levels > 0 {
+			e.statusFunc(plural(lines, "line") + " indented")
+		} else {
+			e.statusFunc(plural(lines, "line") + " dedented")
+		}
+	}
+}
+
+// indent returns the number of spaces and tabs starting the row and their width, a tab reaching the next tabstop.
+func (e *Editor) indent(row int) (int, int) {
+	spans := e.spansPerLines[row]
+	blanks, width := 0, 0
+	for _, span := range spans {
+		if span.runes == nil {
+			break
+		}
+		if span.runes[0] == '\t' {
+			width += e.options.TabStop - width%e.options.TabStop
+		} else if span.runes[0] == ' ' {
+			width++
+		} else {
+			break
+		}
+		blanks++
+	}
+	return blanks, width
+}
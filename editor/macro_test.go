@@ -0,0 +1,33 @@
+package editor
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ngavinsir/sqluy/keymap"
+)
+
+func TestUserMacro(t *testing.T) {
+	b, err := os.ReadFile("../app/keymap.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	km := keymap.New(string(b))
+	// a macro of the user keymap, its keys are undone one at a time like typed ones
+	err = km.Merge(`{"keymaps": {"editor": [{"keys": ["Q"], "groups": ["n"], "macro": "ddp"}]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(WithKeymapper(km))
+	e.SetText("select 1\nfrom t\nwhere a\n", [2]int{})
+	e.WaitMotionIndexes()
+
+	sendKeys(e, "Q")
+	if want := "from t\nselect 1\nwhere a\n"; e.text != want {
+		t.Errorf("text = %q, want %q", e.text, want)
+	}
+	sendKeys(e, "u")
+	if want := "from t\nwhere a\n"; e.text != want {
+		t.Errorf("undo = %q, want %q", e.text, want)
+	}
+}
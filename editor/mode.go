@@ -8,6 +8,7 @@ const (
 	ModeReplace
 	ModeVisual
 	ModeVLine
+	ModeVBlock
 )
 
 func (m mode) String() string {
@@ -20,6 +21,8 @@ func (m mode) String() string {
 		return "VISUAL"
 	case ModeVLine:
 		return "V-LINE"
+	case ModeVBlock:
+		return "V-BLOCK"
 	default:
 		return "NORMAL"
 	}
@@ -31,9 +34,14 @@ func (m mode) ShortString() string {
 		return "i"
 	case ModeReplace:
 		return "r"
-	case ModeVisual, ModeVLine:
+	case ModeVisual, ModeVLine, ModeVBlock:
 		return "v"
 	default:
 		return "n"
 	}
 }
+
+// isVisual reports whether the mode is one of the visual modes.
+func isVisual(m mode) bool {
+	return m == ModeVisual || m == ModeVLine || m == ModeVBlock
+}
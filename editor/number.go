@@ -0,0 +1,71 @@
+package editor
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// rgNumber matches the hexadecimal and the decimal numbers, a decimal one with its minus sign
+var rgNumber = regexp.MustCompile(`0[xX][0-9a-fA-F]+|-?[0-9]+`)
+
+// AddNumber adds n to the first number under or after the cursor on its line, like vim's ctrl-a and ctrl-x.
+// The cursor moves to the last digit. Leading zeros keep the number of digits, and a hexadecimal number
+// keeps the case of its letters.
+func (e *Editor) AddNumber(n int) {
+	line := e.lineText(e.cursor[0])
+	cursor := 0
+	for _, span := range e.spansPerLines[e.cursor[0]][:e.cursor[1]] {
+		cursor += span.bytesWidth
+	}
+
+	var match []int
+	for _, m := range rgNumber.FindAllStringIndex(line, -1) {
+		if m[1] > cursor {
+			match = m
+			break
+		}
+	}
+	if match == nil {
+		return
+	}
+
+	number := addNumber(line[match[0]:match[1]], n)
+	start := uniseg.GraphemeClusterCount(line[:match[0]])
+	until := start + uniseg.GraphemeClusterCount(line[match[0]:match[1]])
+	e.ReplaceText(number, [2]int{e.cursor[0], start}, [2]int{e.cursor[0], until})
+	e.cursor = [2]int{e.cursor[0], start + len(number) - 1}
+	e.setDesiredColumn(e.cursor, e.desiredWidth())
+}
+
+// addNumber returns the number text plus n, formatted like the text.
+func addNumber(text string, n int) string {
+	if digits, ok := strings.CutPrefix(strings.ToLower(text), "0x"); ok {
+		v, _ := new(big.Int).SetString(digits, 16)
+		v.Add(v, big.NewInt(int64(n)))
+		// a hexadecimal number is unsigned, going below zero wraps around its digits
+		if v.Sign() < 0 {
+			v.Add(v, new(big.Int).Lsh(big.NewInt(1), uint(4*len(digits))))
+		}
+		formatted := fmt.Sprintf("%0*x", len(digits), v)
+		if strings.ContainsAny(text[2:], "ABCDEF") {
+			formatted = strings.ToUpper(formatted)
+		}
+		return text[:2] + formatted
+	}
+
+	v, _ := new(big.Int).SetString(text, 10)
+	v.Add(v, big.NewInt(int64(n)))
+	digits := strings.TrimPrefix(text, "-")
+	if len(digits) > 1 && digits[0] == '0' {
+		width := len(digits)
+		if v.Sign() < 0 {
+			width++
+		}
+		return fmt.Sprintf("%0*d", width, v)
+	}
+	return v.String()
+}
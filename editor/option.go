@@ -1,5 +1,7 @@
 package editor
 
+import "github.com/ngavinsir/sqluy/config"
+
 func WithKeymapper(km keymapper) func(e *Editor) {
 	return func(e *Editor) {
 		e.keymapper = km
@@ -11,3 +13,36 @@ func WithDoneFunc(doneFn func(*Editor, string)) func(e *Editor) {
 		e.onDoneFunc = doneFn
 	}
 }
+
+func WithFlash(options config.Flash) func(e *Editor) {
+	return func(e *Editor) {
+		e.flashOptions = options
+	}
+}
+
+func WithOptions(options config.Editor) func(e *Editor) {
+	return func(e *Editor) {
+		e.options = options
+	}
+}
+
+// WithHistory keeps the texts entered in a prompt in the history, Up and Down go through it.
+func WithHistory(h *History) func(e *Editor) {
+	return func(e *Editor) {
+		e.history = h
+	}
+}
+
+// WithValidateFunc checks the prompt text before it's done, an error keeps the prompt open and is shown on it.
+func WithValidateFunc(f func(string) error) func(e *Editor) {
+	return func(e *Editor) {
+		e.validateFunc = f
+	}
+}
+
+// WithCompleteFunc sets the completion of the prompt text, Tab and Shift-Tab cycle through the completions.
+func WithCompleteFunc(f func(string) []string) func(e *Editor) {
+	return func(e *Editor) {
+		e.completeFunc = f
+	}
+}
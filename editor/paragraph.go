@@ -0,0 +1,36 @@
+package editor
+
+// buildParagraphIndexes sets the surround indexes to the rows of the paragraph under the cursor, the block of
+// lines up to the blank lines around it, or the block of blank lines when the cursor is on one.
+// Around a paragraph takes the blank lines after it too, or the ones before it when it ends the text,
+// and around blank lines takes the paragraph after them.
+func (e *Editor) buildParagraphIndexes(inside bool) {
+	last := len(e.spansPerLines) - 1
+	blank := func(row int) bool {
+		blanks, _ := e.indent(row)
+		return blanks == len(e.spansPerLines[row])-1
+	}
+	// extend returns the farthest row from the row in the direction with rows as blank as isBlank in between
+	extend := func(row, direction int, isBlank bool) int {
+		for row+direction >= 0 && row+direction <= last && blank(row+direction) == isBlank {
+			row += direction
+		}
+		return row
+	}
+
+	isBlank := blank(e.cursor[0])
+	first, until := extend(e.cursor[0], -1, isBlank), extend(e.cursor[0], 1, isBlank)
+	if !inside {
+		switch {
+		case until < last:
+			until = extend(until+1, 1, !isBlank)
+		case !isBlank && first > 0:
+			first = extend(first-1, -1, true)
+		}
+	}
+
+	e.motionIndexes['s'] = [][3]int{
+		{first, 0, 0},
+		{until, len(e.spansPerLines[until]) - 1, len(e.spansPerLines[until]) - 1},
+	}
+}
@@ -0,0 +1,67 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCancelPending(t *testing.T) {
+	pending := []struct {
+		name string
+		keys string
+	}{
+		{"operator", "d"},
+		{"count", "3"},
+		{"operator and count", "d2"},
+		{"count and operator", "2d"},
+		{"register", `"a`},
+		{"waiting for a register", `"`},
+		{"register and operator", `"ay`},
+		{"find", "f"},
+		{"operator and find", "dt"},
+		{"text object", "di"},
+		{"key sequence", "g"},
+		{"visual", "vj"},
+		{"visual line and operator", "Vj"},
+		{"insert", "ix"},
+		{"replace", "Rx"},
+	}
+
+	for _, cancel := range []string{"<Esc>", "<C-c>"} {
+		for _, tt := range pending {
+			t.Run(tt.name+" "+cancel, func(t *testing.T) {
+				e := newTestEditor(t, "a\nb\nc\n", [2]int{})
+				sendKeys(e, tt.keys+cancel)
+				if e.hasPending() {
+					t.Fatalf("pending after %s: keys %v, action %v, count %d, register %q", cancel, e.pending, e.pendingAction, e.pendingCount, e.register)
+				}
+				if e.mode != ModeNormal {
+					t.Fatalf("mode = %s, want NORMAL", e.mode)
+				}
+
+				// the next command runs on its own, without the canceled count, operator, or register
+				want := e.text[strings.Index(e.text, "\n")+1:]
+				sendKeys(e, "gg0dd")
+				if e.text != want {
+					t.Errorf("text after dd = %q, want %q", e.text, want)
+				}
+			})
+		}
+	}
+}
+
+func TestCancelPrompt(t *testing.T) {
+	// esc leaves the insert mode of the prompt first, ctrl-c closes it right away
+	for _, keys := range []string{"/se<Esc><Esc>", "/se<C-c>", ":se<Esc><Esc>", ":se<C-c>", "d/se<C-c>"} {
+		t.Run(keys, func(t *testing.T) {
+			e := newTestEditor(t, "select 1\n", [2]int{})
+			sendKeys(e, keys)
+			if e.searchEditor != nil {
+				t.Fatal("the prompt is still open")
+			}
+			if e.hasPending() || e.mode != ModeNormal {
+				t.Errorf("pending %v in %s mode, want nothing pending in NORMAL mode", e.pending, e.mode)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package editor
+
+import (
+	"slices"
+
+	"github.com/rivo/uniseg"
+)
+
+// historySize is the number of texts kept in a prompt history.
+const historySize = 100
+
+type (
+	// History is the list of texts entered in a prompt, the oldest first.
+	// Up and Down in the prompt go through it.
+	History struct {
+		entries []string
+	}
+)
+
+func NewHistory() *History {
+	return &History{}
+}
+
+// Add appends the text, moving it to the end if it's already there. Empty texts are ignored.
+func (h *History) Add(text string) {
+	if text == "" {
+		return
+	}
+	h.entries = slices.DeleteFunc(h.entries, func(s string) bool { return s == text })
+	h.entries = append(h.entries, text)
+	if len(h.entries) > historySize {
+		h.entries = h.entries[len(h.entries)-historySize:]
+	}
+}
+
+// Entries returns the texts in the history, the oldest first.
+func (h *History) Entries() []string {
+	return slices.Clone(h.entries)
+}
+
+// NewPrompt returns a one-line editor in insert mode showing the prompt before the typed text,
+// e.g. for search, flash, or the command line.
+func NewPrompt(prompt string, options ...func(*Editor)) *Editor {
+	e := New(options...).SetOneLineMode(true)
+	e.Box.SetBorder(false)
+	e.SetPrompt(prompt)
+	e.SetText("", [2]int{0, 0})
+	e.mode = ModeInsert
+	if e.history != nil {
+		e.historyIndex = len(e.history.entries)
+	}
+	return e
+}
+
+// SetPromptRect places the prompt on the bottom line of the given primitive.
+func SetPromptRect(prompt *Editor, p interface{ GetInnerRect() (int, int, int, int) }) *Editor {
+	x, y, w, h := p.GetInnerRect()
+	prompt.SetRect(x, y+h-1, w, 1)
+	return prompt
+}
+
+// submit passes the prompt text to the done handler once it's valid, adding it to the history.
+// An invalid text keeps the prompt open, showing the error.
+func (e *Editor) submit(text string) {
+	if e.validateFunc != nil {
+		if err := e.validateFunc(text); err != nil {
+			e.promptErr = err
+			return
+		}
+	}
+	if e.history != nil {
+		e.history.Add(text)
+	}
+	e.onDoneFunc(e, text)
+}
+
+// recall replaces the prompt text with the previous (delta -1) or next (delta 1) history entry,
+// going past the last entry brings back the typed text.
+func (e *Editor) recall(delta int) {
+	entries := e.history.entries
+	if e.historyIndex == len(entries) {
+		e.historyDraft = e.text
+	}
+	i := max(0, min(e.historyIndex+delta, len(entries)))
+	if i == e.historyIndex {
+		return
+	}
+	e.historyIndex = i
+
+	text := e.historyDraft
+	if i < len(entries) {
+		text = entries[i]
+	}
+	e.SetText(text, [2]int{0, uniseg.GraphemeClusterCount(text)})
+}
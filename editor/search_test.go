@@ -0,0 +1,57 @@
+package editor
+
+import "testing"
+
+func TestSearchCount(t *testing.T) {
+	// the matches of "id" are at [0 7], [1 0], [2 3], and [3 6]
+	text := "select id\nid = 1\nor id = 2\nwhere id\n"
+	tests := []struct {
+		name       string
+		cursor     [2]int
+		keys       string
+		wantCursor [2]int
+		wantStatus string
+	}{
+		{"search", [2]int{}, "/id<CR>", [2]int{0, 7}, ""},
+		{"n", [2]int{}, "/id<CR>n", [2]int{1, 0}, ""},
+		{"3n", [2]int{}, "/id<CR>3n", [2]int{3, 6}, ""},
+		{"4n wraps", [2]int{}, "/id<CR>4n", [2]int{0, 7}, "search hit BOTTOM, continuing at TOP"},
+		{"9n wraps twice", [2]int{}, "/id<CR>9n", [2]int{1, 0}, "search hit BOTTOM, continuing at TOP"},
+		{"N wraps", [2]int{}, "/id<CR>N", [2]int{3, 6}, "search hit TOP, continuing at BOTTOM"},
+		{"2N", [2]int{3, 0}, "/id<CR>2N", [2]int{1, 0}, ""},
+		{"6N wraps", [2]int{3, 0}, "/id<CR>6N", [2]int{1, 0}, "search hit TOP, continuing at BOTTOM"},
+		{"? reverses n", [2]int{3, 0}, "?id<CR>n", [2]int{1, 0}, ""},
+		{"? reverses N", [2]int{3, 0}, "?id<CR>2N", [2]int{0, 7}, "search hit BOTTOM, continuing at TOP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t, text, tt.cursor)
+			var status string
+			e.SetStatusFunc(func(s string) { status = s })
+			sendKeys(e, tt.keys)
+			if e.cursor != tt.wantCursor {
+				t.Errorf("cursor = %v, want %v", e.cursor, tt.wantCursor)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", status, tt.wantStatus)
+			}
+			// the statusline shows W while the last jump is the one that wrapped
+			if wrapped := tt.wantStatus != ""; e.searchWrapped != wrapped {
+				t.Errorf("searchWrapped = %v, want %v", e.searchWrapped, wrapped)
+			}
+		})
+	}
+}
+
+func TestSearchWrappedResets(t *testing.T) {
+	e := newTestEditor(t, "select id\nid = 1\n", [2]int{})
+	sendKeys(e, "/id<CR>2n")
+	if !e.searchWrapped {
+		t.Fatal("2n from the last match didn't wrap")
+	}
+	sendKeys(e, "n")
+	if e.searchWrapped || e.cursor != [2]int{1, 0} {
+		t.Errorf("after n, searchWrapped = %v and cursor = %v, want false and [1 0]", e.searchWrapped, e.cursor)
+	}
+}
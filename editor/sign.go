@@ -0,0 +1,86 @@
+package editor
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/ngavinsir/sqluy/theme"
+)
+
+type (
+	// Sign is a glyph drawn in the sign column before the line numbers, e.g. a diagnostic or a bookmark.
+	// The sign with the highest priority is shown when a line has several.
+	Sign struct {
+		Glyph    rune
+		Style    tcell.Style
+		Priority int
+	}
+)
+
+// built-in sign groups
+const (
+	SignGroupDiagnostic = "diagnostic"
+	SignGroupSearch     = "search"
+)
+
+// signWidth is the width of the sign column, the glyph and a space
+const signWidth = 2
+
+// PlaceSign places the sign of the group on the 0-based row, replacing the group's sign on that row.
+func (e *Editor) PlaceSign(group string, row int, sign Sign) {
+	if e.signs == nil {
+		e.signs = make(map[string]map[int]Sign)
+	}
+	if e.signs[group] == nil {
+		e.signs[group] = make(map[int]Sign)
+	}
+	e.signs[group][row] = sign
+}
+
+// RemoveSign removes the sign of the group on the 0-based row.
+func (e *Editor) RemoveSign(group string, row int) {
+	delete(e.signs[group], row)
+}
+
+// ClearSigns removes every sign of the group.
+func (e *Editor) ClearSigns(group string) {
+	delete(e.signs, group)
+}
+
+// hasSigns reports whether the sign column is shown, it's hidden without signs.
+func (e *Editor) hasSigns() bool {
+	for _, signs := range e.signs {
+		if len(signs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// signAt returns the sign shown on the row.
+func (e *Editor) signAt(row int) (Sign, bool) {
+	var sign Sign
+	found := false
+	for _, signs := range e.signs {
+		if s, ok := signs[row]; ok && (!found || s.Priority > sign.Priority) {
+			sign, found = s, true
+		}
+	}
+	return sign, found
+}
+
+// updateDiagnosticSigns marks the lines with a syntax error.
+func (e *Editor) updateDiagnosticSigns() {
+	e.ClearSigns(SignGroupDiagnostic)
+	style := tcell.StyleDefault.Foreground(theme.Current().Error)
+	for _, d := range e.diagnostics {
+		e.PlaceSign(SignGroupDiagnostic, d.Row, Sign{Glyph: 'E', Style: style, Priority: 20})
+	}
+}
+
+// updateSearchSigns marks the lines with a search match.
+func (e *Editor) updateSearchSigns() {
+	e.ClearSigns(SignGroupSearch)
+	style := tcell.StyleDefault.Foreground(theme.Current().Accent)
+	for _, m := range e.motionIndexes['n'] {
+		e.PlaceSign(SignGroupSearch, m[0], Sign{Glyph: '/', Style: style})
+	}
+}
@@ -0,0 +1,97 @@
+package editor
+
+import "strings"
+
+type (
+	// buffer is the text shared by the split views of an editor, with its undo history and change subscribers.
+	buffer struct {
+		views       []*Editor
+		changeFuncs []*changeFunc
+		undoStack   []undoStackItem
+		undoOffset  int
+		// bookmarks is the bookmarked rows, sorted
+		bookmarks []int
+	}
+
+	// changeFunc is a change subscriber, its pointer is the handle unsubscribing it
+	changeFunc struct {
+		f func(Change)
+	}
+)
+
+// Split returns a new view of the editor text with its own cursor and offsets, e.g. to keep a CTE visible
+// while editing the query below it. Edits in a view show in the others, the undo history and handlers are shared.
+func (e *Editor) Split() *Editor {
+	e.ensureSpans()
+	v := New(e.viewOptions...)
+	v.buffer = e.buffer
+	e.buffer.views = append(e.buffer.views, v)
+
+	v.viewModalFunc = e.viewModalFunc
+	v.statusFunc = e.statusFunc
+	v.commandFunc = e.commandFunc
+	v.completeFunc = e.completeFunc
+	v.statuslineFuncs = e.statuslineFuncs
+	v.delayDrawFunc = e.delayDrawFunc
+	v.onExitFunc = e.onExitFunc
+	v.options = e.options
+	v.readOnly = e.readOnly
+	v.dialect = e.dialect
+
+	v.setText(e.text, e.cursor)
+	v.offsets = e.offsets
+	e.buffer.updateBookmarkSigns()
+	return v
+}
+
+// Close detaches the split view from the text of the other views.
+func (e *Editor) Close() {
+	views := e.buffer.views
+	for i, v := range views {
+		if v == e {
+			e.buffer.views = append(views[:i:i], views[i+1:]...)
+			break
+		}
+	}
+	e.buffer = &buffer{views: []*Editor{e}}
+}
+
+// Views returns the number of views of the editor text.
+func (e *Editor) Views() int {
+	return len(e.buffer.views)
+}
+
+// syncViews sets the text of the other views after this view changed it from the old text.
+// The cursor and offsets of a view below the changed lines move with its text.
+func (e *Editor) syncViews(oldText string) {
+	if len(e.buffer.views) < 2 || oldText == e.text {
+		return
+	}
+
+	prefix := 0
+	for prefix < min(len(oldText), len(e.text)) && oldText[prefix] == e.text[prefix] {
+		prefix++
+	}
+	changedRow := strings.Count(oldText[:prefix], "\n")
+	delta := strings.Count(e.text, "\n") - strings.Count(oldText, "\n")
+
+	for _, v := range e.buffer.views {
+		if v == e {
+			continue
+		}
+		cursor, offsets := v.cursor, v.offsets
+		if cursor[0] > changedRow {
+			cursor[0] = max(cursor[0]+delta, changedRow)
+		}
+		if offsets[0] > changedRow {
+			offsets[0] = max(offsets[0]+delta, 0)
+		}
+		// the spans of this view are of the same text, unless they're still measured and the view keeps its cursor in them
+		if e.measured == nil {
+			cursor[0] = min(cursor[0], len(e.spansPerLines)-1)
+			cursor[1] = min(cursor[1], len(e.spansPerLines[cursor[0]])-1)
+		}
+		v.offsets = offsets
+		v.setText(e.text, cursor)
+	}
+}
@@ -0,0 +1,123 @@
+package editor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ngavinsir/sqluy/theme"
+	"github.com/rivo/tview"
+)
+
+// SetStatuslineFunc adds a status line segment, e.g. the file name, usable in the statusline options.
+func (e *Editor) SetStatuslineFunc(name string, f func() string) *Editor {
+	if e.statuslineFuncs == nil {
+		e.statuslineFuncs = make(map[string]func() string)
+	}
+	e.statuslineFuncs[name] = f
+	return e
+}
+
+// statuslineText joins the non empty segments with a space, in tview color tag format.
+func (e *Editor) statuslineText(names []string) string {
+	var texts []string
+	for _, name := range names {
+		if text := e.statuslineSegment(name); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, " ")
+}
+
+func (e *Editor) statuslineSegment(name string) string {
+	switch name {
+	case "mode":
+		modeColor := theme.Current().ModeNormal
+		if e.mode == ModeInsert {
+			modeColor = theme.Current().ModeInsert
+		} else if e.mode == ModeReplace {
+			modeColor = theme.Current().ModeReplace
+		}
+		return fmt.Sprintf("[%s]%s[-] mode", modeColor, e.mode.String())
+	case "readonly":
+		if e.readOnly {
+			return tview.Escape("[read-only]")
+		}
+		return ""
+	case "pending":
+		pending := e.pendingKeys()
+		if pending == "" {
+			return ""
+		}
+		return fmt.Sprintf("[%s](%s)[-]", theme.Current().Accent, tview.Escape(pending))
+	case "selection":
+		if e.mode != ModeVisual && e.mode != ModeVLine && e.mode != ModeVBlock {
+			return ""
+		}
+		lines, chars, bytes := e.selectionSize()
+		return fmt.Sprintf("%s, %s, %s", plural(lines, "line"), plural(chars, "char"), plural(bytes, "byte"))
+	case "search":
+		idx, total := e.searchPosition()
+		if idx == 0 {
+			return ""
+		}
+		if e.searchWrapped {
+			return fmt.Sprintf("[%s]W[-] match %d/%d", theme.Current().Accent, idx, total)
+		}
+		return fmt.Sprintf("match %d/%d", idx, total)
+	case "progress":
+		if e.measured != nil {
+			return "loading"
+		}
+		if percent, ok := e.SyntaxProgress(); ok {
+			return fmt.Sprintf("highlighting %d%%", percent)
+		}
+		return ""
+	case "diagnostic":
+		if len(e.diagnostics) == 0 {
+			return ""
+		}
+		for _, d := range e.diagnostics {
+			if d.Row == e.cursor[0] {
+				return fmt.Sprintf("[%s]%s[-]", theme.Current().Error, tview.Escape(d.String()))
+			}
+		}
+		return fmt.Sprintf("[%s]%d syntax errors[-]", theme.Current().Error, len(e.diagnostics))
+	case "position":
+		return fmt.Sprintf("x: %d/%d y: %d/%d", e.cursor[1]+1, len(e.spansPerLines[e.cursor[0]]), e.cursor[0]+1, len(e.spansPerLines))
+	}
+
+	if f := e.statuslineFuncs[name]; f != nil {
+		return tview.Escape(f())
+	}
+	return ""
+}
+
+// pendingKeys returns the command being typed as it was typed, e.g. "a2d3 while typing "a2d3w.
+func (e *Editor) pendingKeys() string {
+	var b strings.Builder
+	if e.register != 0 {
+		b.WriteString(`"` + string(e.register))
+	} else if e.waitingForRegister {
+		b.WriteString(`"`)
+	}
+
+	operatorKeys := min(e.operatorKeys, len(e.pending))
+	if e.operatorCount > 0 {
+		b.WriteString(strconv.Itoa(e.operatorCount))
+	}
+	b.WriteString(strings.Join(e.pending[:operatorKeys], ""))
+	if e.pendingCount > 0 {
+		b.WriteString(strconv.Itoa(e.pendingCount))
+	}
+	b.WriteString(strings.Join(e.pending[operatorKeys:], ""))
+	return b.String()
+}
+
+// plural formats the count of the noun, e.g. "1 line" or "2 lines".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
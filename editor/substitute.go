@@ -0,0 +1,414 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
+)
+
+type (
+	// substitution is a parsed :s command, the rows are the range it applies to.
+	// While confirming, row is the row of the line being substituted and matches are the matches
+	// of its text before the substitution, the ones up to current are decided in replaced.
+	substitution struct {
+		pattern     *regexp.Regexp
+		replacement string
+		global      bool
+		confirm     bool
+		first       int
+		last        int
+
+		row      int
+		line     string
+		text     string
+		matches  [][]int
+		replaced []bool
+		current  int
+		count    int
+		lines    int
+		lastRow  int    // the last substituted row
+		cursor   [2]int // the cursor before the substitution, kept when nothing is substituted
+		match    [3]int // the row and the first and last column of the candidate, highlighted
+	}
+)
+
+var (
+	rgSubstitute = regexp.MustCompile(`^(%|[.$]|\d+|'[<>])?(?:,([.$]|\d+|'[<>]))?\s*(?:substitute|s)([^\w\s\\"|].*)?$`)
+	rgEscape     = regexp.MustCompile(`\\.`)
+)
+
+// parseSubstitute parses a [range]s/pattern/replacement/[flags] command line, ok is false when it's
+// not a substitute command. The range is the cursor line by default, % is every line, and '<,'> are
+// the lines of the last visual selection. The flags are g for every match of the lines, c to confirm
+// each of them, and i or I to ignore the case or not, overriding the ignorecase and smartcase options.
+func (e *Editor) parseSubstitute(cmd string) (s *substitution, ok bool, err error) {
+	m := rgSubstitute.FindStringSubmatch(cmd)
+	if m == nil {
+		return nil, false, nil
+	}
+	if m[3] == "" {
+		return nil, true, errors.New("usage: [range]s/pattern/replacement/[gci]")
+	}
+
+	s = &substitution{}
+	s.first, s.last, err = e.substituteRange(m[1], m[2])
+	if err != nil {
+		return nil, true, err
+	}
+
+	delimiter := m[3][:1]
+	parts := splitUnescaped(m[3][1:], delimiter)
+	pattern := strings.ReplaceAll(parts[0], `\`+delimiter, delimiter)
+	if pattern == "" && e.searchHistory != nil && len(e.searchHistory.entries) > 0 {
+		pattern = regexp.QuoteMeta(e.searchHistory.entries[len(e.searchHistory.entries)-1])
+	}
+	if pattern == "" {
+		return nil, true, errors.New("editor: no previous pattern")
+	}
+	if len(parts) > 1 {
+		s.replacement = parts[1]
+	}
+
+	// smartcase looks at the letters of the pattern, not at escapes like \W
+	ignoreCase := e.ignoreCase(rgEscape.ReplaceAllString(pattern, ""))
+	if len(parts) > 2 {
+		for _, flag := range parts[2] {
+			switch flag {
+			case 'g':
+				s.global = true
+			case 'c':
+				s.confirm = true
+			case 'i':
+				ignoreCase = true
+			case 'I':
+				ignoreCase = false
+			default:
+				return nil, true, fmt.Errorf("editor: unknown substitute flag %q", flag)
+			}
+		}
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	s.pattern, err = regexp.Compile(pattern)
+	if err != nil {
+		return nil, true, fmt.Errorf("editor: invalid pattern: %w", err)
+	}
+	return s, true, nil
+}
+
+// substituteRange returns the rows of the range addresses, the cursor row without addresses.
+func (e *Editor) substituteRange(from, until string) (int, int, error) {
+	if from == "%" {
+		return 0, len(e.spansPerLines) - 1, nil
+	}
+	first, err := e.substituteAddress(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	last := first
+	if until != "" {
+		last, err = e.substituteAddress(until)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if first > last {
+		first, last = last, first
+	}
+	return first, last, nil
+}
+
+// substituteAddress returns the row of a range address, a 1-based line number, . the cursor line,
+// $ the last line, and '< or '> the first or last line of the last visual selection.
+func (e *Editor) substituteAddress(address string) (int, error) {
+	switch address {
+	case "", ".":
+		return e.cursor[0], nil
+	case "$":
+		return len(e.spansPerLines) - 1, nil
+	case "'<", "'>":
+		if e.lastVisual == nil {
+			return 0, errors.New("editor: no visual selection")
+		}
+		row := e.lastVisual[0]
+		if address == "'>" {
+			row = e.lastVisual[1]
+		}
+		return min(row, len(e.spansPerLines)-1), nil
+	}
+
+	n, err := strconv.Atoi(address)
+	if err != nil {
+		return 0, fmt.Errorf("editor: invalid address %s: %w", address, err)
+	}
+	return max(min(n, len(e.spansPerLines))-1, 0), nil
+}
+
+// splitUnescaped splits the text on the delimiters not escaped by a backslash into up to three parts,
+// the escapes are kept.
+func splitUnescaped(text, delimiter string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(text) && len(parts) < 2; i++ {
+		switch {
+		case text[i] == '\\':
+			i++
+		case strings.HasPrefix(text[i:], delimiter):
+			parts = append(parts, text[start:i])
+			start = i + len(delimiter)
+		}
+	}
+	return append(parts, text[start:])
+}
+
+// expandReplacement returns the replacement of the match, & and \0 are the matched text, \1 to \9
+// its groups, \n and \r break the line, \t is a tab, and a backslash escapes any other character.
+func expandReplacement(replacement, line string, match []int) string {
+	var b strings.Builder
+	group := func(n int) {
+		if 2*n+1 < len(match) && match[2*n] >= 0 {
+			b.WriteString(line[match[2*n]:match[2*n+1]])
+		}
+	}
+	for i := 0; i < len(replacement); i++ {
+		c := replacement[i]
+		if c == '&' {
+			group(0)
+			continue
+		}
+		if c != '\\' || i == len(replacement)-1 {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch c = replacement[i]; {
+		case c >= '0' && c <= '9':
+			group(int(c - '0'))
+		case c == 'n' || c == 'r':
+			b.WriteByte('\n')
+		case c == 't':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// substitutedLine returns the line with the matches replaced up to the n-th one, those replaced
+// is false for are kept.
+func (s *substitution) substitutedLine(n int) string {
+	var b strings.Builder
+	end := 0
+	for i, match := range s.matches[:n] {
+		if !s.replaced[i] {
+			continue
+		}
+		b.WriteString(s.line[end:match[0]])
+		b.WriteString(expandReplacement(s.replacement, s.line, match))
+		end = match[1]
+	}
+	b.WriteString(s.line[end:])
+	return b.String()
+}
+
+// lineText returns the text of the row without its line break.
+func (e *Editor) lineText(row int) string {
+	return e.textBefore([2]int{row, 0}, [2]int{row, len(e.spansPerLines[row]) - 1})
+}
+
+// findSubstitution moves the substitution to the next row of its range with a match from its row,
+// it reports whether there's one.
+func (e *Editor) findSubstitution(s *substitution) bool {
+	s.replaced = nil
+	for ; s.row <= s.last; s.row++ {
+		s.line = e.lineText(s.row)
+		s.matches = s.pattern.FindAllStringSubmatchIndex(s.line, -1)
+		if len(s.matches) == 0 {
+			continue
+		}
+		if !s.global {
+			s.matches = s.matches[:1]
+		}
+		s.text = s.line
+		s.replaced = make([]bool, len(s.matches))
+		s.current = 0
+		return true
+	}
+	return false
+}
+
+// replaceSubstitution replaces the text of the substitution row with its line substituted up to the
+// n-th match. A line broken by the replacement moves the rows after it, and the end of the range.
+func (e *Editor) replaceSubstitution(s *substitution, n int) {
+	text := s.substitutedLine(n)
+	lastRow := s.row + strings.Count(s.text, "\n")
+	e.ReplaceText(text, [2]int{s.row, 0}, [2]int{lastRow, len(e.spansPerLines[lastRow]) - 1})
+	s.last += strings.Count(text, "\n") - strings.Count(s.text, "\n")
+	s.text = text
+}
+
+// nextSubstitution moves to the next row once the matches of the row are decided, counting the row
+// if it was substituted.
+func (e *Editor) nextSubstitution(s *substitution) bool {
+	if s.current < len(s.matches) {
+		return true
+	}
+	s.endLine()
+	s.row += strings.Count(s.text, "\n") + 1
+	return e.findSubstitution(s)
+}
+
+// endLine counts the row if it was substituted.
+func (s *substitution) endLine() {
+	if slices.Contains(s.replaced, true) {
+		s.lines++
+		s.lastRow = s.row + strings.Count(s.text, "\n")
+	}
+}
+
+// substitute replaces the matches of the substitution in its range as a single undo step, stepping
+// through them to confirm each one with the c flag. The cursor ends on the last substituted line.
+func (e *Editor) substitute(s *substitution) error {
+	if e.readOnly {
+		return errors.New("editor is read-only")
+	}
+
+	s.row, s.cursor = s.first, e.cursor
+	if !e.findSubstitution(s) {
+		return fmt.Errorf("editor: pattern not found: %s", strings.TrimPrefix(s.pattern.String(), "(?i)"))
+	}
+
+	e.Begin()
+	if s.confirm {
+		e.confirmSubstitution(s)
+		return nil
+	}
+	e.substituteAll(s)
+	e.finishSubstitution(s)
+	return nil
+}
+
+// substituteAll replaces the undecided matches of the row and of the rows after it.
+func (e *Editor) substituteAll(s *substitution) {
+	for {
+		for i := s.current; i < len(s.matches); i++ {
+			s.replaced[i] = true
+			s.count++
+		}
+		s.current = len(s.matches)
+		e.replaceSubstitution(s, len(s.matches))
+		if !e.nextSubstitution(s) {
+			return
+		}
+	}
+}
+
+// finishSubstitution commits the substitution, leaving the cursor on the first non blank of the last
+// substituted line, and shows the number of substitutions.
+func (e *Editor) finishSubstitution(s *substitution) {
+	e.substitution = nil
+	e.cursor = s.cursor
+	if s.lines > 0 {
+		e.cursor = [2]int{s.lastRow, e.firstNonBlank(s.lastRow)}
+	}
+	e.setDesiredColumn(e.cursor, e.desiredWidth())
+	e.Commit()
+
+	if e.statusFunc == nil {
+		return
+	}
+	if s.count == 0 {
+		e.statusFunc("no substitutions")
+		return
+	}
+	e.statusFunc(plural(s.count, "substitution") + " on " + plural(s.lines, "line"))
+}
+
+// confirmSubstitution highlights the next match and asks on the command line whether to replace it,
+// y replaces it, n skips it, a replaces it and all the remaining ones, l replaces it and stops, and
+// q or esc stop.
+func (e *Editor) confirmSubstitution(s *substitution) {
+	e.substitution = s
+	e.showSubstitution(s)
+
+	se := NewPrompt(fmt.Sprintf("replace with %s (y/n/a/q/l)? ", s.replacement), WithKeymapper(e.keymapper))
+	SetPromptRect(se, e.Box)
+	se.SetDelayDrawFunc(e.delayDrawFunc)
+	stop := func() {
+		s.endLine()
+		e.searchEditor = nil
+		e.finishSubstitution(s)
+	}
+	se.onDoneFunc = func(*Editor, string) { stop() }
+	se.onExitFunc = stop
+	se.onTextChangedFunc = func(text string) {
+		if text == "" {
+			return
+		}
+
+		answer := text[len(text)-1]
+		switch answer {
+		case 'y', 'l':
+			s.replaced[s.current] = true
+			s.count++
+			s.current++
+			e.replaceSubstitution(s, s.current)
+		case 'n':
+			s.current++
+		case 'a':
+			e.substituteAll(s)
+			stop()
+			return
+		case 'q':
+		default:
+			return
+		}
+
+		if answer == 'q' || answer == 'l' {
+			stop()
+			return
+		}
+		if !e.nextSubstitution(s) {
+			stop()
+			return
+		}
+		e.showSubstitution(s)
+	}
+	e.searchEditor = se
+}
+
+// showSubstitution moves the cursor to the current match of the substitution, highlighting it.
+func (e *Editor) showSubstitution(s *substitution) {
+	match := s.matches[s.current]
+	prefix := s.substitutedLine(s.current)
+	prefix = prefix[:len(prefix)-(len(s.line)-match[0])]
+
+	row := s.row + strings.Count(prefix, "\n")
+	col := uniseg.GraphemeClusterCount(prefix[strings.LastIndex(prefix, "\n")+1:])
+	width := uniseg.GraphemeClusterCount(s.line[match[0]:match[1]])
+	s.match = [3]int{row, col, col + max(width, 1) - 1}
+	e.cursor = [2]int{row, col}
+	e.setDesiredColumn(e.cursor, e.desiredWidth())
+}
+
+func (e *Editor) substituteDecorator(x, y, width, height int) {
+	s := e.substitution
+	if s == nil || s.match[0] < y || s.match[0] >= y+height {
+		return
+	}
+
+	style := tcell.StyleDefault.Background(tview.Styles.MoreContrastBackgroundColor).Foreground(tview.Styles.PrimitiveBackgroundColor)
+	for col := s.match[1]; col <= s.match[2]; col++ {
+		e.decorations[[2]int{s.match[0], col}] = decoration{style: style, text: ""}
+	}
+}
@@ -0,0 +1,79 @@
+package editor
+
+type (
+	// undoStackItem is a text state, before and after are the cursor when the transaction
+	// leading to the state began and committed.
+	undoStackItem struct {
+		text   string
+		before [2]int
+		after  [2]int
+	}
+)
+
+// Begin starts an undo transaction, edits until the matching Commit are undone and redone as one step.
+// Transactions nest, only the outermost one records a step.
+func (e *Editor) Begin() {
+	e.ensureSpans()
+	e.undoDepth++
+	if e.undoDepth > 1 {
+		return
+	}
+
+	// text set outside a transaction, e.g. by SetText, becomes its own state
+	if len(e.buffer.undoStack) == 0 || e.buffer.undoStack[e.buffer.undoOffset].text != e.text {
+		e.pushUndo(undoStackItem{text: e.text, before: e.cursor, after: e.cursor})
+	}
+	e.undoBefore = e.cursor
+}
+
+// Commit ends the undo transaction started by Begin, recording a step if the text changed.
+func (e *Editor) Commit() {
+	if e.undoDepth == 0 {
+		return
+	}
+	e.undoDepth--
+	if e.undoDepth > 0 || e.buffer.undoStack[e.buffer.undoOffset].text == e.text {
+		return
+	}
+
+	e.pushUndo(undoStackItem{text: e.text, before: e.undoBefore, after: e.cursor})
+}
+
+// pushUndo drops the redoable states and appends the state.
+func (e *Editor) pushUndo(item undoStackItem) {
+	e.buffer.undoStack = append(e.buffer.undoStack[:min(e.buffer.undoOffset+1, len(e.buffer.undoStack))], item)
+	e.buffer.undoOffset = len(e.buffer.undoStack) - 1
+}
+
+func (e *Editor) Undo() {
+	if e.undoDepth > 0 {
+		return
+	}
+	// record the text set outside a transaction before moving through the states
+	e.Begin()
+	e.Commit()
+	if e.buffer.undoOffset < 1 {
+		return
+	}
+
+	n := max(e.buffer.undoOffset-e.getActionCount(), 0)
+	cursor := e.buffer.undoStack[n+1].before
+	e.buffer.undoOffset = n
+	e.SetTextAndNotify(e.buffer.undoStack[n].text, cursor)
+}
+
+func (e *Editor) Redo() {
+	if e.undoDepth > 0 {
+		return
+	}
+	// record the text set outside a transaction before moving through the states
+	e.Begin()
+	e.Commit()
+	if e.buffer.undoOffset >= len(e.buffer.undoStack)-1 {
+		return
+	}
+
+	n := min(e.buffer.undoOffset+e.getActionCount(), len(e.buffer.undoStack)-1)
+	e.buffer.undoOffset = n
+	e.SetTextAndNotify(e.buffer.undoStack[n].text, e.buffer.undoStack[n].after)
+}
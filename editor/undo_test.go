@@ -0,0 +1,121 @@
+package editor
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// randomEdit replaces a random range of the text with random text, lines included.
+func randomEdit(e *Editor, r *rand.Rand) {
+	position := func() [2]int {
+		row := r.IntN(len(e.spansPerLines))
+		return [2]int{row, r.IntN(len(e.spansPerLines[row]))}
+	}
+	from, until := position(), position()
+	if until[0] < from[0] || until[0] == from[0] && until[1] < from[1] {
+		from, until = until, from
+	}
+
+	const alphabet = "ab \n("
+	text := make([]byte, r.IntN(4))
+	for i := range text {
+		text[i] = alphabet[r.IntN(len(alphabet))]
+	}
+	e.ReplaceText(string(text), from, until)
+}
+
+// checkCursor fails the test if the cursor is outside the text.
+func checkCursor(t *testing.T, e *Editor, seed uint64) {
+	t.Helper()
+	if e.cursor[0] < 0 || e.cursor[0] >= len(e.spansPerLines) || e.cursor[1] < 0 || e.cursor[1] >= len(e.spansPerLines[e.cursor[0]]) {
+		t.Fatalf("seed %d: cursor %v is outside the text %q", seed, e.cursor, e.text)
+	}
+}
+
+func TestUndoRedoRandomEdits(t *testing.T) {
+	for seed := range uint64(50) {
+		r := rand.New(rand.NewPCG(seed, seed))
+		e := newTestEditor(t, "select a\nfrom b\n", [2]int{})
+
+		// texts[i] is the text after i edits, an edit replacing a text with itself records no step
+		texts := []string{e.text}
+		for range 30 {
+			randomEdit(e, r)
+			if e.text != texts[len(texts)-1] {
+				texts = append(texts, e.text)
+			}
+		}
+
+		for i := len(texts) - 2; i >= 0; i-- {
+			e.Undo()
+			if e.text != texts[i] {
+				t.Fatalf("seed %d: undo to state %d = %q, want %q", seed, i, e.text, texts[i])
+			}
+			checkCursor(t, e, seed)
+		}
+		e.Undo()
+		if e.text != texts[0] {
+			t.Fatalf("seed %d: undo past the first state changed the text to %q", seed, e.text)
+		}
+
+		for i := 1; i < len(texts); i++ {
+			e.Redo()
+			if e.text != texts[i] {
+				t.Fatalf("seed %d: redo to state %d = %q, want %q", seed, i, e.text, texts[i])
+			}
+			checkCursor(t, e, seed)
+		}
+		e.Redo()
+		if e.text != texts[len(texts)-1] {
+			t.Fatalf("seed %d: redo past the last state changed the text to %q", seed, e.text)
+		}
+	}
+}
+
+func TestUndoTransaction(t *testing.T) {
+	for seed := range uint64(50) {
+		r := rand.New(rand.NewPCG(seed, seed))
+		e := newTestEditor(t, "select a\nfrom b\n", [2]int{})
+		before := e.text
+
+		// nested transactions of random edits are one step
+		e.Begin()
+		for range 1 + r.IntN(5) {
+			e.Begin()
+			randomEdit(e, r)
+			e.Commit()
+		}
+		e.Commit()
+		after := e.text
+
+		e.Undo()
+		if e.text != before {
+			t.Fatalf("seed %d: undo = %q, want the text before the transaction %q", seed, e.text, before)
+		}
+		e.Redo()
+		if e.text != after {
+			t.Fatalf("seed %d: redo = %q, want the text after the transaction %q", seed, e.text, after)
+		}
+	}
+}
+
+func TestUndoDropsRedoAfterEdit(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 1))
+	e := newTestEditor(t, "select a\nfrom b\n", [2]int{})
+	e.ReplaceText("x", [2]int{0, 0}, [2]int{0, 0})
+	e.ReplaceText("y", [2]int{0, 0}, [2]int{0, 0})
+	e.Undo()
+
+	for e.text == "xselect a\nfrom b\n" {
+		randomEdit(e, r)
+	}
+	edited := e.text
+	e.Redo()
+	if e.text != edited {
+		t.Errorf("redo after an edit = %q, want the edited text %q", e.text, edited)
+	}
+	e.Undo()
+	if e.text != "xselect a\nfrom b\n" {
+		t.Errorf("undo after an edit = %q, want the text before it", e.text)
+	}
+}
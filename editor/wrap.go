@@ -0,0 +1,49 @@
+package editor
+
+// wrapsBefore reports whether the span at textX, past the start of the screen row at startX,
+// continues on the next screen row to fit within endX. The end of line sentinel takes a cell.
+func wrapsBefore(s span, textX, startX, endX int) bool {
+	width := s.width
+	if s.runes == nil {
+		width = 1
+	}
+	return textX > startX && textX+width > endX
+}
+
+// wrappedRows returns the screen rows the line takes when wrapped at the text width,
+// and the screen row of the span at col within them.
+func wrappedRows(spans []span, col, textWidth int) (rows int, colRow int) {
+	rows = 1
+	textX := 0
+	for i, s := range spans {
+		// the line ends at the sentinel, unless it's the cursor past the last span
+		if s.runes == nil && i > 0 && i != col {
+			break
+		}
+		if wrapsBefore(s, textX, 0, textWidth) {
+			rows++
+			textX = 0
+		}
+		if i == col {
+			colRow = rows - 1
+		}
+		textX += s.width
+	}
+	return rows, colRow
+}
+
+// scrollWrapped moves the row offset down until the wrapped rows from it to the cursor,
+// and the scrolloff lines after it, fit in the height.
+func (e *Editor) scrollWrapped(textWidth, h, scrollOff int) {
+	_, cursorRow := wrappedRows(e.spansPerLines[e.cursor[0]], e.cursor[1], textWidth)
+	needed := cursorRow + 1 + min(scrollOff, len(e.spansPerLines)-1-e.cursor[0])
+	for row := e.offsets[0]; row < e.cursor[0]; row++ {
+		rows, _ := wrappedRows(e.spansPerLines[row], -1, textWidth)
+		needed += rows
+	}
+	for needed > h && e.offsets[0] < e.cursor[0] {
+		rows, _ := wrappedRows(e.spansPerLines[e.offsets[0]], -1, textWidth)
+		needed -= rows
+		e.offsets[0]++
+	}
+}
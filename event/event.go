@@ -0,0 +1,98 @@
+// Package event is the publish/subscribe bus of the app events, the panes subscribe to the events they react to
+package event
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+type (
+	// QueryStarted is published when a query starts executing.
+	QueryStarted struct {
+		Query string
+	}
+
+	// QueryFinished is published when a query is done with its results, Err is set when it failed or was canceled.
+	QueryFinished struct {
+		Query    string
+		Headers  []string
+		Rows     []map[string]string
+		Duration time.Duration
+		Err      error
+	}
+
+	// ConnectionChanged is published when the app connects to another database.
+	ConnectionChanged struct {
+		Path string
+	}
+
+	// BufferSaved is published when the editor query is written to its file.
+	BufferSaved struct {
+		Path string
+	}
+
+	// SchemaRefreshed is published when the schema introspection is done, Err is set when it failed.
+	SchemaRefreshed struct {
+		Err error
+	}
+
+	// Modal asks for the text shown in a modal, Refocus gets the focus back once it's closed.
+	Modal struct {
+		Text    string
+		Refocus tview.Primitive
+	}
+
+	// DrawAt asks for a redraw at the given time, after running Fn on the UI goroutine.
+	// Primitives limits the redraw to them, the whole app is redrawn when it's empty.
+	DrawAt struct {
+		When       time.Time
+		Fn         func()
+		Primitives []tview.Primitive
+	}
+
+	// Bus dispatches the published events to the handlers subscribed to their type.
+	Bus struct {
+		mutex    sync.Mutex
+		handlers map[reflect.Type][]*handler
+	}
+
+	handler struct {
+		fn func(any)
+	}
+)
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[reflect.Type][]*handler)}
+}
+
+// Subscribe calls fn with the events of type T published on the bus, it returns the function unsubscribing it.
+func Subscribe[T any](b *Bus, fn func(T)) (unsubscribe func()) {
+	t := reflect.TypeFor[T]()
+	h := &handler{fn: func(e any) { fn(e.(T)) }}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.handlers[t] = append(b.handlers[t], h)
+
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		b.handlers[t] = slices.DeleteFunc(b.handlers[t], func(other *handler) bool { return other == h })
+	}
+}
+
+// Publish calls the handlers subscribed to the event type in the order they subscribed, on the goroutine
+// publishing it. Handlers must not block, most events are published on the UI goroutine.
+func (b *Bus) Publish(e any) {
+	b.mutex.Lock()
+	handlers := slices.Clone(b.handlers[reflect.TypeOf(e)])
+	b.mutex.Unlock()
+
+	for _, h := range handlers {
+		h.fn(e)
+	}
+}
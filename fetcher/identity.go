@@ -0,0 +1,207 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type (
+	// RowIdentity is the table behind the result set of a simple select query and the columns identifying
+	// its rows, e.g. to build the WHERE clause of an UPDATE or to follow a row across refreshes.
+	RowIdentity struct {
+		Table string
+		// Keys is the primary key of the table, or its first unique key without one.
+		// It's empty when none of them is fully in the result columns.
+		Keys []string
+	}
+)
+
+var (
+	rgFromTable = regexp.MustCompile(`(?is)^\s*select\b.*?\bfrom\s+("[^"]+"|\x60[^\x60]+\x60|[\w.]+)`)
+	// rgMultiRowSource matches the clauses a result row isn't a single table row with
+	rgMultiRowSource = regexp.MustCompile(`(?is)\b(join|union|intersect|except|group\s+by|distinct)\b|\bfrom\s+("[^"]+"|\x60[^\x60]+\x60|[\w.]+)(\s+(as\s+)?\w+)?\s*,`)
+	rgSelectList     = regexp.MustCompile(`(?is)^\s*select\s+(.*?)\s+from\b`)
+	// rgColumnRef matches a column of the table, optionally qualified, capturing its name
+	rgColumnRef = regexp.MustCompile(`(?s)^(?:(?:"[^"]+"|\x60[^\x60]+\x60|\w+)\s*\.\s*)?("[^"]+"|\x60[^\x60]+\x60|\w+)$`)
+	// rgAliased matches a result column with an alias, capturing the expression and the alias
+	rgAliased = regexp.MustCompile(`(?is)^(.+?)\s+(?:as\s+)?("[^"]+"|\x60[^\x60]+\x60|\w+)$`)
+	rgStar    = regexp.MustCompile(`^(?:(?:"[^"]+"|\x60[^\x60]+\x60|\w+)\s*\.\s*)?\*$`)
+)
+
+// TableFromQuery returns the table name of a simple select query, empty if the query isn't one.
+func TableFromQuery(query string) string {
+	m := rgFromTable.FindStringSubmatch(query)
+	if m == nil {
+		return ""
+	}
+	return strings.Trim(m[1], "\"`")
+}
+
+// RowIdentity resolves the table behind the result set of the query and the key identifying its rows
+// among the result columns.
+func (s SqliteFetcher) RowIdentity(ctx context.Context, query string, columns []string) (RowIdentity, error) {
+	table := TableFromQuery(query)
+	if table == "" || rgMultiRowSource.MatchString(query) {
+		return RowIdentity{}, errors.New("unknown table, only simple select query is supported")
+	}
+
+	identity := RowIdentity{Table: table}
+	keys, err := s.PrimaryKeys(ctx, table)
+	if err != nil {
+		return identity, err
+	}
+	if len(keys) > 0 && containsAll(columns, keys) && keysFromTable(query, keys) {
+		identity.Keys = keys
+		return identity, nil
+	}
+
+	uniqueKeys, err := s.UniqueKeys(ctx, table)
+	if err != nil {
+		return identity, err
+	}
+	for _, keys := range uniqueKeys {
+		if containsAll(columns, keys) && keysFromTable(query, keys) {
+			identity.Keys = keys
+			break
+		}
+	}
+	return identity, nil
+}
+
+// UniqueKeys returns the columns of every unique index of the table, skipping partial and expression indexes,
+// and the indexes of nullable columns, their NULL values aren't unique.
+func (s SqliteFetcher) UniqueKeys(ctx context.Context, table string) ([][]string, error) {
+	dbRows, err := s.db.QueryContext(ctx, `SELECT il.name, ii.name, coalesce(ti."notnull", 0)
+FROM pragma_index_list(?1) il JOIN pragma_index_info(il.name) ii
+LEFT JOIN pragma_table_info(?1) ti ON ti.name = ii.name
+WHERE il."unique" AND NOT il.partial AND il.origin <> 'pk'
+ORDER BY il.seq, ii.seqno`, table)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: error querying unique keys: %w", err)
+	}
+	defer dbRows.Close()
+
+	var keys [][]string
+	var last string
+	// skip is set when the last index has an expression or a nullable column
+	skip := false
+	for dbRows.Next() {
+		var index string
+		var column *string
+		var notNull bool
+		err = dbRows.Scan(&index, &column, &notNull)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite: error scanning unique keys: %w", err)
+		}
+		if index != last {
+			if skip {
+				keys = keys[:len(keys)-1]
+			}
+			last, skip = index, false
+			keys = append(keys, nil)
+		}
+		if column == nil || !notNull {
+			skip = true
+			continue
+		}
+		keys[len(keys)-1] = append(keys[len(keys)-1], *column)
+	}
+	if err = dbRows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite: error reading unique keys: %w", err)
+	}
+	if skip {
+		keys = keys[:len(keys)-1]
+	}
+
+	return keys, nil
+}
+
+// keysFromTable reports whether the result columns named like the keys are the key columns of the table,
+// and not an alias or an expression shadowing them, e.g. SELECT name AS id.
+func keysFromTable(query string, keys []string) bool {
+	m := rgSelectList.FindStringSubmatch(query)
+	if m == nil {
+		return false
+	}
+
+	// sources maps the result column names to the table column they select, empty for an expression
+	sources := make(map[string][]string)
+	star := false
+	for _, item := range splitSelectList(m[1]) {
+		item = strings.TrimSpace(item)
+		if rgStar.MatchString(item) {
+			star = true
+			continue
+		}
+		if ref := rgColumnRef.FindStringSubmatch(item); ref != nil {
+			name := unquoteIdentifier(ref[1])
+			sources[strings.ToLower(name)] = append(sources[strings.ToLower(name)], name)
+			continue
+		}
+		alias := rgAliased.FindStringSubmatch(item)
+		if alias == nil {
+			return false
+		}
+		name := strings.ToLower(unquoteIdentifier(alias[2]))
+		source := ""
+		if ref := rgColumnRef.FindStringSubmatch(strings.TrimSpace(alias[1])); ref != nil {
+			source = unquoteIdentifier(ref[1])
+		}
+		sources[name] = append(sources[name], source)
+	}
+
+	for _, key := range keys {
+		selected := sources[strings.ToLower(key)]
+		// a key only selected by the star is the table column, unless an alias takes its name
+		if len(selected) == 0 && star {
+			continue
+		}
+		if len(selected) != 1 || !strings.EqualFold(selected[0], key) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitSelectList splits the result columns of a select list by the commas outside parentheses and quotes.
+func splitSelectList(list string) []string {
+	var items []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(list); i++ {
+		c := list[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, list[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, list[start:])
+}
+
+func unquoteIdentifier(s string) string {
+	return strings.Trim(s, "\"`")
+}
+
+func containsAll(columns, keys []string) bool {
+	for _, key := range keys {
+		if !slices.Contains(columns, key) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package fetcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MetaCommands are the psql-like meta commands MetaQuery translates, with their usage.
+var MetaCommands = []string{
+	`\d [table]`,
+	`\dt [pattern]`,
+	`\dv [pattern]`,
+	`\di [pattern]`,
+	`\l`,
+}
+
+// MetaQuery translates a psql-like meta command, e.g. \dt or \d table, into the introspection query of the database.
+// The patterns of the listing commands are globs like users_*.
+func (s SqliteFetcher) MetaQuery(name, args string) (string, error) {
+	switch name {
+	case "d":
+		if args == "" {
+			return metaListQuery("'table', 'view'", ""), nil
+		}
+		return fmt.Sprintf(`SELECT name, type, "notnull" AS not_null, dflt_value AS "default", pk
+FROM pragma_table_info(%s)
+ORDER BY cid`, quoteString(strings.Trim(args, "\"`"))), nil
+	case "dt":
+		return metaListQuery("'table'", args), nil
+	case "dv":
+		return metaListQuery("'view'", args), nil
+	case "di":
+		return metaListQuery("'index'", args), nil
+	case "l":
+		return "SELECT name, file FROM pragma_database_list ORDER BY seq", nil
+	}
+	return "", fmt.Errorf("sqlite: unknown meta command \\%s", name)
+}
+
+func metaListQuery(types, pattern string) string {
+	query := fmt.Sprintf(`SELECT name, type, tbl_name AS "table"
+FROM sqlite_schema
+WHERE type IN (%s) AND name NOT LIKE 'sqlite\_%%' ESCAPE '\'`, types)
+	if pattern != "" {
+		query += " AND name GLOB " + quoteString(pattern)
+	}
+	return query + "\nORDER BY name"
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
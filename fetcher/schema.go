@@ -0,0 +1,60 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type (
+	// Schema is the tables and views of the database with their columns, sorted by name.
+	Schema struct {
+		Tables []Table
+	}
+
+	Table struct {
+		Name    string
+		Columns []string
+	}
+)
+
+// Table returns the table by its case insensitive name.
+func (s Schema) Table(name string) (Table, bool) {
+	for _, t := range s.Tables {
+		if strings.EqualFold(t.Name, name) {
+			return t, true
+		}
+	}
+	return Table{}, false
+}
+
+// Schema introspects the tables and views with their columns in the column order.
+func (s SqliteFetcher) Schema(ctx context.Context) (Schema, error) {
+	dbRows, err := s.db.QueryContext(ctx, `SELECT m.name, p.name
+FROM sqlite_schema m JOIN pragma_table_info(m.name) p
+WHERE m.type IN ('table', 'view')
+ORDER BY m.name, p.cid`)
+	if err != nil {
+		return Schema{}, fmt.Errorf("sqlite: error querying schema: %w", err)
+	}
+	defer dbRows.Close()
+
+	var schema Schema
+	for dbRows.Next() {
+		var table, column string
+		err = dbRows.Scan(&table, &column)
+		if err != nil {
+			return Schema{}, fmt.Errorf("sqlite: error scanning schema: %w", err)
+		}
+		if n := len(schema.Tables); n == 0 || schema.Tables[n-1].Name != table {
+			schema.Tables = append(schema.Tables, Table{Name: table})
+		}
+		t := &schema.Tables[len(schema.Tables)-1]
+		t.Columns = append(t.Columns, column)
+	}
+	if err = dbRows.Err(); err != nil {
+		return Schema{}, fmt.Errorf("sqlite: error reading schema: %w", err)
+	}
+
+	return schema, nil
+}
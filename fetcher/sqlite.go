@@ -4,7 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
 	_ "github.com/ncruces/go-sqlite3/embed"
@@ -12,53 +15,234 @@ import (
 
 type (
 	SqliteFetcher struct {
-		db *sql.DB
+		db   *sql.DB
+		path string
 	}
 )
 
-func NewSqliteFetcher() SqliteFetcher {
-	db, err := sql.Open("sqlite3", "./chinook.db")
+// batchInterval is how long the scanned rows are held before they're passed on as a batch
+const batchInterval = 100 * time.Millisecond
+
+var (
+	rgSelectQuery = regexp.MustCompile(`(?is)^\s*(select|with|values)\b`)
+	rgDDLQuery    = regexp.MustCompile(`(?is)(^|;)\s*(create|alter|drop)\b`)
+)
+
+func NewSqliteFetcher(path string) (SqliteFetcher, error) {
+	db, err := sql.Open("sqlite3", path)
 	if err != nil {
-		log.Fatal(err)
+		return SqliteFetcher{}, fmt.Errorf("fetcher: error opening %s: %w", path, err)
 	}
 
 	return SqliteFetcher{
-		db: db,
+		db:   db,
+		path: path,
+	}, nil
+}
+
+// Path returns the database file path.
+func (s SqliteFetcher) Path() string {
+	return s.path
+}
+
+func (s SqliteFetcher) Close() error {
+	return s.db.Close()
+}
+
+// Dialect returns the SQL dialect name of the driver.
+func (s SqliteFetcher) Dialect() string {
+	return "sqlite"
+}
+
+// Name returns the connection name shown in the status line.
+func (s SqliteFetcher) Name() string {
+	return "sqlite:" + filepath.Base(s.path)
+}
+
+// Count returns the single number selected by the query, e.g. a SELECT COUNT(*).
+func (s SqliteFetcher) Count(ctx context.Context, query string) (int64, error) {
+	var count int64
+	err := s.db.QueryRowContext(ctx, query).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite: error counting: %w", err)
+	}
+	return count, nil
+}
+
+// Exec executes the statements in a single transaction and returns the number of changed rows.
+func (s SqliteFetcher) Exec(ctx context.Context, query string) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite: error beginning transaction: %w", err)
 	}
+	defer tx.Rollback()
+
+	// total_changes is per connection, which the transaction holds on to
+	var before, after int64
+	err = tx.QueryRowContext(ctx, "SELECT total_changes()").Scan(&before)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite: error counting changes: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite: error executing: %w", err)
+	}
+	err = tx.QueryRowContext(ctx, "SELECT total_changes()").Scan(&after)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite: error counting changes: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite: error committing: %w", err)
+	}
+	return after - before, nil
+}
+
+func (s SqliteFetcher) Select(ctx context.Context, query string) ([]string, []string, []map[string]string, error) {
+	var cols, types []string
+	var rows []map[string]string
+	err := s.SelectBatches(ctx, query, func(c, t []string, batch []map[string]string) {
+		cols, types = c, t
+		rows = append(rows, batch...)
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return cols, types, rows, nil
 }
 
-func (s SqliteFetcher) Select(ctx context.Context, query string) ([]string, []map[string]string, error) {
+// SelectBatches runs the query and calls fn with the next scanned rows every batchInterval, so they can be shown
+// while the query still runs. The first call has no rows and comes as soon as the columns are known.
+// NULL values are missing from the rows.
+func (s SqliteFetcher) SelectBatches(ctx context.Context, query string, fn func(cols, types []string, rows []map[string]string)) error {
 	dbRows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
-		return nil, nil, fmt.Errorf("sqlite: error querying: %w", err)
+		return fmt.Errorf("sqlite: error querying: %w", err)
 	}
 	defer dbRows.Close()
 
 	cols, err := dbRows.Columns()
 	if err != nil {
-		return nil, nil, fmt.Errorf("sqlite: error getting columns: %w", err)
+		return fmt.Errorf("sqlite: error getting columns: %w", err)
 	}
 
+	colTypes, err := dbRows.ColumnTypes()
+	if err != nil {
+		return fmt.Errorf("sqlite: error getting column types: %w", err)
+	}
+	types := make([]string, len(colTypes))
+	for i, colType := range colTypes {
+		types[i] = colType.DatabaseTypeName()
+	}
+
+	fn(cols, types, nil)
+
 	var rows []map[string]string
+	flushed := time.Now()
 	for dbRows.Next() {
 		rowValues := make([]any, len(cols))
 		for i := range cols {
-			rowValues[i] = new(sql.RawBytes)
+			rowValues[i] = new(sql.NullString)
 		}
 
 		err = dbRows.Scan(rowValues...)
 		if err != nil {
-			return nil, nil, fmt.Errorf("sqlite: error scanning rows: %w", err)
+			return fmt.Errorf("sqlite: error scanning rows: %w", err)
 		}
 
+		// a NULL value is left out of the row, it reads as empty but the DML builders can tell it apart.
+		// It's told by the driver value, an empty string can scan to nil bytes too.
 		row := make(map[string]string)
 		for i, col := range rowValues {
-			colString := string(*col.(*sql.RawBytes))
-			row[cols[i]] = colString
+			v := col.(*sql.NullString)
+			if !v.Valid {
+				continue
+			}
+			row[cols[i]] = v.String
 		}
 
 		rows = append(rows, row)
+		if time.Since(flushed) >= batchInterval {
+			fn(cols, types, rows)
+			rows, flushed = nil, time.Now()
+		}
+	}
+	err = dbRows.Err()
+	if ctx.Err() != nil {
+		// the canceled query is interrupted, report why
+		err = ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("sqlite: error scanning rows: %w", err)
 	}
 
-	return cols, rows, nil
+	if len(rows) > 0 {
+		fn(cols, types, rows)
+	}
+	return nil
+}
+
+func (s SqliteFetcher) PrimaryKeys(ctx context.Context, table string) ([]string, error) {
+	dbRows, err := s.db.QueryContext(ctx, "SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk", table)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: error querying primary keys: %w", err)
+	}
+	defer dbRows.Close()
+
+	var keys []string
+	for dbRows.Next() {
+		var key string
+		err = dbRows.Scan(&key)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite: error scanning primary keys: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// ChangesSchema reports whether any statement of the query is a CREATE, ALTER, or DROP statement.
+func (s SqliteFetcher) ChangesSchema(query string) bool {
+	return rgDDLQuery.MatchString(query)
+}
+
+// SortQuery wraps a single select query with an ORDER BY of the column, false if the query can't be sorted.
+func (s SqliteFetcher) SortQuery(query, column string, desc bool) (string, bool) {
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\n")
+	if !rgSelectQuery.MatchString(query) || strings.Contains(query, ";") {
+		return "", false
+	}
+	order := "ASC"
+	if desc {
+		order = "DESC"
+	}
+	return fmt.Sprintf("SELECT * FROM (\n%s\n) ORDER BY %s %s", query, quoteIdentifier(column), order), true
+}
+
+// PageQuery wraps a single select query with limit and offset, false if the query can't be paginated.
+func (s SqliteFetcher) PageQuery(query string, limit, offset int) (string, bool) {
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\n")
+	if !rgSelectQuery.MatchString(query) || strings.Contains(query, ";") {
+		return "", false
+	}
+	return fmt.Sprintf("SELECT * FROM (\n%s\n) LIMIT %d OFFSET %d", query, limit, offset), true
+}
+
+// KeysetQuery wraps a single select query ordered by the key column with the page of rows after the key value,
+// false if the query can't be paginated. Unlike OFFSET, the rows before the page aren't read again.
+// The NULL keys sort first, so a descending page keeps them.
+func (s SqliteFetcher) KeysetQuery(query, column, after string, desc bool, limit int) (string, bool) {
+	query = strings.TrimRight(strings.TrimSpace(query), "; \t\n")
+	if !rgSelectQuery.MatchString(query) || strings.Contains(query, ";") {
+		return "", false
+	}
+	condition := fmt.Sprintf("%s > %s", quoteIdentifier(column), quoteString(after))
+	order := "ASC"
+	if desc {
+		condition = fmt.Sprintf("(%s < %s OR %s IS NULL)", quoteIdentifier(column), quoteString(after), quoteIdentifier(column))
+		order = "DESC"
+	}
+	return fmt.Sprintf("SELECT * FROM (\n%s\n) WHERE %s ORDER BY %s %s LIMIT %d", query, condition, quoteIdentifier(column), order, limit), true
 }
@@ -0,0 +1,221 @@
+// Package filepicker is a file browser with vim navigation to pick a file to open or save
+package filepicker
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+type (
+	// FilePicker lists a directory, j/k move, h goes to the parent, l or enter opens a directory or picks a file.
+	// On save mode, i edits the file name to save in the current directory.
+	FilePicker struct {
+		*tview.Flex
+		list       *tview.List
+		input      *tview.InputField
+		title      string
+		dir        string
+		entries    []os.DirEntry
+		save       bool
+		showHidden bool
+		selectFunc func(path string)
+		cancelFunc func()
+	}
+)
+
+// New returns a file picker listing the directory.
+func New(dir string) *FilePicker {
+	p := &FilePicker{
+		Flex:  tview.NewFlex().SetDirection(tview.FlexRow),
+		list:  tview.NewList().ShowSecondaryText(false).SetHighlightFullLine(true),
+		input: tview.NewInputField().SetLabel("name: "),
+		title: "open",
+	}
+	p.Flex.SetBorder(true).SetTitleAlign(tview.AlignLeft)
+	p.Flex.AddItem(p.list, 0, 1, true)
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+	p.setDir(abs)
+	return p
+}
+
+// SetTitle sets the action shown in the title before the directory, e.g. "export".
+func (p *FilePicker) SetTitle(title string) *FilePicker {
+	p.title = title
+	p.updateTitle()
+	return p
+}
+
+// SetSaveMode allows picking a file that doesn't exist yet, the name is the initial file name.
+func (p *FilePicker) SetSaveMode(name string) *FilePicker {
+	p.save = true
+	p.input.SetText(name)
+	p.Flex.AddItem(p.input, 1, 0, false)
+	return p
+}
+
+// SetSelectFunc sets the handler of the picked file path.
+func (p *FilePicker) SetSelectFunc(fn func(path string)) *FilePicker {
+	p.selectFunc = fn
+	return p
+}
+
+// SetCancelFunc sets the handler called when the picker is closed without picking a file.
+func (p *FilePicker) SetCancelFunc(fn func()) *FilePicker {
+	p.cancelFunc = fn
+	return p
+}
+
+func (p *FilePicker) Focus(delegate func(tview.Primitive)) {
+	delegate(p.list)
+}
+
+func (p *FilePicker) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return p.WrapInputHandler(func(event *tcell.EventKey, setFocus func(tview.Primitive)) {
+		if p.input.HasFocus() {
+			switch event.Key() {
+			case tcell.KeyEnter:
+				if name := strings.TrimSpace(p.input.GetText()); name != "" {
+					p.pick(filepath.Join(p.dir, name))
+				}
+			case tcell.KeyEsc:
+				setFocus(p.list)
+			default:
+				p.input.InputHandler()(event, setFocus)
+			}
+			return
+		}
+
+		switch event.Key() {
+		case tcell.KeyEsc:
+			p.cancel()
+			return
+		case tcell.KeyEnter:
+			p.open(p.list.GetCurrentItem())
+			return
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			p.setDir(filepath.Dir(p.dir))
+			return
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'j':
+				event = tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+			case 'k':
+				event = tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+			case 'g':
+				event = tcell.NewEventKey(tcell.KeyHome, 0, tcell.ModNone)
+			case 'G':
+				event = tcell.NewEventKey(tcell.KeyEnd, 0, tcell.ModNone)
+			case 'h', '-':
+				p.setDir(filepath.Dir(p.dir))
+				return
+			case 'l':
+				p.open(p.list.GetCurrentItem())
+				return
+			case '.':
+				p.showHidden = !p.showHidden
+				p.setDir(p.dir)
+				return
+			case 'i', 'a':
+				if p.save {
+					setFocus(p.input)
+				}
+				return
+			case 'q':
+				p.cancel()
+				return
+			}
+		}
+		p.list.InputHandler()(event, setFocus)
+	})
+}
+
+// setDir lists the directory, directories first, hidden entries are listed only when toggled with ".".
+func (p *FilePicker) setDir(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		p.Flex.SetTitle(" " + err.Error() + " ")
+		return
+	}
+
+	entries = slices.DeleteFunc(entries, func(e os.DirEntry) bool {
+		return !p.showHidden && strings.HasPrefix(e.Name(), ".")
+	})
+	slices.SortStableFunc(entries, func(a, b os.DirEntry) int {
+		if a.IsDir() != b.IsDir() {
+			if a.IsDir() {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.Name(), b.Name())
+	})
+
+	prev := p.dir
+	p.dir = dir
+	p.entries = entries
+	p.list.Clear()
+	p.list.AddItem("../", "", 0, nil)
+	for i, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			name += "/"
+		}
+		p.list.AddItem(tview.Escape(name), "", 0, nil)
+		// going up keeps the cursor on the directory we came from
+		if e.IsDir() && filepath.Join(dir, e.Name()) == prev {
+			p.list.SetCurrentItem(i + 1)
+		}
+	}
+	p.updateTitle()
+}
+
+// open goes into the directory of the list item, or picks its file.
+func (p *FilePicker) open(item int) {
+	if item == 0 {
+		p.setDir(filepath.Dir(p.dir))
+		return
+	}
+	if item > len(p.entries) {
+		return
+	}
+
+	entry := p.entries[item-1]
+	path := filepath.Join(p.dir, entry.Name())
+	if entry.IsDir() {
+		p.setDir(path)
+		return
+	}
+	p.pick(path)
+}
+
+func (p *FilePicker) pick(path string) {
+	// pick a path relative to the working directory when it's inside it
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	if p.selectFunc != nil {
+		p.selectFunc(path)
+	}
+}
+
+func (p *FilePicker) cancel() {
+	if p.cancelFunc != nil {
+		p.cancelFunc()
+	}
+}
+
+func (p *FilePicker) updateTitle() {
+	p.Flex.SetTitle(" " + p.title + ": " + tview.Escape(p.dir) + " ")
+}
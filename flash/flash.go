@@ -0,0 +1,46 @@
+// Package flash is the jump label generation shared by the editor and the dataviewer
+package flash
+
+import "slices"
+
+// Labels returns the labels of n targets ordered from the closest one. Runes reported by skip,
+// e.g. the characters continuing the typed pattern, don't start a label. With multiChar, the last
+// runes of the alphabet start two character labels when the alphabet runs out, otherwise the
+// farthest targets are unlabeled.
+func Labels(alphabet string, multiChar bool, n int, skip func(rune) bool) []string {
+	var runes, firsts []rune
+	for _, r := range alphabet {
+		if slices.Contains(runes, r) {
+			continue
+		}
+		runes = append(runes, r)
+		if !skip(r) {
+			firsts = append(firsts, r)
+		}
+	}
+
+	// prefixes is the number of runes starting two character labels
+	prefixes := 0
+	if multiChar {
+		for prefixes < len(firsts) && len(firsts)-prefixes+prefixes*len(runes) < n {
+			prefixes++
+		}
+	}
+
+	labels := make([]string, 0, min(n, len(firsts)+prefixes*len(runes)))
+	for _, r := range firsts[:len(firsts)-prefixes] {
+		if len(labels) == n {
+			return labels
+		}
+		labels = append(labels, string(r))
+	}
+	for _, p := range firsts[len(firsts)-prefixes:] {
+		for _, r := range runes {
+			if len(labels) == n {
+				return labels
+			}
+			labels = append(labels, string(p)+string(r))
+		}
+	}
+	return labels
+}
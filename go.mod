@@ -4,6 +4,7 @@ go 1.23.0
 
 require (
 	github.com/gdamore/tcell/v2 v2.7.5-0.20240415204149-88b9c25c3c5e
+	github.com/mattn/go-runewidth v0.0.15
 	github.com/ncruces/go-sqlite3 v0.17.1
 	github.com/ngavinsir/treesittergo v0.0.0-20241208075130-20468ca169ca
 	github.com/rivo/tview v0.0.0-20240616192244-23476fa0bab2
@@ -13,7 +14,6 @@ require (
 require (
 	github.com/gdamore/encoding v1.0.1 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
-	github.com/mattn/go-runewidth v0.0.15 // indirect
 	github.com/ncruces/julianday v1.0.0 // indirect
 	github.com/tetratelabs/wazero v1.8.2 // indirect
 	golang.org/x/sys v0.22.0 // indirect
@@ -0,0 +1,77 @@
+package keymap
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+type (
+	// Binding is a key sequence and the actions mapped to it, Args is the arguments per action.
+	// Macro is the keys a macro binding expands to.
+	Binding struct {
+		Keys    []string
+		Actions []string
+		Args    []Args
+		Macro   []string
+	}
+)
+
+// Groups returns the keymap group names, sorted.
+func (k Keymapper) Groups() []string {
+	groups := make([]string, 0, len(k.keyTreePerGroup))
+	for group := range k.keyTreePerGroup {
+		groups = append(groups, group)
+	}
+	slices.Sort(groups)
+	return groups
+}
+
+// Bindings returns the bindings of the group sorted by keys, nil if the group doesn't exist.
+func (k Keymapper) Bindings(group string) []Binding {
+	var bindings []Binding
+	k.keyTreePerGroup[group].walk(nil, func(node *keyTree, keys []string) {
+		bindings = append(bindings, Binding{Keys: keys, Actions: node.actions, Args: node.args, Macro: node.macro})
+	})
+	slices.SortFunc(bindings, func(a, b Binding) int {
+		return slices.Compare(a.Keys, b.Keys)
+	})
+	return bindings
+}
+
+// Table formats the bindings of the group as a table of keys and actions,
+// the effective ones with the merged user keymap.
+func (k Keymapper) Table(group string) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	w.Write([]byte("KEYS\tACTIONS\n"))
+	for _, binding := range k.Bindings(group) {
+		actions := make([]string, len(binding.Actions))
+		for i, action := range binding.Actions {
+			actions[i] = action
+			if len(binding.Args[i]) > 0 {
+				args, _ := json.Marshal(binding.Args[i])
+				actions[i] += " " + string(args)
+			}
+		}
+		if binding.Macro != nil {
+			actions = append(actions, "macro "+strings.Join(binding.Macro, ""))
+		}
+		w.Write([]byte(strings.Join(binding.Keys, " ") + "\t" + strings.Join(actions, ", ") + "\n"))
+	}
+	w.Flush()
+	return b.String()
+}
+
+func (k *keyTree) walk(prefix []string, fn func(node *keyTree, keys []string)) {
+	if k == nil {
+		return
+	}
+	if len(k.actions) > 0 || k.macro != nil {
+		fn(k, slices.Clone(prefix))
+	}
+	for key, child := range k.childs {
+		child.walk(append(prefix, key), fn)
+	}
+}
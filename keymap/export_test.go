@@ -0,0 +1,24 @@
+package keymap
+
+import "testing"
+
+func TestTableMerged(t *testing.T) {
+	k := New(testKeymap)
+	err := k.Merge(`{"keymaps": {"editor": [
+		{"keys": ["<C-d>"], "groups": ["n"], "action": "move_lines", "args": {"n": 20}},
+		{"keys": ["x"], "groups": ["n"]},
+		{"keys": ["Y"], "groups": ["n"], "macro": "y$"}
+	]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `KEYS   ACTIONS
+<C-d>  editor.move_lines {"n":20}
+Y      macro y$
+d d    editor.delete_line
+`
+	if got := k.Table("n"); got != want {
+		t.Errorf("Table(n) =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -16,38 +16,64 @@ type (
 			Action          string   `json:"action"`
 			AllPossibleKeys keys     `json:"keys"`
 			Groups          []string `json:"groups"`
+			Args            Args     `json:"args"`
+			// Macro is the keys the entry expands to instead of an action, e.g. "y$"
+			Macro string `json:"macro"`
 		} `json:"keymaps"`
 	}
 
 	keyTree struct {
 		childs  map[string]*keyTree
 		actions []string
+		args    []Args
+		macro   []string
 	}
 
+	// Args is the arguments of a parameterized action, e.g. {"action": "move_lines", "args": {"n": 10}}
+	Args map[string]any
+
 	Keymapper struct {
 		keyTreePerGroup map[string]*keyTree
 	}
 )
 
-func (k *keyTree) Add(keys []string, action string) {
+// MaxMacroDepth limits macros expanding to other macros, stopping recursive ones.
+const MaxMacroDepth = 10
+
+func (k *keyTree) Add(keys []string, action string, args Args) {
 	if k.childs == nil {
 		k.childs = make(map[string]*keyTree)
 	}
 	if len(keys) == 0 {
 		k.actions = append(k.actions, action)
+		k.args = append(k.args, args)
 		return
 	}
 	if len(keys) == 1 {
 		if k.childs[keys[0]] == nil {
 			k.childs[keys[0]] = &keyTree{}
 		}
-		k.childs[keys[0]].Add(nil, action)
+		k.childs[keys[0]].Add(nil, action, args)
 		return
 	}
 	if k.childs[keys[0]] == nil {
 		k.childs[keys[0]] = &keyTree{}
 	}
-	k.childs[keys[0]].Add(keys[1:], action)
+	k.childs[keys[0]].Add(keys[1:], action, args)
+}
+
+// AddMacro maps the keys to the macro keys.
+func (k *keyTree) AddMacro(keys, macro []string) {
+	for _, key := range keys {
+		if k.childs == nil {
+			k.childs = make(map[string]*keyTree)
+		}
+		if k.childs[key] == nil {
+			k.childs[key] = &keyTree{}
+		}
+		k = k.childs[key]
+	}
+	k.macro = macro
 }
 
 func (k *keyTree) Get(keys []string) ([]string, bool) {
@@ -60,6 +86,13 @@ func (k *keyTree) Get(keys []string) ([]string, bool) {
 	return k.childs[keys[0]].Get(keys[1:])
 }
 
+func (k *keyTree) node(keys []string) *keyTree {
+	if k == nil || len(keys) == 0 {
+		return k
+	}
+	return k.childs[keys[0]].node(keys[1:])
+}
+
 func (k *keyTree) String() string {
 	if k.actions != nil {
 		return fmt.Sprintf("%+v", k.actions)
@@ -84,20 +117,64 @@ func keyTreePerGroupFromJSONString(s string) map[string]*keyTree {
 	if err != nil {
 		panic("invalid key map json: " + err.Error())
 	}
+	addKeymaps(m, j)
+	return m
+}
 
+// Merge adds the bindings of the keymap json over the current ones, e.g. the user keymap over the defaults.
+// Keys bound in it replace their bindings of the same group, an entry without an action or a macro unbinds them.
+func (k Keymapper) Merge(s string) error {
+	var j keymapJSON
+	err := json.Unmarshal([]byte(s), &j)
+	if err != nil {
+		return fmt.Errorf("keymap: error parsing: %w", err)
+	}
+
+	// every binding of the merged keys is dropped first, the entries of the same keys add up like in one file
+	for _, keymaps := range j.Keymaps {
+		for _, keymap := range keymaps {
+			for _, group := range keymap.Groups {
+				for _, keys := range keymap.AllPossibleKeys.Keys {
+					if node := k.keyTreePerGroup[group].node(canonicalKeys(keys)); node != nil {
+						node.actions, node.args, node.macro = nil, nil, nil
+					}
+				}
+			}
+		}
+	}
+	addKeymaps(k.keyTreePerGroup, j)
+	return nil
+}
+
+func addKeymaps(m map[string]*keyTree, j keymapJSON) {
 	for namespace, keymaps := range j.Keymaps {
 		for _, keymap := range keymaps {
+			if keymap.Action == "" && keymap.Macro == "" {
+				continue
+			}
 			for _, group := range keymap.Groups {
 				if m[group] == nil {
 					m[group] = &keyTree{}
 				}
 				for _, k := range keymap.AllPossibleKeys.Keys {
-					m[group].Add(k, namespace+"."+keymap.Action)
+					keys := canonicalKeys(k)
+					if keymap.Macro != "" {
+						m[group].AddMacro(keys, ParseKeys(keymap.Macro))
+						continue
+					}
+					m[group].Add(keys, namespace+"."+keymap.Action, keymap.Args)
 				}
 			}
 		}
 	}
-	return m
+}
+
+func canonicalKeys(k []string) []string {
+	keys := make([]string, len(k))
+	for i, key := range k {
+		keys[i] = Canonical(key)
+	}
+	return keys
 }
 
 func (k Keymapper) Get(keys []string, group string) ([]string, bool) {
@@ -107,6 +184,43 @@ func (k Keymapper) Get(keys []string, group string) ([]string, bool) {
 	return k.keyTreePerGroup[group].Get(keys)
 }
 
+// Macro returns the keys the macro bound to the keys expands to, nil if it's not a macro.
+func (k Keymapper) Macro(keys []string, group string) []string {
+	node := k.keyTreePerGroup[group].node(keys)
+	if node == nil {
+		return nil
+	}
+	return node.macro
+}
+
+// Args returns the arguments of the action bound to the keys, nil if it has none.
+func (k Keymapper) Args(keys []string, group, action string) Args {
+	node := k.keyTreePerGroup[group].node(keys)
+	if node == nil {
+		return nil
+	}
+	for i, a := range node.actions {
+		if a == action {
+			return node.args[i]
+		}
+	}
+	return nil
+}
+
+// Int returns the integer argument, def if it's missing or not a number.
+func (a Args) Int(name string, def int) int {
+	if n, ok := a[name].(float64); ok {
+		return int(n)
+	}
+	return def
+}
+
+// String returns the string argument, empty if it's missing or not a string.
+func (a Args) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
 func (k *keys) UnmarshalJSON(data []byte) error {
 	var stringArray []string
 	err := json.Unmarshal(data, &stringArray)
@@ -0,0 +1,101 @@
+package keymap
+
+import (
+	"slices"
+	"testing"
+)
+
+const testKeymap = `{"keymaps": {"editor": [
+	{"keys": ["d", "d"], "groups": ["n"], "action": "delete_line"},
+	{"keys": ["<C-d>"], "groups": ["n", "v"], "action": "move_lines", "args": {"n": 10}},
+	{"keys": ["x"], "groups": ["n"], "action": "delete_char"}
+]}}`
+
+func TestMerge(t *testing.T) {
+	k := New(testKeymap)
+	err := k.Merge(`{"keymaps": {"editor": [
+		{"keys": ["ctrl+d"], "groups": ["n"], "action": "move_lines", "args": {"n": 20}},
+		{"keys": ["x"], "groups": ["n"]},
+		{"keys": ["g", "d"], "groups": ["n", "o"], "action": "delete_line"}
+	]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		keys  []string
+		group string
+		want  []string
+	}{
+		{[]string{"d", "d"}, "n", []string{"editor.delete_line"}},
+		// an overridden key replaces the default action instead of adding to it
+		{[]string{"<C-d>"}, "n", []string{"editor.move_lines"}},
+		{[]string{"<C-d>"}, "v", []string{"editor.move_lines"}},
+		{[]string{"x"}, "n", nil},
+		{[]string{"g", "d"}, "n", []string{"editor.delete_line"}},
+		{[]string{"g", "d"}, "o", []string{"editor.delete_line"}},
+	}
+	for _, tt := range tests {
+		if got, _ := k.Get(tt.keys, tt.group); !slices.Equal(got, tt.want) {
+			t.Errorf("Get(%q, %q) = %q, want %q", tt.keys, tt.group, got, tt.want)
+		}
+	}
+
+	if got := k.Args([]string{"<C-d>"}, "n", "editor.move_lines").Int("n", 0); got != 20 {
+		t.Errorf("normal <C-d> n = %d, want the merged 20", got)
+	}
+	if got := k.Args([]string{"<C-d>"}, "v", "editor.move_lines").Int("n", 0); got != 10 {
+		t.Errorf("visual <C-d> n = %d, want the default 10", got)
+	}
+}
+
+func TestMergeInvalid(t *testing.T) {
+	k := New(testKeymap)
+	if err := k.Merge(`{"keymaps": [`); err == nil {
+		t.Error("Merge of invalid json succeeded, want an error")
+	}
+	if got, _ := k.Get([]string{"x"}, "n"); !slices.Equal(got, []string{"editor.delete_char"}) {
+		t.Errorf("Get(x) after a failed merge = %q, want the default", got)
+	}
+}
+
+func TestMergeMacro(t *testing.T) {
+	k := New(testKeymap)
+	err := k.Merge(`{"keymaps": {"editor": [
+		{"keys": ["Y"], "groups": ["n"], "macro": "y$"},
+		{"keys": ["x"], "groups": ["n"], "macro": "<lt>dd"},
+		{"keys": ["<C-d>"], "groups": ["v"], "macro": "<Esc>dd"}
+	]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		keys  []string
+		group string
+		want  []string
+	}{
+		{[]string{"Y"}, "n", []string{"y", "$"}},
+		{[]string{"x"}, "n", []string{"<", "d", "d"}},
+		{[]string{"<C-d>"}, "v", []string{"<Esc>", "d", "d"}},
+		{[]string{"<C-d>"}, "n", nil},
+	}
+	for _, tt := range tests {
+		if got := k.Macro(tt.keys, tt.group); !slices.Equal(got, tt.want) {
+			t.Errorf("Macro(%q, %q) = %q, want %q", tt.keys, tt.group, got, tt.want)
+		}
+	}
+	// a macro replaces the default action of its keys
+	if got, _ := k.Get([]string{"x"}, "n"); got != nil {
+		t.Errorf("Get(x) = %q, want no action besides the macro", got)
+	}
+
+	// and an action replaces a macro
+	err = k.Merge(`{"keymaps": {"editor": [{"keys": ["Y"], "groups": ["n"], "action": "yank_line"}]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := k.Macro([]string{"Y"}, "n"); got != nil {
+		t.Errorf("Macro(Y) = %q after binding an action, want nil", got)
+	}
+}
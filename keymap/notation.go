@@ -0,0 +1,227 @@
+package keymap
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var (
+	// keyNames is the notation name of the special keys,
+	// tcell.KeyBackspace is left out as it's also ctrl+h
+	keyNames = map[tcell.Key]string{
+		tcell.KeyEnter:      "CR",
+		tcell.KeyEsc:        "Esc",
+		tcell.KeyTab:        "Tab",
+		tcell.KeyBackspace2: "BS",
+		tcell.KeyDelete:     "Del",
+		tcell.KeyInsert:     "Insert",
+		tcell.KeyUp:         "Up",
+		tcell.KeyDown:       "Down",
+		tcell.KeyLeft:       "Left",
+		tcell.KeyRight:      "Right",
+		tcell.KeyHome:       "Home",
+		tcell.KeyEnd:        "End",
+		tcell.KeyPgUp:       "PageUp",
+		tcell.KeyPgDn:       "PageDown",
+	}
+
+	// keyAliases maps the lowercased key names accepted in keymap.json to their notation name,
+	// both vim (<CR>, <BS>) and tcell (enter, backspace2) names are accepted
+	keyAliases = map[string]string{
+		"cr":         "CR",
+		"enter":      "CR",
+		"return":     "CR",
+		"esc":        "Esc",
+		"escape":     "Esc",
+		"tab":        "Tab",
+		"bs":         "BS",
+		"backspace":  "BS",
+		"backspace2": "BS",
+		"del":        "Del",
+		"delete":     "Del",
+		"ins":        "Insert",
+		"insert":     "Insert",
+		"up":         "Up",
+		"down":       "Down",
+		"left":       "Left",
+		"right":      "Right",
+		"home":       "Home",
+		"end":        "End",
+		"pageup":     "PageUp",
+		"pgup":       "PageUp",
+		"pagedown":   "PageDown",
+		"pgdn":       "PageDown",
+		"space":      " ",
+		"lt":         "<",
+		"bar":        "|",
+		"bslash":     "\\",
+	}
+)
+
+func init() {
+	for i := range 64 {
+		name := "F" + strconv.Itoa(i+1)
+		keyNames[tcell.KeyF1+tcell.Key(i)] = name
+		keyAliases[strings.ToLower(name)] = name
+	}
+}
+
+// EventName returns the canonical notation of the key event, e.g. "a", "<C-r>", "<S-Tab>", or "<CR>".
+func EventName(event *tcell.EventKey) string {
+	key, mods := event.Key(), event.Modifiers()
+	switch {
+	case key == tcell.KeyRune:
+		return notation(mods, string(event.Rune()))
+	case key == tcell.KeyBacktab:
+		return notation(mods|tcell.ModShift, "Tab")
+	case key == tcell.KeyNUL:
+		return notation(mods|tcell.ModCtrl, " ")
+	case keyNames[key] != "":
+		return notation(mods, keyNames[key])
+	case key >= tcell.KeyCtrlA && key <= tcell.KeyCtrlZ:
+		return notation(mods|tcell.ModCtrl, string(rune('a'+key-tcell.KeyCtrlA)))
+	}
+	return "<" + tcell.KeyNames[key] + ">"
+}
+
+// Canonical returns the canonical notation of a key in keymap.json.
+// Single characters are themselves, other keys are written as <C-r>, <S-Tab>, <Esc>, <CR>,
+// or as tcell names like ctrl+r and esc.
+func Canonical(key string) string {
+	mods, name, ok := parseKey(key)
+	if !ok {
+		return key
+	}
+	return notation(mods, name)
+}
+
+// ParseKeys splits a key sequence like "dd<C-r>" into its canonical keys,
+// a < not starting a key notation is the < key.
+func ParseKeys(s string) []string {
+	var keys []string
+	for s != "" {
+		if i := strings.Index(s, ">"); strings.HasPrefix(s, "<") && i > 1 {
+			if _, _, ok := parseKey(s[:i+1]); ok {
+				keys = append(keys, Canonical(s[:i+1]))
+				s = s[i+1:]
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(s)
+		keys = append(keys, s[:size])
+		s = s[size:]
+	}
+	return keys
+}
+
+// Event returns the key event of a canonical key, the reverse of EventName.
+func Event(key string) *tcell.EventKey {
+	mods, name, ok := parseKey(key)
+	if !ok {
+		return tcell.NewEventKey(tcell.KeyRune, []rune(key)[0], 0)
+	}
+
+	if r, size := utf8.DecodeRuneInString(name); size == len(name) {
+		switch {
+		case mods&tcell.ModCtrl != 0 && r == ' ':
+			return tcell.NewEventKey(tcell.KeyNUL, 0, mods)
+		case mods&tcell.ModCtrl != 0 && unicode.ToLower(r) >= 'a' && unicode.ToLower(r) <= 'z':
+			return tcell.NewEventKey(tcell.KeyCtrlA+tcell.Key(unicode.ToLower(r)-'a'), 0, mods)
+		}
+		return tcell.NewEventKey(tcell.KeyRune, r, mods)
+	}
+
+	if name == "Tab" && mods&tcell.ModShift != 0 {
+		return tcell.NewEventKey(tcell.KeyBacktab, 0, mods)
+	}
+	if name == "BS" {
+		return tcell.NewEventKey(tcell.KeyBackspace2, 0, mods)
+	}
+	for k, n := range keyNames {
+		if n == name {
+			return tcell.NewEventKey(k, 0, mods)
+		}
+	}
+	return tcell.NewEventKey(tcell.KeyRune, []rune(key)[0], 0)
+}
+
+// parseKey returns the modifiers and the notation name of a key in keymap.json,
+// ok is false if it's not a known key.
+func parseKey(key string) (mods tcell.ModMask, name string, ok bool) {
+	if utf8.RuneCountInString(key) <= 1 {
+		return 0, key, key != ""
+	}
+
+	var parts []string
+	if inner, ok := strings.CutPrefix(key, "<"); ok && strings.HasSuffix(inner, ">") {
+		parts = strings.Split(strings.TrimSuffix(inner, ">"), "-")
+	} else {
+		parts = strings.Split(key, "+")
+	}
+	// the key itself is the separator, e.g. <C-->
+	if len(parts) > 1 && parts[len(parts)-1] == "" {
+		parts = append(parts[:len(parts)-2], "-")
+	}
+
+	for _, p := range parts[:len(parts)-1] {
+		switch strings.ToLower(p) {
+		case "c", "ctrl":
+			mods |= tcell.ModCtrl
+		case "s", "shift":
+			mods |= tcell.ModShift
+		case "m", "a", "alt", "meta":
+			mods |= tcell.ModAlt
+		default:
+			return 0, "", false
+		}
+	}
+
+	name = parts[len(parts)-1]
+	if strings.EqualFold(name, "backtab") {
+		return mods | tcell.ModShift, "Tab", true
+	}
+	if alias, ok := keyAliases[strings.ToLower(name)]; ok {
+		return mods, alias, true
+	}
+	return mods, name, utf8.RuneCountInString(name) == 1
+}
+
+func notation(mods tcell.ModMask, name string) string {
+	mods &= tcell.ModCtrl | tcell.ModShift | tcell.ModAlt | tcell.ModMeta
+	if r, size := utf8.DecodeRuneInString(name); size == len(name) {
+		switch {
+		// ctrl combinations are case insensitive
+		case mods&tcell.ModCtrl != 0:
+			name = string(unicode.ToLower(r))
+		// shift is already applied to the character
+		case mods&tcell.ModShift != 0:
+			name = string(unicode.ToUpper(r))
+			mods &^= tcell.ModShift
+		}
+		if mods == 0 {
+			return name
+		}
+		if name == " " {
+			name = "Space"
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("<")
+	if mods&tcell.ModCtrl != 0 {
+		b.WriteString("C-")
+	}
+	if mods&(tcell.ModAlt|tcell.ModMeta) != 0 {
+		b.WriteString("M-")
+	}
+	if mods&tcell.ModShift != 0 {
+		b.WriteString("S-")
+	}
+	b.WriteString(name)
+	b.WriteString(">")
+	return b.String()
+}
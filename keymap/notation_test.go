@@ -0,0 +1,125 @@
+package keymap
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestEventName(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *tcell.EventKey
+		want  string
+	}{
+		{"rune", tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone), "a"},
+		{"shifted rune", tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModShift), "A"},
+		{"ctrl", tcell.NewEventKey(tcell.KeyCtrlR, 0, tcell.ModCtrl), "<C-r>"},
+		{"ctrl without the modifier", tcell.NewEventKey(tcell.KeyCtrlR, 0, tcell.ModNone), "<C-r>"},
+		{"ctrl space", tcell.NewEventKey(tcell.KeyNUL, 0, tcell.ModCtrl), "<C-Space>"},
+		{"alt rune", tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModAlt), "<M-x>"},
+		{"meta rune", tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModMeta), "<M-x>"},
+		{"alt shifted rune", tcell.NewEventKey(tcell.KeyRune, 'X', tcell.ModAlt|tcell.ModShift), "<M-X>"},
+		{"ctrl alt", tcell.NewEventKey(tcell.KeyCtrlA, 0, tcell.ModCtrl|tcell.ModAlt), "<C-M-a>"},
+		{"esc", tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone), "<Esc>"},
+		{"enter", tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), "<CR>"},
+		{"backspace", tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone), "<BS>"},
+		{"tab", tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone), "<Tab>"},
+		{"backtab", tcell.NewEventKey(tcell.KeyBacktab, 0, tcell.ModNone), "<S-Tab>"},
+		{"backtab with shift", tcell.NewEventKey(tcell.KeyBacktab, 0, tcell.ModShift), "<S-Tab>"},
+		{"shift arrow", tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModShift), "<S-Up>"},
+		{"ctrl shift arrow", tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModCtrl|tcell.ModShift), "<C-S-Right>"},
+		{"function key", tcell.NewEventKey(tcell.KeyF5, 0, tcell.ModNone), "<F5>"},
+		{"alt function key", tcell.NewEventKey(tcell.KeyF12, 0, tcell.ModAlt), "<M-F12>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EventName(tt.event); got != tt.want {
+				t.Errorf("EventName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"a", "a"},
+		{"<C-r>", "<C-r>"},
+		{"<c-R>", "<C-r>"},
+		{"ctrl+r", "<C-r>"},
+		{"Ctrl+Shift+Right", "<C-S-Right>"},
+		{"<S-C-Right>", "<C-S-Right>"},
+		{"<S-Tab>", "<S-Tab>"},
+		{"backtab", "<S-Tab>"},
+		{"<Backtab>", "<S-Tab>"},
+		{"<esc>", "<Esc>"},
+		{"escape", "<Esc>"},
+		{"<Enter>", "<CR>"},
+		{"<cr>", "<CR>"},
+		{"enter", "<CR>"},
+		{"backspace2", "<BS>"},
+		{"<A-x>", "<M-x>"},
+		{"alt+x", "<M-x>"},
+		{"<S-a>", "A"},
+		{"<C-M-a>", "<C-M-a>"},
+		{"<M-C-a>", "<C-M-a>"},
+		{"<C-Space>", "<C-Space>"},
+		{"<lt>", "<"},
+		{"<C-->", "<C-->"},
+		{"<f5>", "<F5>"},
+		// unknown notation is a key of its own
+		{"<X-a>", "<X-a>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := Canonical(tt.key); got != tt.want {
+				t.Errorf("Canonical(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		keys string
+		want []string
+	}{
+		{"dd", []string{"d", "d"}},
+		{"d2e", []string{"d", "2", "e"}},
+		{"ihi<Esc>", []string{"i", "h", "i", "<Esc>"}},
+		{"<c-r><CR>", []string{"<C-r>", "<CR>"}},
+		{"<lt><", []string{"<", "<"}},
+		{"a<b", []string{"a", "<", "b"}},
+		{"<>", []string{"<", ">"}},
+		{"<nope>", []string{"<", "n", "o", "p", "e", ">"}},
+		{"é<S-Tab>", []string{"é", "<S-Tab>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.keys, func(t *testing.T) {
+			if got := ParseKeys(tt.keys); !slices.Equal(got, tt.want) {
+				t.Errorf("ParseKeys(%q) = %q, want %q", tt.keys, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEventRoundTrip checks that the event of a canonical key is named the same key,
+// so macros and playback send what keymap.json maps.
+func TestEventRoundTrip(t *testing.T) {
+	keys := []string{
+		"a", "A", "<", " ", "<C-r>", "<C-Space>", "<M-x>", "<M-X>", "<C-M-a>",
+		"<Esc>", "<CR>", "<BS>", "<Tab>", "<S-Tab>", "<Del>", "<S-Up>", "<C-S-Right>", "<F1>", "<M-F12>",
+	}
+	for _, key := range keys {
+		if got := EventName(Event(key)); got != key {
+			t.Errorf("EventName(Event(%q)) = %q", key, got)
+		}
+	}
+}
@@ -3,28 +3,107 @@ package main
 import (
 	"context"
 	_ "embed"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
 	"sync"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
 	"github.com/ngavinsir/sqluy/app"
+	"github.com/ngavinsir/sqluy/config"
+	"github.com/ngavinsir/sqluy/theme"
 	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
 )
 
 func main() {
+	pprofAddr := flag.String("pprof", "", "")
+	execute := flag.String("execute", "", "query executed at startup, - reads it from stdin")
+	// flags without usage are hidden
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+		flag.VisitAll(func(f *flag.Flag) {
+			if f.Usage != "" {
+				fmt.Fprintf(flag.CommandLine.Output(), "  -%s\n    \t%s\n", f.Name, f.Usage)
+			}
+		})
+	}
+	flag.Parse()
+
+	// expose net/http/pprof for profiling, e.g. --pprof localhost:6060
+	if *pprofAddr != "" {
+		go func() {
+			log.Println(http.ListenAndServe(*pprofAddr, nil))
+		}()
+	}
+
+	// the log goes to a file while the terminal runs the UI, the plugin stderr too
+	logFile, err := config.OpenLog()
+	if err != nil {
+		log.SetOutput(io.Discard)
+	} else {
+		defer logFile.Close()
+		log.SetOutput(logFile)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	// a query piped to stdin is loaded into the editor, the terminal is still read from /dev/tty
+	query := *execute
+	if query == "-" || query == "" && !isTerminal(os.Stdin) {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			panic(err)
+		}
+		query = string(b)
+	}
+	if query != "" {
+		cfg.Dashboard = false
+	}
+	err = theme.Set(cfg.Theme)
+	if err != nil {
+		panic(err)
+	}
+
+	// keep tview (uniseg) and tcell (runewidth) agreeing on the width of ambiguous characters
+	if cfg.AmbiguousWidth == "wide" {
+		uniseg.EastAsianAmbiguousWidth = 2
+		runewidth.DefaultCondition.EastAsianWidth = true
+		runewidth.DefaultCondition.CreateLUT()
+	}
+
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(context.Background())
 
 	application := tview.NewApplication()
-	a := app.New(ctx, &wg, application)
+	a := app.New(ctx, &wg, application, cfg)
+	if query != "" {
+		a.SetQuery(query)
+		if *execute != "" {
+			a.Execute()
+		}
+	}
 
 	application.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyLF {
 			event = tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModCtrl)
 		}
+		// ctrl+c cancels the running query instead of quitting
+		if event.Key() == tcell.KeyCtrlC && a.CancelExecution() {
+			return nil
+		}
 		return event
 	})
 
-	err := application.SetRoot(a, true).Run()
+	err = application.SetRoot(a, true).Run()
 	cancel()
 	wg.Wait()
 
@@ -32,3 +111,12 @@ func main() {
 		panic(err)
 	}
 }
+
+// isTerminal reports whether the file is a terminal rather than a pipe or a regular file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return true
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,118 @@
+// Package playback drives tview primitives on a tcell simulation screen with scripted key sequences,
+// so vim behaviors can be replayed deterministically and asserted on.
+package playback
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ngavinsir/sqluy/keymap"
+	"github.com/rivo/tview"
+)
+
+type (
+	// Harness routes key events to the root primitive like tview.Application does, without the event loop.
+	Harness struct {
+		screen    tcell.SimulationScreen
+		root      tview.Primitive
+		focus     tview.Primitive
+		idleFuncs []func()
+	}
+)
+
+// New creates a harness with the root primitive filling a width x height simulation screen, focusing the root.
+func New(root tview.Primitive, width, height int) (*Harness, error) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	err := screen.Init()
+	if err != nil {
+		return nil, fmt.Errorf("playback: error initializing screen: %w", err)
+	}
+	screen.SetSize(width, height)
+
+	h := &Harness{
+		screen: screen,
+		root:   root,
+	}
+	root.SetRect(0, 0, width, height)
+	h.SetFocus(root)
+	return h, nil
+}
+
+// OnIdle adds a function called after every key, e.g. waiting for async work like editor motion indexes.
+func (h *Harness) OnIdle(f func()) *Harness {
+	h.idleFuncs = append(h.idleFuncs, f)
+	return h
+}
+
+// SetFocus moves the focus like tview.Application.SetFocus.
+func (h *Harness) SetFocus(p tview.Primitive) {
+	if h.focus != nil {
+		h.focus.Blur()
+	}
+	h.focus = p
+	p.Focus(h.SetFocus)
+}
+
+// Keys parses the script in the keymap notation, e.g. "ihello<Esc>dd<C-n>", and sends every key to the root.
+// Use <lt> for a literal "<".
+func (h *Harness) Keys(script string) {
+	for _, key := range keymap.ParseKeys(script) {
+		h.Key(keymap.Event(key))
+	}
+}
+
+// Key sends a key event to the root primitive, then draws the screen.
+func (h *Harness) Key(event *tcell.EventKey) {
+	if handler := h.root.InputHandler(); handler != nil {
+		handler(event, h.SetFocus)
+	}
+	for _, f := range h.idleFuncs {
+		f()
+	}
+	h.Draw()
+}
+
+// Draw renders the root primitive to the simulation screen.
+func (h *Harness) Draw() {
+	h.screen.Clear()
+	h.root.Draw(h.screen)
+	h.screen.Show()
+}
+
+// Line returns the rendered text of the screen row, with trailing spaces trimmed.
+func (h *Harness) Line(y int) string {
+	cells, width, _ := h.screen.GetContents()
+	var b strings.Builder
+	for x := range width {
+		cell := cells[y*width+x]
+		if len(cell.Runes) == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteString(string(cell.Runes))
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// Lines returns every rendered screen row.
+func (h *Harness) Lines() []string {
+	_, _, height := h.screen.GetContents()
+	lines := make([]string, height)
+	for y := range lines {
+		lines[y] = h.Line(y)
+	}
+	return lines
+}
+
+// Cell returns the rendered text and style of the screen cell.
+func (h *Harness) Cell(x, y int) (string, tcell.Style) {
+	cells, width, _ := h.screen.GetContents()
+	cell := cells[y*width+x]
+	return string(cell.Runes), cell.Style
+}
+
+// Cursor returns the shown terminal cursor position, false if it's hidden.
+func (h *Harness) Cursor() (int, int, bool) {
+	return h.screen.GetCursor()
+}
@@ -0,0 +1,114 @@
+package playback_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ngavinsir/sqluy/editor"
+	"github.com/ngavinsir/sqluy/keymap"
+	"github.com/ngavinsir/sqluy/playback"
+)
+
+// newEditor returns an editor with the default keymap, its motion indexes built for the text.
+func newEditor(t *testing.T, text string, cursor [2]int) *editor.Editor {
+	t.Helper()
+	b, err := os.ReadFile("../app/keymap.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := editor.New(editor.WithKeymapper(keymap.New(string(b))))
+	e.SetText(text, cursor)
+	e.WaitMotionIndexes()
+	return e
+}
+
+func TestEditorSession(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		cursor     [2]int
+		keys       string
+		wantText   string
+		wantCursor [2]int
+		// wantLine is the start of the first text row, after the border
+		wantLine string
+	}{
+		{
+			name:       "change word",
+			text:       "select id from users\n",
+			keys:       "wcwname<esc>",
+			wantText:   "select name from users\n",
+			wantCursor: [2]int{0, 11},
+			wantLine:   "1 select name from users",
+		},
+		{
+			name:       "delete line",
+			text:       "select id\nfrom users\nwhere id = 1\n",
+			keys:       "jdd",
+			wantText:   "select id\nwhere id = 1\n",
+			wantCursor: [2]int{1, 0},
+			wantLine:   "1 select id",
+		},
+		{
+			name:       "undo delete",
+			text:       "select 1\n",
+			keys:       "wxu",
+			wantText:   "select 1\n",
+			wantCursor: [2]int{0, 7},
+			wantLine:   "1 select 1",
+		},
+		{
+			name:       "paste yanked line",
+			text:       "select 1\nfrom t\n",
+			keys:       "Vyjp",
+			wantText:   "select 1\nfrom t\nselect 1\n",
+			wantCursor: [2]int{2, 0},
+			wantLine:   "2 select 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newEditor(t, tt.text, tt.cursor)
+			h, err := playback.New(e, 40, 8)
+			if err != nil {
+				t.Fatal(err)
+			}
+			h.OnIdle(e.WaitMotionIndexes)
+
+			h.Keys(tt.keys)
+			if got := e.GetFullText(); got != tt.wantText {
+				t.Errorf("text = %q, want %q", got, tt.wantText)
+			}
+			if got := e.GetCursor(); got != tt.wantCursor {
+				t.Errorf("cursor = %v, want %v", got, tt.wantCursor)
+			}
+			if got := h.Line(1); !strings.HasPrefix(got, "║"+tt.wantLine) {
+				t.Errorf("line 1 = %q, want it to start with %q", got, "║"+tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestEditorSessionCells(t *testing.T) {
+	e := newEditor(t, "select id\nfrom users\n", [2]int{})
+	h, err := playback.New(e, 40, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.OnIdle(e.WaitMotionIndexes)
+
+	h.Keys("jw")
+	// the border, the line number, and a space come before the text
+	x, y, ok := h.Cursor()
+	if !ok || x != 8 || y != 2 {
+		t.Errorf("screen cursor = %d, %d, %v, want 8, 2, true", x, y, ok)
+	}
+	if s, _ := h.Cell(8, 2); s != "u" {
+		t.Errorf("cell under the cursor = %q, want %q", s, "u")
+	}
+	if got := h.Line(6); !strings.Contains(got, "NORMAL") {
+		t.Errorf("status line = %q, want the NORMAL mode", got)
+	}
+}
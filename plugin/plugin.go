@@ -0,0 +1,290 @@
+// Package plugin runs the experimental plugins, executables exchanging JSON-RPC 2.0 messages with the app
+// over their stdin and stdout, one message per line. Their stderr goes to the app log, the log.txt file
+// next to the config file.
+//
+// The app sends the initialize request first, then the command/run requests of the commands the plugin
+// registered and the query/started, query/finished, connection/changed, and buffer/saved notifications.
+// The plugin sends the command/register, key/register, and status/show requests.
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"sync"
+)
+
+type (
+	// Message is a JSON-RPC request, notification, or response, a notification has no ID.
+	Message struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      *int64          `json:"id,omitempty"`
+		Method  string          `json:"method,omitempty"`
+		Params  json.RawMessage `json:"params,omitempty"`
+		Result  json.RawMessage `json:"result,omitempty"`
+		Error   *Error          `json:"error,omitempty"`
+	}
+
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+
+	// Handler answers the requests and notifications of a plugin, the result of a notification is dropped.
+	// It's called on the goroutine reading the plugin output, one message at a time.
+	Handler func(p *Plugin, method string, params json.RawMessage) (any, error)
+
+	Plugin struct {
+		name       string
+		cmd        *exec.Cmd
+		stdin      io.WriteCloser
+		handler    Handler
+		writeMutex sync.Mutex
+		mutex      sync.Mutex
+		nextID     int64
+		pending    map[int64]chan Message
+		// notifications are written by writeLoop, the app isn't blocked by a plugin slow to read them
+		notifications chan Message
+		closed        bool
+		done          chan struct{}
+	}
+)
+
+const (
+	codeMethodNotFound = -32601
+	codeError          = -32000
+
+	notificationBuffer = 256
+)
+
+// ErrMethodNotFound is returned by a handler for the methods it doesn't know.
+var ErrMethodNotFound = errors.New("method not found")
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Start runs the plugin command, the plugin is stopped when the context is done.
+func Start(ctx context.Context, name, command string, args []string, handler Handler) (*Plugin, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Stderr = log.Writer()
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin: error starting %s: %w", name, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin: error starting %s: %w", name, err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("plugin: error starting %s: %w", name, err)
+	}
+
+	p := &Plugin{
+		name:          name,
+		cmd:           cmd,
+		stdin:         stdin,
+		handler:       handler,
+		pending:       make(map[int64]chan Message),
+		notifications: make(chan Message, notificationBuffer),
+		done:          make(chan struct{}),
+	}
+	go p.readLoop(stdout)
+	go p.writeLoop()
+	return p, nil
+}
+
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// Call sends the request and decodes its result into result, unless it's nil.
+func (p *Plugin) Call(ctx context.Context, method string, params, result any) error {
+	p.mutex.Lock()
+	p.nextID++
+	id := p.nextID
+	response := make(chan Message, 1)
+	p.pending[id] = response
+	p.mutex.Unlock()
+	defer func() {
+		p.mutex.Lock()
+		delete(p.pending, id)
+		p.mutex.Unlock()
+	}()
+
+	err := p.send(Message{ID: &id, Method: method}, params)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.done:
+		return fmt.Errorf("plugin: %s exited", p.name)
+	case m := <-response:
+		if m.Error != nil {
+			return fmt.Errorf("plugin: %s: %w", p.name, m.Error)
+		}
+		if result == nil || len(m.Result) == 0 {
+			return nil
+		}
+		err := json.Unmarshal(m.Result, result)
+		if err != nil {
+			return fmt.Errorf("plugin: error decoding the %s result of %s: %w", method, p.name, err)
+		}
+		return nil
+	}
+}
+
+// Notify queues the notification without waiting for the plugin, it's dropped when the plugin is too slow
+// to read the queued ones.
+func (p *Plugin) Notify(method string, params any) error {
+	m := Message{JSONRPC: "2.0", Method: method}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("plugin: error encoding the %s params: %w", method, err)
+	}
+	m.Params = b
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed {
+		return fmt.Errorf("plugin: %s is closed", p.name)
+	}
+	select {
+	case <-p.done:
+		return fmt.Errorf("plugin: %s exited", p.name)
+	case p.notifications <- m:
+		return nil
+	default:
+		return fmt.Errorf("plugin: %s is too slow, %s is dropped", p.name, method)
+	}
+}
+
+// Close closes the plugin stdin once the queued notifications are written, asking it to exit.
+func (p *Plugin) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.closed {
+		p.closed = true
+		close(p.notifications)
+	}
+}
+
+func (p *Plugin) send(m Message, params any) error {
+	m.JSONRPC = "2.0"
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("plugin: error encoding the %s params: %w", m.Method, err)
+		}
+		m.Params = b
+	}
+	return p.write(m)
+}
+
+func (p *Plugin) write(m Message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("plugin: error encoding a message to %s: %w", p.name, err)
+	}
+
+	p.writeMutex.Lock()
+	defer p.writeMutex.Unlock()
+	_, err = p.stdin.Write(append(b, '\n'))
+	if err != nil {
+		return fmt.Errorf("plugin: error writing to %s: %w", p.name, err)
+	}
+	return nil
+}
+
+// writeLoop writes the queued notifications until the plugin is closed.
+func (p *Plugin) writeLoop() {
+	for m := range p.notifications {
+		err := p.write(m)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	err := p.stdin.Close()
+	if err != nil {
+		log.Printf("plugin: error closing %s: %s", p.name, err)
+	}
+}
+
+// readLoop dispatches the plugin messages until its stdout is closed, then waits for it to exit.
+func (p *Plugin) readLoop(stdout io.Reader) {
+	defer close(p.done)
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var m Message
+		err := json.Unmarshal(scanner.Bytes(), &m)
+		if err != nil {
+			log.Printf("plugin: error decoding a message from %s: %s", p.name, err)
+			continue
+		}
+
+		if m.Method == "" {
+			if m.ID == nil {
+				continue
+			}
+			p.mutex.Lock()
+			response := p.pending[*m.ID]
+			p.mutex.Unlock()
+			if response != nil {
+				response <- m
+			}
+			continue
+		}
+		p.handle(m)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("plugin: error reading from %s: %s", p.name, err)
+	}
+	err := p.cmd.Wait()
+	if err != nil {
+		log.Printf("plugin: %s exited: %s", p.name, err)
+	}
+}
+
+// handle answers the request with the handler result, or runs the handler of the notification.
+func (p *Plugin) handle(m Message) {
+	result, err := p.handler(p, m.Method, m.Params)
+	if m.ID == nil {
+		if err != nil {
+			log.Printf("plugin: error handling %s of %s: %s", m.Method, p.name, err)
+		}
+		return
+	}
+
+	response := Message{JSONRPC: "2.0", ID: m.ID}
+	switch {
+	case errors.Is(err, ErrMethodNotFound):
+		response.Error = &Error{Code: codeMethodNotFound, Message: fmt.Sprintf("%s: %s", err, m.Method)}
+	case err != nil:
+		response.Error = &Error{Code: codeError, Message: err.Error()}
+	default:
+		b, err := json.Marshal(result)
+		if err != nil {
+			response.Error = &Error{Code: codeError, Message: err.Error()}
+			break
+		}
+		response.Result = b
+	}
+	err = p.write(response)
+	if err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,112 @@
+// Package register is the register file shared by the editor and the dataviewer
+package register
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/ngavinsir/sqluy/clipboard"
+)
+
+const (
+	// Unnamed is the register used when no register is selected, it's backed by the system clipboard
+	Unnamed = '"'
+	// Yanked is the register holding the last yanked text
+	Yanked = '0'
+	// BlackHole is the register that discards the text written to it and reads empty
+	BlackHole = '_'
+)
+
+// Type is how the register text is pasted.
+type Type int
+
+const (
+	// Charwise text is pasted inside the cursor line
+	Charwise Type = iota
+	// Linewise text is whole lines pasted above or below the cursor line, it always ends with a line break
+	Linewise
+	// Blockwise text is the rows of a rectangular selection, pasted at the same column of the rows from the cursor
+	Blockwise
+)
+
+type entry struct {
+	text string
+	typ  Type
+}
+
+var (
+	mu        sync.Mutex
+	registers = map[rune]entry{}
+	// clipboardText is the system clipboard text when the unnamed register was last set,
+	// a different clipboard text is copied outside of the app since then.
+	clipboardText string
+)
+
+// Valid reports whether the name is a register: ", _, 0-9, or a-z.
+func Valid(name rune) bool {
+	return name == Unnamed || name == BlackHole || (name >= '0' && name <= '9') || (name >= 'a' && name <= 'z')
+}
+
+// Get returns the register text and type, the unnamed register reads the system clipboard instead
+// when it's copied outside of the app since the register was set.
+// Text copied outside of the app is linewise when it ends with a line break.
+func Get(name rune) (string, Type) {
+	mu.Lock()
+	e := registers[name]
+	copied := clipboardText
+	mu.Unlock()
+
+	if name == Unnamed {
+		if txt, err := clipboard.Read(); err == nil && txt != "" && txt != copied {
+			typ := Charwise
+			if strings.HasSuffix(txt, "\n") {
+				typ = Linewise
+			}
+			return txt, typ
+		}
+	}
+	return e.text, e.typ
+}
+
+// Yank stores yanked text in the register and the unnamed register.
+// Without a selected register, it's also stored in the yank register and the system clipboard.
+func Yank(name rune, text string, typ Type) {
+	if name == BlackHole {
+		return
+	}
+	if name == Unnamed {
+		set(Yanked, text, typ)
+	}
+	Delete(name, text, typ)
+}
+
+// Delete stores deleted text in the register and the unnamed register.
+// Without a selected register, it's also stored in the system clipboard.
+// The black hole register leaves every register untouched.
+func Delete(name rune, text string, typ Type) {
+	if name == BlackHole {
+		return
+	}
+	if typ == Linewise && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	copied := text
+	if name == Unnamed {
+		clipboard.Write(text)
+	} else {
+		set(name, text, typ)
+		// the clipboard is left as is, it's not copied outside of the app after the unnamed register
+		copied, _ = clipboard.Read()
+	}
+	set(Unnamed, text, typ)
+
+	mu.Lock()
+	clipboardText = copied
+	mu.Unlock()
+}
+
+func set(name rune, text string, typ Type) {
+	mu.Lock()
+	defer mu.Unlock()
+	registers[name] = entry{text: text, typ: typ}
+}
@@ -0,0 +1,191 @@
+// Package snippet is the built-in library of admin queries per SQL dialect, e.g. table sizes or active locks.
+package snippet
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type (
+	// Snippet is a named admin query. Its {name} parameters are filled with the arguments in order,
+	// they're always inside a string literal so the arguments are escaped as one.
+	Snippet struct {
+		Name        string
+		Description string
+		Query       string
+	}
+)
+
+var rgParam = regexp.MustCompile(`\{(\w+)\}`)
+
+var snippets = map[string][]Snippet{
+	"sqlite": {
+		{
+			Name:        "database-size",
+			Description: "size of the database file and its free pages",
+			Query: `SELECT page_count * page_size AS bytes, freelist_count * page_size AS free_bytes
+FROM pragma_page_count(), pragma_page_size(), pragma_freelist_count()`,
+		},
+		{
+			Name:        "index-usage",
+			Description: "indexes of every table and how they were created",
+			Query: `SELECT m.name AS table_name, il.name AS index_name, il."unique", il.origin, il.partial
+FROM sqlite_master m JOIN pragma_index_list(m.name) il
+WHERE m.type = 'table'
+ORDER BY m.name, il.name`,
+		},
+		{
+			Name:        "columns",
+			Description: "columns of a table",
+			Query:       `SELECT * FROM pragma_table_info('{table}')`,
+		},
+		{
+			Name:        "foreign-keys",
+			Description: "foreign keys of a table",
+			Query:       `SELECT * FROM pragma_foreign_key_list('{table}')`,
+		},
+		{
+			Name:        "integrity-check",
+			Description: "database integrity check",
+			Query:       `SELECT * FROM pragma_integrity_check()`,
+		},
+	},
+	"postgres": {
+		{
+			Name:        "table-sizes",
+			Description: "largest tables with their indexes",
+			Query: `SELECT relname AS table_name,
+  pg_size_pretty(pg_total_relation_size(relid)) AS total_size,
+  pg_size_pretty(pg_relation_size(relid)) AS table_size,
+  n_live_tup AS rows
+FROM pg_stat_user_tables
+ORDER BY pg_total_relation_size(relid) DESC`,
+		},
+		{
+			Name:        "locks",
+			Description: "active locks and the queries holding them",
+			Query: `SELECT l.pid, l.locktype, l.relation::regclass AS relation, l.mode, l.granted, a.query
+FROM pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid
+WHERE a.pid <> pg_backend_pid()
+ORDER BY l.granted, l.pid`,
+		},
+		{
+			Name:        "slow-queries",
+			Description: "running queries, the longest first",
+			Query: `SELECT pid, now() - query_start AS duration, state, query
+FROM pg_stat_activity
+WHERE state <> 'idle' AND pid <> pg_backend_pid()
+ORDER BY duration DESC`,
+		},
+		{
+			Name:        "index-usage",
+			Description: "index scans per index, the unused first",
+			Query: `SELECT relname AS table_name, indexrelname AS index_name, idx_scan,
+  pg_size_pretty(pg_relation_size(indexrelid)) AS index_size
+FROM pg_stat_user_indexes
+ORDER BY idx_scan, pg_relation_size(indexrelid) DESC`,
+		},
+		{
+			Name:        "columns",
+			Description: "columns of a table",
+			Query: `SELECT column_name, data_type, is_nullable, column_default
+FROM information_schema.columns
+WHERE table_name = '{table}'
+ORDER BY ordinal_position`,
+		},
+	},
+	"mysql": {
+		{
+			Name:        "table-sizes",
+			Description: "largest tables with their indexes",
+			Query: `SELECT table_name, table_rows AS ` + "`rows`" + `,
+  data_length AS data_bytes, index_length AS index_bytes
+FROM information_schema.tables
+WHERE table_schema = DATABASE()
+ORDER BY data_length + index_length DESC`,
+		},
+		{
+			Name:        "locks",
+			Description: "active locks and the transactions holding them",
+			Query: `SELECT engine_transaction_id, object_name, index_name, lock_type, lock_mode, lock_status
+FROM performance_schema.data_locks`,
+		},
+		{
+			Name:        "slow-queries",
+			Description: "running queries, the longest first",
+			Query: `SELECT id, user, time, state, info
+FROM information_schema.processlist
+WHERE command <> 'Sleep'
+ORDER BY time DESC`,
+		},
+		{
+			Name:        "index-usage",
+			Description: "reads per index, the unused first",
+			Query: `SELECT object_name AS table_name, index_name, count_read, count_write
+FROM performance_schema.table_io_waits_summary_by_index_usage
+WHERE object_schema = DATABASE() AND index_name IS NOT NULL
+ORDER BY count_read`,
+		},
+		{
+			Name:        "columns",
+			Description: "columns of a table",
+			Query: `SELECT column_name, column_type, is_nullable, column_default
+FROM information_schema.columns
+WHERE table_schema = DATABASE() AND table_name = '{table}'
+ORDER BY ordinal_position`,
+		},
+	},
+}
+
+// Names returns the snippet names of the dialect, sorted.
+func Names(dialect string) []string {
+	names := make([]string, len(snippets[dialect]))
+	for i, s := range snippets[dialect] {
+		names[i] = s.Name
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Get returns the snippet of the dialect by its name.
+func Get(dialect, name string) (Snippet, error) {
+	i := slices.IndexFunc(snippets[dialect], func(s Snippet) bool { return s.Name == name })
+	if i < 0 {
+		return Snippet{}, fmt.Errorf("snippet: unknown %s snippet %s, available: %s", dialect, name, strings.Join(Names(dialect), ", "))
+	}
+	return snippets[dialect][i], nil
+}
+
+// Params returns the parameter names of the snippet in the order they're filled.
+func (s Snippet) Params() []string {
+	var params []string
+	for _, m := range rgParam.FindAllStringSubmatch(s.Query, -1) {
+		if !slices.Contains(params, m[1]) {
+			params = append(params, m[1])
+		}
+	}
+	return params
+}
+
+// Usage returns the snippet name followed by its parameters, e.g. "columns {table}".
+func (s Snippet) Usage() string {
+	usage := s.Name
+	for _, p := range s.Params() {
+		usage += " {" + p + "}"
+	}
+	return usage
+}
+
+// Fill returns the query with the parameters replaced by the arguments in order.
+func (s Snippet) Fill(args []string) (string, error) {
+	params := s.Params()
+	if len(args) != len(params) {
+		return "", fmt.Errorf("snippet: usage: %s", s.Usage())
+	}
+	return rgParam.ReplaceAllStringFunc(s.Query, func(m string) string {
+		i := slices.Index(params, m[1:len(m)-1])
+		return strings.ReplaceAll(args[i], "'", "''")
+	}), nil
+}
@@ -0,0 +1,156 @@
+package theme
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+var dark = Theme{
+	Styles: tview.Theme{
+		PrimitiveBackgroundColor:    tcell.ColorBlack,
+		ContrastBackgroundColor:     tcell.ColorBlue,
+		MoreContrastBackgroundColor: tcell.ColorGreen,
+		BorderColor:                 tcell.ColorWhite,
+		TitleColor:                  tcell.ColorWhite,
+		GraphicsColor:               tcell.ColorWhite,
+		PrimaryTextColor:            tcell.ColorWhite,
+		SecondaryTextColor:          tcell.ColorYellow,
+		TertiaryTextColor:           tcell.ColorGreen,
+		InverseTextColor:            tcell.ColorBlue,
+		ContrastSecondaryTextColor:  tcell.ColorNavy,
+	},
+	Syntax: map[string]tcell.Style{
+		"variable":              tcell.StyleDefault.Foreground(tcell.NewHexColor(0xc0caf5)),
+		"function.call":         tcell.StyleDefault.Foreground(tcell.NewHexColor(0x7aa2f7)),
+		"keyword.operator":      tcell.StyleDefault.Foreground(tcell.NewHexColor(0x89ddff)),
+		"keyword":               tcell.StyleDefault.Foreground(tcell.NewHexColor(0x9d7cd8)),
+		"type":                  tcell.StyleDefault.Foreground(tcell.NewHexColor(0x2ac3de)),
+		"variable.member":       tcell.StyleDefault.Foreground(tcell.NewHexColor(0x73daca)),
+		"type.builtin":          tcell.StyleDefault.Foreground(tcell.NewHexColor(0x2ac3de)),
+		"string":                tcell.StyleDefault.Foreground(tcell.NewHexColor(0x9ece6a)),
+		"operator":              tcell.StyleDefault.Foreground(tcell.NewHexColor(0x89ddff)),
+		"keyword.modifier":      tcell.StyleDefault.Foreground(tcell.NewHexColor(0x9d7cd8)),
+		"punctuation.bracket":   tcell.StyleDefault.Foreground(tcell.NewHexColor(0xa9b1d6)),
+		"punctuation.delimiter": tcell.StyleDefault.Foreground(tcell.NewHexColor(0x89ddff)),
+		"error":                 tcell.StyleDefault.Underline(tcell.UnderlineStyleCurly, tcell.ColorRed),
+	},
+	Border:            tcell.ColorGray,
+	FocusedBorder:     tcell.ColorWhite,
+	CursorLine:        tcell.ColorGray,
+	LineNumber:        tcell.ColorSlateGray,
+	CurrentLineNumber: tcell.ColorOrange,
+	ModeNormal:        tcell.ColorLightGray,
+	ModeInsert:        tcell.ColorGreen,
+	ModeReplace:       tcell.ColorPink,
+	Accent:            tcell.ColorYellow,
+	Dim:               tcell.ColorGray,
+	Error:             tcell.ColorRed,
+	HeaderText:        tcell.ColorBlack,
+	HeaderBackground:  tcell.ColorWhite,
+	CursorText:        tcell.ColorBlack,
+	CursorBackground:  tcell.ColorYellow,
+	MarkedText:        tcell.ColorBlack,
+	MarkedBackground:  tcell.ColorLightBlue,
+	MatchText:         tcell.ColorBlack,
+	MatchBackground:   tcell.ColorLightGreen,
+}
+
+var light = Theme{
+	Styles: tview.Theme{
+		PrimitiveBackgroundColor:    tcell.ColorWhite,
+		ContrastBackgroundColor:     tcell.NewHexColor(0xd0d7ff),
+		MoreContrastBackgroundColor: tcell.NewHexColor(0xc4e6c4),
+		BorderColor:                 tcell.ColorBlack,
+		TitleColor:                  tcell.ColorBlack,
+		GraphicsColor:               tcell.ColorBlack,
+		PrimaryTextColor:            tcell.ColorBlack,
+		SecondaryTextColor:          tcell.NewHexColor(0x8c6c00),
+		TertiaryTextColor:           tcell.NewHexColor(0x2e7d32),
+		InverseTextColor:            tcell.ColorWhite,
+		ContrastSecondaryTextColor:  tcell.ColorNavy,
+	},
+	Syntax: map[string]tcell.Style{
+		"variable":              tcell.StyleDefault.Foreground(tcell.NewHexColor(0x3760bf)),
+		"function.call":         tcell.StyleDefault.Foreground(tcell.NewHexColor(0x2e7de9)),
+		"keyword.operator":      tcell.StyleDefault.Foreground(tcell.NewHexColor(0x006a83)),
+		"keyword":               tcell.StyleDefault.Foreground(tcell.NewHexColor(0x7847bd)),
+		"type":                  tcell.StyleDefault.Foreground(tcell.NewHexColor(0x188092)),
+		"variable.member":       tcell.StyleDefault.Foreground(tcell.NewHexColor(0x387068)),
+		"type.builtin":          tcell.StyleDefault.Foreground(tcell.NewHexColor(0x188092)),
+		"string":                tcell.StyleDefault.Foreground(tcell.NewHexColor(0x587539)),
+		"operator":              tcell.StyleDefault.Foreground(tcell.NewHexColor(0x006a83)),
+		"keyword.modifier":      tcell.StyleDefault.Foreground(tcell.NewHexColor(0x7847bd)),
+		"punctuation.bracket":   tcell.StyleDefault.Foreground(tcell.NewHexColor(0x6172b0)),
+		"punctuation.delimiter": tcell.StyleDefault.Foreground(tcell.NewHexColor(0x006a83)),
+		"error":                 tcell.StyleDefault.Underline(tcell.UnderlineStyleCurly, tcell.ColorRed),
+	},
+	Border:            tcell.ColorDarkGray,
+	FocusedBorder:     tcell.ColorBlack,
+	CursorLine:        tcell.NewHexColor(0xe4e4e4),
+	LineNumber:        tcell.ColorGray,
+	CurrentLineNumber: tcell.ColorDarkOrange,
+	ModeNormal:        tcell.ColorDimGray,
+	ModeInsert:        tcell.ColorDarkGreen,
+	ModeReplace:       tcell.ColorPurple,
+	Accent:            tcell.NewHexColor(0x8c6c00),
+	Dim:               tcell.ColorGray,
+	Error:             tcell.ColorDarkRed,
+	HeaderText:        tcell.ColorWhite,
+	HeaderBackground:  tcell.NewHexColor(0x3760bf),
+	CursorText:        tcell.ColorBlack,
+	CursorBackground:  tcell.ColorGold,
+	MarkedText:        tcell.ColorBlack,
+	MarkedBackground:  tcell.NewHexColor(0xb3d4fc),
+	MatchText:         tcell.ColorBlack,
+	MatchBackground:   tcell.NewHexColor(0xc4e6c4),
+}
+
+var highContrast = Theme{
+	Styles: tview.Theme{
+		PrimitiveBackgroundColor:    tcell.ColorBlack,
+		ContrastBackgroundColor:     tcell.ColorNavy,
+		MoreContrastBackgroundColor: tcell.ColorPurple,
+		BorderColor:                 tcell.ColorWhite,
+		TitleColor:                  tcell.ColorYellow,
+		GraphicsColor:               tcell.ColorWhite,
+		PrimaryTextColor:            tcell.ColorWhite,
+		SecondaryTextColor:          tcell.ColorYellow,
+		TertiaryTextColor:           tcell.ColorLime,
+		InverseTextColor:            tcell.ColorBlack,
+		ContrastSecondaryTextColor:  tcell.ColorYellow,
+	},
+	Syntax: map[string]tcell.Style{
+		"variable":              tcell.StyleDefault.Foreground(tcell.ColorWhite),
+		"function.call":         tcell.StyleDefault.Foreground(tcell.ColorAqua),
+		"keyword.operator":      tcell.StyleDefault.Foreground(tcell.ColorYellow).Bold(true),
+		"keyword":               tcell.StyleDefault.Foreground(tcell.ColorYellow).Bold(true),
+		"type":                  tcell.StyleDefault.Foreground(tcell.ColorFuchsia),
+		"variable.member":       tcell.StyleDefault.Foreground(tcell.ColorAqua),
+		"type.builtin":          tcell.StyleDefault.Foreground(tcell.ColorFuchsia),
+		"string":                tcell.StyleDefault.Foreground(tcell.ColorLime),
+		"operator":              tcell.StyleDefault.Foreground(tcell.ColorWhite).Bold(true),
+		"keyword.modifier":      tcell.StyleDefault.Foreground(tcell.ColorYellow).Bold(true),
+		"punctuation.bracket":   tcell.StyleDefault.Foreground(tcell.ColorWhite),
+		"punctuation.delimiter": tcell.StyleDefault.Foreground(tcell.ColorWhite),
+		"error":                 tcell.StyleDefault.Underline(tcell.UnderlineStyleCurly, tcell.ColorRed),
+	},
+	Border:            tcell.ColorWhite,
+	FocusedBorder:     tcell.ColorYellow,
+	CursorLine:        tcell.ColorNavy,
+	LineNumber:        tcell.ColorWhite,
+	CurrentLineNumber: tcell.ColorYellow,
+	ModeNormal:        tcell.ColorWhite,
+	ModeInsert:        tcell.ColorLime,
+	ModeReplace:       tcell.ColorFuchsia,
+	Accent:            tcell.ColorYellow,
+	Dim:               tcell.ColorSilver,
+	Error:             tcell.ColorRed,
+	HeaderText:        tcell.ColorBlack,
+	HeaderBackground:  tcell.ColorWhite,
+	CursorText:        tcell.ColorBlack,
+	CursorBackground:  tcell.ColorYellow,
+	MarkedText:        tcell.ColorBlack,
+	MarkedBackground:  tcell.ColorAqua,
+	MatchText:         tcell.ColorBlack,
+	MatchBackground:   tcell.ColorLime,
+}
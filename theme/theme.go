@@ -0,0 +1,75 @@
+package theme
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+type (
+	// Theme is the palette of every component, Styles is applied to tview primitives, e.g. modals and forms.
+	Theme struct {
+		Syntax            map[string]tcell.Style
+		Styles            tview.Theme
+		Border            tcell.Color
+		FocusedBorder     tcell.Color
+		CursorLine        tcell.Color
+		LineNumber        tcell.Color
+		CurrentLineNumber tcell.Color
+		ModeNormal        tcell.Color
+		ModeInsert        tcell.Color
+		ModeReplace       tcell.Color
+		Accent            tcell.Color
+		Dim               tcell.Color
+		Error             tcell.Color
+		HeaderText        tcell.Color
+		HeaderBackground  tcell.Color
+		CursorText        tcell.Color
+		CursorBackground  tcell.Color
+		MarkedText        tcell.Color
+		MarkedBackground  tcell.Color
+		MatchText         tcell.Color
+		MatchBackground   tcell.Color
+	}
+)
+
+var themes = map[string]*Theme{
+	"dark":          &dark,
+	"light":         &light,
+	"high-contrast": &highContrast,
+}
+
+var current atomic.Pointer[Theme]
+
+func init() {
+	current.Store(&dark)
+}
+
+// Current returns the active theme.
+func Current() *Theme {
+	return current.Load()
+}
+
+// Names returns the built-in theme names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Set activates the theme by name, it also replaces tview.Styles so newly created primitives use it.
+func Set(name string) error {
+	t, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("theme: unknown theme %s, available themes: %v", name, Names())
+	}
+	current.Store(t)
+	tview.Styles = t.Styles
+	return nil
+}
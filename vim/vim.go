@@ -2,8 +2,15 @@ package vim
 
 var (
 	AsyncMotion = [2]int{-23, -57}
+	// FailedMotion is returned by a motion that didn't find its target, e.g. f without the character,
+	// the pending operator is dropped.
+	FailedMotion = [2]int{-31, -73}
 )
 
 func IsAsyncMotion(c [2]int) bool {
 	return c == AsyncMotion
 }
+
+func IsFailedMotion(c [2]int) bool {
+	return c == FailedMotion
+}